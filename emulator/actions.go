@@ -0,0 +1,413 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"bytes"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+	"github.com/LordEliasTM/pseudo-terminal-go/vtparse"
+)
+
+func (s *Screen) handle(a *vtparse.Action) {
+	switch a.Type {
+	case vtparse.Print:
+		s.print(a.Rune)
+	case vtparse.Execute:
+		s.execute(a.Byte)
+	case vtparse.CSIDispatch:
+		s.csi(a)
+	case vtparse.ESCDispatch:
+		s.esc(a)
+	case vtparse.OSCDispatch:
+		if cmd, text, ok := bytes.Cut(a.Data, []byte{';'}); ok && (string(cmd) == "0" || string(cmd) == "2") {
+			s.title = string(text)
+		}
+	}
+}
+
+func (s *Screen) print(r rune) {
+	w := terminal.RuneWidth(r)
+	if w == 0 || w > s.width {
+		return
+	}
+	if s.cur.wrapNext && !s.noAutowrap || s.cur.x+w > s.width {
+		if s.noAutowrap {
+			s.cur.x = s.width - w
+		} else {
+			s.buf.wrapped[s.cur.y] = true
+			s.cur.x = 0
+			s.lineFeed()
+		}
+	}
+	s.cur.wrapNext = false
+
+	row := s.buf.rows[s.cur.y]
+	x := s.cur.x
+	// Overwriting half of a wide rune erases the other half.
+	if row[x].Rune == 0 && x > 0 && terminal.RuneWidth(row[x-1].Rune) == 2 {
+		row[x-1] = Cell{Style: row[x-1].Style}
+	}
+	if end := x + w; end < s.width && row[end].Rune == 0 && terminal.RuneWidth(row[end-1].Rune) == 2 {
+		row[end] = Cell{Style: row[end].Style}
+	}
+	row[x] = Cell{Rune: r, Style: s.cur.style}
+	if w == 2 {
+		row[x+1] = Cell{Style: s.cur.style}
+	}
+	s.dirty[s.cur.y] = true
+
+	s.cur.x += w
+	if s.cur.x >= s.width {
+		s.cur.x = s.width - 1
+		s.cur.wrapNext = true
+	}
+}
+
+func (s *Screen) execute(c byte) {
+	s.cur.wrapNext = false
+	switch c {
+	case '\r':
+		s.cur.x = 0
+	case '\n', '\v', '\f':
+		s.lineFeed()
+	case '\b':
+		s.cur.x = max(s.cur.x-1, 0)
+	case '\t':
+		s.cur.x = min((s.cur.x/8+1)*8, s.width-1)
+	}
+}
+
+// lineFeed moves the cursor down, scrolling the region up when it's at its
+// bottom.
+func (s *Screen) lineFeed() {
+	if s.cur.y == s.bottom {
+		s.scrollUp(1)
+	} else if s.cur.y < s.height-1 {
+		s.cur.y++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling the region down when it's at
+// its top.
+func (s *Screen) reverseIndex() {
+	if s.cur.y == s.top {
+		s.scrollDown(1)
+	} else if s.cur.y > 0 {
+		s.cur.y--
+	}
+}
+
+// scrollUp moves the rows of the scroll region up by n, adding blank rows at
+// its bottom. Rows scrolled off the top of the normal screen are kept in the
+// scrollback.
+func (s *Screen) scrollUp(n int) {
+	if s.top == 0 && !s.AltScreen() {
+		for _, row := range s.buf.rows[:min(n, s.bottom+1)] {
+			s.scrollback.push(row, s.scrollLimit)
+		}
+	}
+	s.deleteRows(s.top, n)
+}
+
+// scrollDown moves the rows of the scroll region down by n, adding blank rows
+// at its top.
+func (s *Screen) scrollDown(n int) {
+	s.insertRows(s.top, n)
+}
+
+// deleteRows removes n rows starting at y, moving the rows below it up to the
+// bottom of the scroll region.
+func (s *Screen) deleteRows(y, n int) {
+	n = min(n, s.bottom-y+1)
+	rows, wrapped := s.buf.rows, s.buf.wrapped
+	for i := y; i <= s.bottom; i++ {
+		if i+n <= s.bottom {
+			rows[i], wrapped[i] = rows[i+n], wrapped[i+n]
+		} else {
+			rows[i], wrapped[i] = make([]Cell, s.width), false
+		}
+		s.dirty[i] = true
+	}
+}
+
+// insertRows inserts n blank rows at y, moving the rows below it down within
+// the scroll region.
+func (s *Screen) insertRows(y, n int) {
+	n = min(n, s.bottom-y+1)
+	rows, wrapped := s.buf.rows, s.buf.wrapped
+	for i := s.bottom; i >= y; i-- {
+		if i-n >= y {
+			rows[i], wrapped[i] = rows[i-n], wrapped[i-n]
+		} else {
+			rows[i], wrapped[i] = make([]Cell, s.width), false
+		}
+		s.dirty[i] = true
+	}
+}
+
+// erase blanks the cells from x0 to x1, exclusive, in row y.
+func (s *Screen) erase(y, x0, x1 int) {
+	row := s.buf.rows[y]
+	for x := max(x0, 0); x < min(x1, s.width); x++ {
+		row[x] = Cell{}
+	}
+	if x1 >= s.width {
+		s.buf.wrapped[y] = false
+	}
+	s.dirty[y] = true
+}
+
+func (s *Screen) moveTo(x, y int) {
+	s.cur.x = min(max(x, 0), s.width-1)
+	s.cur.y = min(max(y, 0), s.height-1)
+	s.cur.wrapNext = false
+}
+
+func (s *Screen) csi(a *vtparse.Action) {
+	if len(a.Intermediates) > 0 {
+		return
+	}
+	if a.Private == '?' {
+		s.privateMode(a)
+		return
+	}
+	if a.Private != 0 {
+		return
+	}
+
+	n := a.Param(0, 1)
+	switch a.Final {
+	case 'A':
+		// Inside the scroll region the cursor stops at its margins.
+		limit := 0
+		if s.cur.y >= s.top {
+			limit = s.top
+		}
+		s.moveTo(s.cur.x, max(s.cur.y-n, limit))
+	case 'B':
+		limit := s.height - 1
+		if s.cur.y <= s.bottom {
+			limit = s.bottom
+		}
+		s.moveTo(s.cur.x, min(s.cur.y+n, limit))
+	case 'C':
+		s.moveTo(s.cur.x+n, s.cur.y)
+	case 'D':
+		s.moveTo(s.cur.x-n, s.cur.y)
+	case 'E':
+		s.moveTo(0, s.cur.y+n)
+	case 'F':
+		s.moveTo(0, s.cur.y-n)
+	case 'G', '`':
+		s.moveTo(n-1, s.cur.y)
+	case 'd':
+		s.moveTo(s.cur.x, n-1)
+	case 'H', 'f':
+		s.moveTo(a.Param(1, 1)-1, n-1)
+	case 'J':
+		s.eraseDisplay(a.Param(0, 0))
+	case 'K':
+		switch a.Param(0, 0) {
+		case 0:
+			s.erase(s.cur.y, s.cur.x, s.width)
+		case 1:
+			s.erase(s.cur.y, 0, s.cur.x+1)
+		case 2:
+			s.erase(s.cur.y, 0, s.width)
+		}
+	case 'X':
+		s.erase(s.cur.y, s.cur.x, s.cur.x+n)
+	case '@':
+		row := s.buf.rows[s.cur.y]
+		if s.cur.x+n < s.width {
+			copy(row[s.cur.x+n:], row[s.cur.x:])
+		}
+		s.erase(s.cur.y, s.cur.x, s.cur.x+n)
+	case 'P':
+		row := s.buf.rows[s.cur.y]
+		if s.cur.x+n < s.width {
+			copy(row[s.cur.x:], row[s.cur.x+n:])
+		}
+		s.erase(s.cur.y, max(s.width-n, s.cur.x), s.width)
+	case 'L', 'M':
+		if s.cur.y < s.top || s.cur.y > s.bottom {
+			return
+		}
+		if a.Final == 'L' {
+			s.insertRows(s.cur.y, n)
+		} else {
+			s.deleteRows(s.cur.y, n)
+		}
+		s.cur.x = 0
+	case 'S':
+		s.scrollUp(n)
+	case 'T':
+		s.scrollDown(n)
+	case 'm':
+		s.sgr(a.Params)
+	case 'r':
+		top, bottom := a.Param(0, 1)-1, a.Param(1, s.height)-1
+		if top < bottom && bottom < s.height {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saved = s.cur
+	case 'u':
+		s.restoreCursor()
+	}
+}
+
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.erase(s.cur.y, s.cur.x, s.width)
+		for y := s.cur.y + 1; y < s.height; y++ {
+			s.erase(y, 0, s.width)
+		}
+	case 1:
+		for y := 0; y < s.cur.y; y++ {
+			s.erase(y, 0, s.width)
+		}
+		s.erase(s.cur.y, 0, s.cur.x+1)
+	case 2, 3:
+		for y := 0; y < s.height; y++ {
+			s.erase(y, 0, s.width)
+		}
+	}
+}
+
+func (s *Screen) privateMode(a *vtparse.Action) {
+	set := a.Final == 'h'
+	if !set && a.Final != 'l' {
+		return
+	}
+	for _, mode := range a.Params {
+		switch mode {
+		case 7:
+			s.noAutowrap = !set
+		case 25:
+			s.hidden = !set
+		case 47, 1047, 1049:
+			s.switchScreen(set, mode == 1049)
+		}
+	}
+}
+
+// switchScreen switches to the alternate screen or back to the normal one.
+// With saveCursor, the cursor is saved before switching to the alternate
+// screen and restored afterwards.
+func (s *Screen) switchScreen(alt, saveCursor bool) {
+	if alt == s.AltScreen() {
+		return
+	}
+	if alt {
+		if saveCursor {
+			s.saved = s.cur
+		}
+		s.alt = newBuffer(s.width, s.height)
+		s.buf = s.alt
+	} else {
+		s.buf, s.alt = s.main, nil
+		if saveCursor {
+			s.restoreCursor()
+		}
+	}
+	s.markAll()
+}
+
+// restoreCursor moves the cursor back to where it was saved, kept within
+// the screen.
+func (s *Screen) restoreCursor() {
+	s.cur = s.saved
+	s.cur.x, s.cur.y = min(s.cur.x, s.width-1), min(s.cur.y, s.height-1)
+}
+
+func (s *Screen) esc(a *vtparse.Action) {
+	if len(a.Intermediates) > 0 {
+		return
+	}
+	switch a.Final {
+	case '7':
+		s.saved = s.cur
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.cur.x = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+// sgr changes the style of the text printed from now on.
+func (s *Screen) sgr(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+	st := &s.cur.style
+	for i := 0; i < len(params); i++ {
+		switch p := params[i]; {
+		case p == 0:
+			*st = terminal.Style{}
+		case p == 1:
+			st.Bold = true
+		case p == 2:
+			st.Faint = true
+		case p == 3:
+			st.Italic = true
+		case p == 4:
+			st.Underline = true
+		case p == 7:
+			st.Reverse = true
+		case p == 22:
+			st.Bold, st.Faint = false, false
+		case p == 23:
+			st.Italic = false
+		case p == 24:
+			st.Underline = false
+		case p == 27:
+			st.Reverse = false
+		case p >= 30 && p <= 37:
+			st.Foreground = terminal.ANSIColor(uint8(p - 30))
+		case p >= 90 && p <= 97:
+			st.Foreground = terminal.ANSIColor(uint8(p - 90 + 8))
+		case p == 39:
+			st.Foreground = terminal.Color{}
+		case p >= 40 && p <= 47:
+			st.Background = terminal.ANSIColor(uint8(p - 40))
+		case p >= 100 && p <= 107:
+			st.Background = terminal.ANSIColor(uint8(p - 100 + 8))
+		case p == 49:
+			st.Background = terminal.Color{}
+		case p == 38 || p == 48:
+			c, n := extendedColor(params[i+1:])
+			i += n
+			if p == 38 {
+				st.Foreground = c
+			} else {
+				st.Background = c
+			}
+		}
+	}
+}
+
+// extendedColor parses the parameters following 38 or 48, selecting a color
+// from the 256-color palette or by its RGB value. It returns the color and
+// the number of parameters used.
+func extendedColor(params []int) (terminal.Color, int) {
+	if len(params) >= 2 && params[0] == 5 {
+		return terminal.Color256(uint8(params[1])), 2
+	}
+	if len(params) >= 4 && params[0] == 2 {
+		return terminal.RGB(uint8(params[1]), uint8(params[2]), uint8(params[3])), 4
+	}
+	return terminal.Color{}, len(params)
+}
@@ -0,0 +1,235 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package emulator implements an in-memory terminal screen. Output written to
+// a Screen, such as that of a program running on a pseudo-terminal, is
+// interpreted like a VT100-compatible terminal would, so that tests and
+// frontends can inspect what the user would see.
+package emulator
+
+import (
+	"strings"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+	"github.com/LordEliasTM/pseudo-terminal-go/vtparse"
+)
+
+// Cell is a character on the screen and its appearance. As in the terminal
+// package, a Rune of 0 is blank, except after a wide rune, which covers the
+// cell following it.
+type Cell = terminal.Cell
+
+// buffer holds the contents of the normal or alternate screen.
+type buffer struct {
+	rows [][]Cell
+	// wrapped records for each row whether its text continues on the next
+	// row because it reached the right margin.
+	wrapped []bool
+}
+
+func newBuffer(width, height int) *buffer {
+	b := &buffer{rows: make([][]Cell, height), wrapped: make([]bool, height)}
+	for y := range b.rows {
+		b.rows[y] = make([]Cell, width)
+	}
+	return b
+}
+
+type cursor struct {
+	x, y  int
+	style terminal.Style
+	// wrapNext is set after a rune was written to the last column. The
+	// cursor stays there until the next rune is printed.
+	wrapNext bool
+}
+
+// Screen is an emulated terminal screen. It is not safe for concurrent use.
+type Screen struct {
+	width, height int
+	parser        *vtparse.Parser
+
+	buf         *buffer
+	main, alt   *buffer
+	cur, saved  cursor
+	hidden      bool
+	noAutowrap  bool
+	top, bottom int
+	title       string
+	// dirty records the rows changed since the last call to ClearDirty.
+	dirty []bool
+
+	scrollback  scrollback
+	scrollLimit int
+}
+
+// New returns a blank screen of the given size.
+func New(width, height int) *Screen {
+	s := &Screen{width: max(width, 1), height: max(height, 1), scrollLimit: defaultScrollback}
+	s.parser = vtparse.NewParser(s.handle)
+	s.reset()
+	return s
+}
+
+func (s *Screen) reset() {
+	s.main = newBuffer(s.width, s.height)
+	s.alt = nil
+	s.buf = s.main
+	s.cur, s.saved = cursor{}, cursor{}
+	s.hidden, s.noAutowrap = false, false
+	s.top, s.bottom = 0, s.height-1
+	s.dirty = make([]bool, s.height)
+	s.markAll()
+}
+
+// Write interprets b as output to the terminal. It never returns an error.
+func (s *Screen) Write(b []byte) (n int, err error) {
+	return s.parser.Write(b)
+}
+
+// Size returns the size of the screen.
+func (s *Screen) Size() (width, height int) {
+	return s.width, s.height
+}
+
+// Cursor returns the position of the cursor, starting from 0,0 at the top
+// left, and whether it's visible.
+func (s *Screen) Cursor() (x, y int, visible bool) {
+	return s.cur.x, s.cur.y, !s.hidden
+}
+
+// Title returns the window title last set with OSC 0 or 2.
+func (s *Screen) Title() string {
+	return s.title
+}
+
+// AltScreen reports whether the alternate screen, used by full-screen
+// programs, is shown.
+func (s *Screen) AltScreen() bool {
+	return s.buf == s.alt
+}
+
+// Cell returns the cell at x, y.
+func (s *Screen) Cell(x, y int) Cell {
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return Cell{}
+	}
+	return s.buf.rows[y][x]
+}
+
+// Line returns the text shown in row y, without trailing blanks.
+func (s *Screen) Line(y int) string {
+	if y < 0 || y >= s.height {
+		return ""
+	}
+	return rowText(s.buf.rows[y])
+}
+
+func rowText(row []Cell) string {
+	var b strings.Builder
+	wide := false
+	for _, c := range row {
+		switch {
+		case c.Rune != 0:
+			b.WriteRune(c.Rune)
+		case !wide:
+			b.WriteByte(' ')
+		}
+		wide = terminal.RuneWidth(c.Rune) == 2
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Lines returns the text shown in each row, up to the last one that isn't
+// empty.
+func (s *Screen) Lines() []string {
+	var lines []string
+	for y := 0; y < s.height; y++ {
+		lines = append(lines, s.Line(y))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// String returns the rows returned by Lines separated by newlines.
+func (s *Screen) String() string {
+	return strings.Join(s.Lines(), "\n")
+}
+
+// Dirty returns the rows changed since the last call to ClearDirty, in
+// order from the top.
+func (s *Screen) Dirty() []int {
+	var rows []int
+	for y, d := range s.dirty {
+		if d {
+			rows = append(rows, y)
+		}
+	}
+	return rows
+}
+
+// ClearDirty marks all rows as unchanged.
+func (s *Screen) ClearDirty() {
+	clear(s.dirty)
+}
+
+func (s *Screen) markAll() {
+	for y := range s.dirty {
+		s.dirty[y] = true
+	}
+}
+
+// Resize changes the size of the screen. When the width changes, the lines of
+// the normal screen that were wrapped at the right margin are wrapped again at
+// the new one, like modern terminals do, rather than cut off. Rows that no
+// longer fit above the cursor are dropped from the top. The alternate screen
+// is cut off or extended at the bottom and right, as full-screen programs
+// redraw it anyway.
+func (s *Screen) Resize(width, height int) {
+	width, height = max(width, 1), max(height, 1)
+	onAlt := s.AltScreen()
+
+	x, y := s.cur.x, s.cur.y
+	if onAlt {
+		x, y = s.saved.x, s.saved.y
+	}
+	var rows [][]Cell
+	var wrapped []bool
+	if width != s.width {
+		rows, wrapped, x, y = reflow(s.main, width, x, y)
+	} else {
+		rows, wrapped = s.main.rows, s.main.wrapped
+	}
+	if drop := y - height + 1; drop > 0 {
+		for _, row := range rows[:drop] {
+			s.scrollback.push(row, s.scrollLimit)
+		}
+		rows, wrapped, y = rows[drop:], wrapped[drop:], y-drop
+	}
+	s.main = newBuffer(width, height)
+	for i := 0; i < min(height, len(rows)); i++ {
+		copy(s.main.rows[i], rows[i])
+		s.main.wrapped[i] = wrapped[i]
+	}
+	if onAlt {
+		s.saved.x, s.saved.y = x, y
+		alt := newBuffer(width, height)
+		for i := 0; i < min(height, s.height); i++ {
+			copy(alt.rows[i], s.alt.rows[i])
+		}
+		s.alt, s.buf = alt, alt
+		x, y = s.cur.x, s.cur.y
+	} else {
+		s.buf = s.main
+	}
+
+	s.width, s.height = width, height
+	s.top, s.bottom = 0, height-1
+	s.cur.x, s.cur.y = min(x, width-1), min(y, height-1)
+	s.cur.wrapNext = false
+	s.saved.x, s.saved.y = min(s.saved.x, width-1), min(s.saved.y, height-1)
+	s.dirty = make([]bool, height)
+	s.markAll()
+}
@@ -0,0 +1,107 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+var screenTests = []struct {
+	out   string
+	lines []string
+	x, y  int
+}{
+	{"abc\r\ndef", []string{"abc", "def"}, 3, 1},
+	{"abc\x1b[2D\x1b[K", []string{"a"}, 1, 0},
+	{"abc\x1b[1;2Hx", []string{"axc"}, 2, 0},
+	{"日本\bx", []string{"日 x"}, 4, 0},
+	{"abcde", []string{"abcde"}, 4, 0},
+	{"abcdef", []string{"abcde", "f"}, 1, 1},
+	{"1\r\n2\r\n3\r\n4\r\n5", []string{"2", "3", "4", "5"}, 1, 3},
+	{"abcde\x1b[3G\x1b[2P", []string{"abe"}, 2, 0},
+	{"abcde\x1b[2G\x1b[2@", []string{"a  bc"}, 1, 0},
+	{"a\r\nb\r\nc\x1b[2;1H\x1b[L", []string{"a", "", "b", "c"}, 0, 1},
+	{"a\r\nb\r\nc\x1b[1;1H\x1b[M", []string{"b", "c"}, 0, 0},
+	{"a\r\nb\r\nc\x1b[H\x1bM", []string{"", "a", "b", "c"}, 0, 0},
+	// Scrolling only moves the rows of the scroll region.
+	{"1\r\n2\r\n3\r\n4\x1b[2;3r\x1b[3;1H\nx", []string{"1", "3", "x", "4"}, 1, 2},
+	{"ab\x1b[?1049hxy\x1b[?1049lc", []string{"abc"}, 3, 0},
+	{"ab\x1b7\r\ncd\x1b8e", []string{"abe", "cd"}, 3, 0},
+	{"\x1b]2;title\x07ok", []string{"ok"}, 2, 0},
+	{"abcde\x1b[2J", []string{}, 4, 0},
+}
+
+func TestScreen(t *testing.T) {
+	for i, test := range screenTests {
+		s := New(5, 4)
+		for j := 0; j < len(test.out); j++ {
+			s.Write([]byte{test.out[j]})
+		}
+		if got := s.Lines(); !reflect.DeepEqual(got, test.lines) {
+			t.Errorf("test %d: got %q, expected %q", i, got, test.lines)
+		}
+		if x, y, _ := s.Cursor(); x != test.x || y != test.y {
+			t.Errorf("test %d: cursor at %d,%d, expected %d,%d", i, x, y, test.x, test.y)
+		}
+	}
+}
+
+func TestStyle(t *testing.T) {
+	s := New(10, 2)
+	s.Write([]byte("\x1b[1;31ma\x1b[38;5;200;48;2;1;2;3mb\x1b[0mc"))
+	want := []terminal.Style{
+		{Bold: true, Foreground: terminal.ANSIColor(1)},
+		{Bold: true, Foreground: terminal.Color256(200), Background: terminal.RGB(1, 2, 3)},
+		{},
+	}
+	for x, st := range want {
+		if got := s.Cell(x, 0).Style; got != st {
+			t.Errorf("cell %d: got %+v, expected %+v", x, got, st)
+		}
+	}
+}
+
+func TestCursorAndTitle(t *testing.T) {
+	s := New(10, 2)
+	s.Write([]byte("\x1b[?25l\x1b]0;hello\x1b\\"))
+	if _, _, visible := s.Cursor(); visible {
+		t.Errorf("cursor visible after ESC[?25l")
+	}
+	if s.Title() != "hello" {
+		t.Errorf("got title %q, expected hello", s.Title())
+	}
+}
+
+func TestDirty(t *testing.T) {
+	s := New(10, 4)
+	s.ClearDirty()
+	s.Write([]byte("\x1b[3;1Hx"))
+	if got := s.Dirty(); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("got dirty rows %v, expected [2]", got)
+	}
+	s.ClearDirty()
+	if got := s.Dirty(); len(got) != 0 {
+		t.Errorf("got dirty rows %v after ClearDirty", got)
+	}
+}
+
+// TestTerminal checks that what the terminal package draws comes out as
+// expected.
+func TestTerminal(t *testing.T) {
+	s := New(20, 5)
+	term := terminal.NewTerminal(struct {
+		*strings.Reader
+		*Screen
+	}{strings.NewReader("hello\x1b[D\x1b[DX\r"), s}, "> ", true)
+	term.SetSize(20, 5)
+	term.ReadLine()
+	if got, want := s.String(), "> helXlo"; got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import "github.com/LordEliasTM/pseudo-terminal-go/terminal"
+
+// reflow joins the rows of b that were wrapped at the right margin into
+// logical lines and wraps them again at width. It returns the new rows and
+// the position of the cursor, which was at x, y, in them. Blank lines below
+// the cursor are dropped.
+func reflow(b *buffer, width, x, y int) (rows [][]Cell, wrapped []bool, nx, ny int) {
+	nx, ny = -1, -1
+	var line []Cell
+	// cursor is the offset of the cursor in line, or -1 if it's in
+	// another line.
+	cursor := -1
+	flush := func() {
+		start := len(rows)
+		lineRows, counts := wrapLine(line, width)
+		for j, r := range lineRows {
+			rows = append(rows, r)
+			wrapped = append(wrapped, j < len(lineRows)-1)
+		}
+		if cursor >= 0 {
+			nx, ny = locate(counts, cursor, width)
+			ny += start
+		}
+		line, cursor = line[:0], -1
+	}
+	for i, row := range b.rows {
+		if i == y {
+			cursor = len(line) + x
+		}
+		if b.wrapped[i] {
+			n := len(row)
+			if row[n-1] == (Cell{}) && i+1 < len(b.rows) && terminal.RuneWidth(b.rows[i+1][0].Rune) == 2 {
+				// The last cell was left blank because the wide
+				// rune starting the next row didn't fit.
+				n--
+			}
+			line = append(line, row[:n]...)
+			continue
+		}
+		line = append(line, row[:usedWidth(row)]...)
+		flush()
+	}
+	if len(line) > 0 || cursor >= 0 {
+		// The last row was wrapped, as it is when text reached the
+		// right margin at the bottom of a scroll region that doesn't
+		// extend to the bottom of the screen.
+		flush()
+	}
+	nx, ny = max(nx, 0), max(ny, 0)
+
+	// Drop blank rows at the bottom, but not the cursor's.
+	for len(rows) > ny+1 && usedWidth(rows[len(rows)-1]) == 0 {
+		rows, wrapped = rows[:len(rows)-1], wrapped[:len(wrapped)-1]
+	}
+	return rows, wrapped, nx, ny
+}
+
+// usedWidth returns the number of cells of row up to its last one that isn't
+// blank.
+func usedWidth(row []Cell) int {
+	n := len(row)
+	for n > 0 && row[n-1] == (Cell{}) {
+		n--
+	}
+	if n > 0 && n < len(row) && terminal.RuneWidth(row[n-1].Rune) == 2 {
+		// Keep the cell covered by a wide rune.
+		n++
+	}
+	return n
+}
+
+// wrapLine splits line into rows of width cells and returns them along with
+// the number of cells of line in each. A wide rune that doesn't fit at the
+// end of a row starts the next one.
+func wrapLine(line []Cell, width int) (rows [][]Cell, counts []int) {
+	for {
+		row := make([]Cell, width)
+		n := min(len(line), width)
+		if n == width && n < len(line) && n > 1 && terminal.RuneWidth(line[n-1].Rune) == 2 {
+			n--
+		}
+		copy(row, line[:n])
+		rows, counts = append(rows, row), append(counts, n)
+		line = line[n:]
+		if len(line) == 0 {
+			return rows, counts
+		}
+	}
+}
+
+// locate returns the position of the cell at offset in a line wrapped into
+// rows holding counts cells each.
+func locate(counts []int, offset, width int) (x, y int) {
+	for y = 0; y < len(counts)-1 && offset >= counts[y]; y++ {
+		offset -= counts[y]
+	}
+	return min(offset, width-1), y
+}
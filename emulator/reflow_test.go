@@ -0,0 +1,87 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var reflowTests = []struct {
+	out           string
+	width, height int
+	lines         []string
+	x, y          int
+}{
+	// A wrapped line is joined when the screen gets wider.
+	{"abcdefgh\r\nxy", 10, 4, []string{"abcdefgh", "xy"}, 2, 1},
+	// And wrapped again when it gets narrower.
+	{"abcdefgh\r\nxy", 3, 6, []string{"abc", "def", "gh", "xy"}, 2, 3},
+	// Lines ended by a line feed stay separate.
+	{"abc\r\ndef", 10, 4, []string{"abc", "def"}, 3, 1},
+	// The cursor stays on the same character.
+	{"abcdefgh\x1b[2;2H", 8, 4, []string{"abcdefgh"}, 6, 0},
+	// Rows that don't fit above the cursor are dropped.
+	{"abcdefgh\r\nxy", 2, 3, []string{"ef", "gh", "xy"}, 1, 2},
+	// Wide runes aren't split.
+	{"ab日本", 3, 4, []string{"ab", "日", "本"}, 2, 2},
+	{"ab日本", 6, 4, []string{"ab日本"}, 5, 0},
+}
+
+func TestReflow(t *testing.T) {
+	for i, test := range reflowTests {
+		s := New(5, 4)
+		s.Write([]byte(test.out))
+		s.Resize(test.width, test.height)
+		if got := s.Lines(); !reflect.DeepEqual(got, test.lines) {
+			t.Errorf("test %d: got %q, expected %q", i, got, test.lines)
+		}
+		if x, y, _ := s.Cursor(); x != test.x || y != test.y {
+			t.Errorf("test %d: cursor at %d,%d, expected %d,%d", i, x, y, test.x, test.y)
+		}
+	}
+}
+
+func TestResizeAltScreen(t *testing.T) {
+	s := New(5, 4)
+	s.Write([]byte("abcdefgh\x1b[?1049h\x1b[Hxyz"))
+	s.Resize(10, 4)
+	if got := s.Lines(); !reflect.DeepEqual(got, []string{"xyz"}) {
+		t.Errorf("got %q on the alternate screen", got)
+	}
+	s.Write([]byte("\x1b[?1049l"))
+	if got := s.Lines(); !reflect.DeepEqual(got, []string{"abcdefgh"}) {
+		t.Errorf("got %q after leaving the alternate screen", got)
+	}
+}
+
+func TestResizeSavedCursor(t *testing.T) {
+	s := New(80, 24)
+	s.Write([]byte("\x1b[24;80H\x1b7"))
+	s.Resize(40, 10)
+	s.Write([]byte("\x1b8x"))
+	if x, y, _ := s.Cursor(); x != 39 || y != 9 {
+		t.Errorf("cursor at %d,%d, expected the saved cursor to be kept on the screen", x, y)
+	}
+	if c := s.Cell(39, 9); c.Rune != 'x' {
+		t.Errorf("got %q in the bottom right corner", c.Rune)
+	}
+}
+
+func TestReflowWrappedLastRow(t *testing.T) {
+	s := New(20, 5)
+	s.Write([]byte("\x1b[1;3r\x1b[5;1H" + strings.Repeat("a", 25)))
+	s.Resize(10, 5)
+	s.Write([]byte("x"))
+	// The 5 a's that didn't fit overwrote the start of the row.
+	want := []string{"", "", "", "", "aaaaaxaaaa"}
+	if got := s.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+	if x, y, _ := s.Cursor(); x != 6 || y != 4 {
+		t.Errorf("cursor at %d,%d, expected 6,4", x, y)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Render returns the output that draws the screen, including the styles of
+// its cells and the position and visibility of the cursor, on a terminal of
+// the same size. It's used to show the screen to a client that attaches to a
+// running program.
+func (s *Screen) Render() []byte {
+	var b strings.Builder
+	b.WriteString("\x1b[0m\x1b[H\x1b[2J")
+	for y, row := range s.buf.rows {
+		if usedWidth(row) == 0 {
+			continue
+		}
+		b.WriteString("\x1b[" + strconv.Itoa(y+1) + ";1H")
+		var run strings.Builder
+		style := terminal.Style{}
+		for x, c := range row[:usedWidth(row)] {
+			if c.Style != style {
+				b.WriteString(style.Render(terminal.TrueColor, run.String()))
+				run.Reset()
+				style = c.Style
+			}
+			switch {
+			case c.Rune != 0:
+				run.WriteRune(c.Rune)
+			case x == 0 || terminal.RuneWidth(row[x-1].Rune) != 2:
+				run.WriteByte(' ')
+			}
+		}
+		b.WriteString(style.Render(terminal.TrueColor, run.String()))
+	}
+	b.WriteString("\x1b[" + strconv.Itoa(s.cur.y+1) + ";" + strconv.Itoa(s.cur.x+1) + "H")
+	if s.hidden {
+		b.WriteString("\x1b[?25l")
+	} else {
+		b.WriteString("\x1b[?25h")
+	}
+	return []byte(b.String())
+}
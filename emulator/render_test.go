@@ -0,0 +1,29 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	s := New(10, 4)
+	s.Write([]byte("ab\x1b[1;31mcd\x1b[0m\r\n\r\n日本\x1b[2;3H"))
+
+	replay := New(10, 4)
+	replay.Write(s.Render())
+	if got, want := replay.Lines(), s.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+	for x := 0; x < 4; x++ {
+		if got, want := replay.Cell(x, 0), s.Cell(x, 0); got != want {
+			t.Errorf("cell %d: got %+v, expected %+v", x, got, want)
+		}
+	}
+	if x, y, _ := replay.Cursor(); x != 2 || y != 1 {
+		t.Errorf("cursor at %d,%d, expected 2,1", x, y)
+	}
+}
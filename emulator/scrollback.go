@@ -0,0 +1,114 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import "regexp"
+
+// defaultScrollback is the number of rows kept in the scrollback unless set
+// otherwise with SetScrollback.
+const defaultScrollback = 1000
+
+// scrollback is a ring of the rows that scrolled off the top of the normal
+// screen, oldest first.
+type scrollback struct {
+	rows  [][]Cell
+	start int
+	n     int
+}
+
+func (b *scrollback) push(row []Cell, limit int) {
+	if limit <= 0 {
+		return
+	}
+	if len(b.rows) != limit {
+		b.resize(limit)
+	}
+	if b.n < limit {
+		b.rows[(b.start+b.n)%limit] = row
+		b.n++
+		return
+	}
+	b.rows[b.start] = row
+	b.start = (b.start + 1) % limit
+}
+
+// resize keeps the newest limit rows.
+func (b *scrollback) resize(limit int) {
+	rows := make([][]Cell, limit)
+	keep := min(b.n, limit)
+	for i := 0; i < keep; i++ {
+		rows[i] = b.row(b.n - keep + i)
+	}
+	b.rows, b.start, b.n = rows, 0, keep
+}
+
+func (b *scrollback) row(i int) []Cell {
+	return b.rows[(b.start+i)%len(b.rows)]
+}
+
+// SetScrollback sets the number of rows kept after they scroll off the top
+// of the normal screen. The default is 1000; 0 turns the scrollback off.
+//
+// A Terminal's own output can be kept the same way by passing a Screen to
+// its SetTranscript method.
+func (s *Screen) SetScrollback(rows int) {
+	s.scrollLimit = max(rows, 0)
+	if s.scrollLimit == 0 {
+		s.scrollback = scrollback{}
+	} else {
+		s.scrollback.resize(s.scrollLimit)
+	}
+}
+
+// ScrollbackLen returns the number of rows in the scrollback.
+func (s *Screen) ScrollbackLen() int {
+	return s.scrollback.n
+}
+
+// HistoryLine returns the text of row i of the history, which is the
+// scrollback, oldest row first, followed by the rows of the screen.
+func (s *Screen) HistoryLine(i int) string {
+	if i < 0 {
+		return ""
+	}
+	if i < s.scrollback.n {
+		return rowText(s.scrollback.row(i))
+	}
+	return s.Line(i - s.scrollback.n)
+}
+
+// View returns the rows that fill the screen when scrolled back by offset
+// rows, for paging through the history like Shift-PageUp does. An offset of 0
+// is the screen itself; larger ones are limited to the size of the
+// scrollback.
+func (s *Screen) View(offset int) []string {
+	offset = min(max(offset, 0), s.scrollback.n)
+	first := s.scrollback.n - offset
+	lines := make([]string, s.height)
+	for i := range lines {
+		lines[i] = s.HistoryLine(first + i)
+	}
+	return lines
+}
+
+// Match is a match found by Search. Row is the row of the history, as for
+// HistoryLine, and Start and End are the byte offsets of the match in its
+// text.
+type Match struct {
+	Row        int
+	Start, End int
+}
+
+// Search returns the matches of re in the history, in order from the oldest.
+// Each row is searched separately.
+func (s *Screen) Search(re *regexp.Regexp) []Match {
+	var matches []Match
+	for i := 0; i < s.scrollback.n+s.height; i++ {
+		for _, m := range re.FindAllStringIndex(s.HistoryLine(i), -1) {
+			matches = append(matches, Match{i, m[0], m[1]})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,92 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+func writeLines(s *Screen, n int) {
+	for i := 1; i <= n; i++ {
+		if i > 1 {
+			s.Write([]byte("\r\n"))
+		}
+		fmt.Fprintf(s, "line %d", i)
+	}
+}
+
+func TestScrollback(t *testing.T) {
+	s := New(10, 3)
+	writeLines(s, 6)
+	if n := s.ScrollbackLen(); n != 3 {
+		t.Fatalf("got %d rows of scrollback, expected 3", n)
+	}
+	if got, want := s.View(0), []string{"line 4", "line 5", "line 6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+	if got, want := s.View(2), []string{"line 2", "line 3", "line 4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+	if got, want := s.View(100), []string{"line 1", "line 2", "line 3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
+
+func TestScrollbackLimit(t *testing.T) {
+	s := New(10, 2)
+	s.SetScrollback(3)
+	writeLines(s, 10)
+	if n := s.ScrollbackLen(); n != 3 {
+		t.Fatalf("got %d rows of scrollback, expected 3", n)
+	}
+	if got := s.HistoryLine(0); got != "line 6" {
+		t.Errorf("got oldest row %q, expected line 6", got)
+	}
+
+	s.SetScrollback(1)
+	if got := s.HistoryLine(0); got != "line 8" || s.ScrollbackLen() != 1 {
+		t.Errorf("got oldest row %q after shrinking, expected line 8", got)
+	}
+}
+
+func TestScrollbackAltScreen(t *testing.T) {
+	s := New(10, 2)
+	s.Write([]byte("\x1b[?1049h"))
+	writeLines(s, 5)
+	if n := s.ScrollbackLen(); n != 0 {
+		t.Errorf("alternate screen added %d rows to the scrollback", n)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := New(10, 3)
+	writeLines(s, 12)
+	got := s.Search(regexp.MustCompile(`1\d?`))
+	want := []Match{{0, 5, 6}, {9, 5, 7}, {10, 5, 7}, {11, 5, 7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+}
+
+func TestTerminalScrollback(t *testing.T) {
+	s := New(20, 2)
+	conn := struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(""), io.Discard}
+	term := terminal.NewTerminal(conn, "> ", true)
+	term.SetTranscript(s, nil)
+	term.Write([]byte("one\r\ntwo\r\nthree\r\n"))
+	if got := s.HistoryLine(0); got != "one" {
+		t.Errorf("got %q, expected the terminal's output in the scrollback", got)
+	}
+}
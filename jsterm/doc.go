@@ -0,0 +1,16 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsterm runs a Terminal in the browser, when compiled with
+// GOOS=js GOARCH=wasm, on an xterm.js terminal:
+//
+//	term := jsterm.NewTerminal(js.Global().Get("term"), "> ")
+//	for {
+//		line, err := term.ReadLine()
+//		...
+//	}
+//
+// The line editor runs in Go, so xterm.js is used without a local echo or
+// line discipline of its own. On other systems the package is empty.
+package jsterm
@@ -0,0 +1,162 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build js && wasm
+
+package jsterm
+
+import (
+	"io"
+	"sync"
+	"syscall/js"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Conn exchanges bytes with an xterm.js Terminal: what's read from it is what
+// the user typed, and what's written to it is displayed.
+type Conn struct {
+	xterm js.Value
+
+	lock sync.Mutex
+	// input holds what was typed and not read yet, and ready is signalled
+	// when there's some, or the Conn is closed.
+	input  []byte
+	ready  chan struct{}
+	closed bool
+
+	resized func(width, height int)
+	// disposables are the xterm.js subscriptions and funcs are the
+	// callbacks, to be released by Close.
+	disposables []js.Value
+	funcs       []js.Func
+}
+
+// NewConn returns a Conn on xterm, a Terminal of xterm.js.
+func NewConn(xterm js.Value) *Conn {
+	c := &Conn{xterm: xterm, ready: make(chan struct{}, 1)}
+	onData := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// xterm.js passes the input as a string, which may hold runes
+		// of any size.
+		c.typed([]byte(args[0].String()))
+		return nil
+	})
+	onResize := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		c.lock.Lock()
+		f := c.resized
+		c.lock.Unlock()
+		if f != nil {
+			// The callback mustn't block the browser's event loop.
+			go f(args[0].Get("cols").Int(), args[0].Get("rows").Int())
+		}
+		return nil
+	})
+	c.funcs = []js.Func{onData, onResize}
+	c.disposables = []js.Value{
+		xterm.Call("onData", onData),
+		xterm.Call("onResize", onResize),
+	}
+	return c
+}
+
+// typed adds input, without blocking.
+func (c *Conn) typed(b []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.input = append(c.input, b...)
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// NewTerminal returns a Terminal using prompt on a new Conn on xterm. Its size
+// follows the size of xterm.
+func NewTerminal(xterm js.Value, prompt string) *terminal.Terminal {
+	c := NewConn(xterm)
+	t := terminal.NewTerminal(c, prompt, true)
+	// xterm.js doesn't turn "\n" into "\r\n" unless it's told to.
+	t.SetTranslateNewlines(true)
+	t.SetSize(c.Size())
+	c.OnResize(func(width, height int) {
+		t.SetSize(width, height)
+		t.Refresh()
+	})
+	return t
+}
+
+// Size returns the number of columns and rows of the xterm.js terminal.
+func (c *Conn) Size() (width, height int) {
+	return c.xterm.Get("cols").Int(), c.xterm.Get("rows").Int()
+}
+
+// OnResize sets a function to be called when the xterm.js terminal is
+// resized.
+func (c *Conn) OnResize(f func(width, height int)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.resized = f
+}
+
+// Read waits for the user to type something. It returns io.EOF after Close.
+func (c *Conn) Read(b []byte) (n int, err error) {
+	for {
+		c.lock.Lock()
+		if len(c.input) > 0 {
+			n = copy(b, c.input)
+			c.input = c.input[:copy(c.input, c.input[n:])]
+			if len(c.input) > 0 {
+				// Let the next Read through.
+				select {
+				case c.ready <- struct{}{}:
+				default:
+				}
+			}
+			c.lock.Unlock()
+			return n, nil
+		}
+		if c.closed {
+			c.lock.Unlock()
+			return 0, io.EOF
+		}
+		c.lock.Unlock()
+		<-c.ready
+	}
+}
+
+// Write displays b.
+func (c *Conn) Write(b []byte) (n int, err error) {
+	data := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(data, b)
+	c.xterm.Call("write", data)
+	return len(b), nil
+}
+
+// Close stops listening to the xterm.js terminal, which is left open, and
+// makes Read return io.EOF.
+func (c *Conn) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	for _, d := range c.disposables {
+		d.Call("dispose")
+	}
+	for _, f := range c.funcs {
+		f.Release()
+	}
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
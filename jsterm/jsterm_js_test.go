@@ -0,0 +1,90 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build js && wasm
+
+package jsterm
+
+import (
+	"io"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+// fakeXterm has the parts of an xterm.js Terminal that Conn uses.
+const fakeXterm = `({
+	cols: 20,
+	rows: 5,
+	out: "",
+	disposed: 0,
+	onData(cb) { this.data = cb; return {dispose: () => this.disposed++}; },
+	onResize(cb) { this.resize = cb; return {dispose: () => this.disposed++}; },
+	write(b) { this.out += new TextDecoder().decode(b); },
+})`
+
+func TestTerminal(t *testing.T) {
+	xterm := js.Global().Call("eval", fakeXterm)
+	term := NewTerminal(xterm, "> ")
+	lines := make(chan string)
+	go func() {
+		line, _ := term.ReadLine()
+		lines <- line
+	}()
+	xterm.Call("data", "hello")
+	xterm.Call("data", "\r")
+	select {
+	case line := <-lines:
+		if line != "hello" {
+			t.Errorf("got %q, expected hello", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+
+	term.Write([]byte("bye\n"))
+	if got, want := xterm.Get("out").String(), "> hello\r\nbye\r\n"; got != want {
+		t.Errorf("displayed %q, expected %q", got, want)
+	}
+}
+
+func TestResize(t *testing.T) {
+	xterm := js.Global().Call("eval", fakeXterm)
+	c := NewConn(xterm)
+	if w, h := c.Size(); w != 20 || h != 5 {
+		t.Errorf("got size %dx%d, expected 20x5", w, h)
+	}
+	sizes := make(chan [2]int, 1)
+	c.OnResize(func(width, height int) { sizes <- [2]int{width, height} })
+	size := js.Global().Get("Object").New()
+	size.Set("cols", 30)
+	size.Set("rows", 10)
+	xterm.Call("resize", size)
+	if got := <-sizes; got != [2]int{30, 10} {
+		t.Errorf("got %v, expected 30x10", got)
+	}
+}
+
+func TestClose(t *testing.T) {
+	xterm := js.Global().Call("eval", fakeXterm)
+	c := NewConn(xterm)
+	xterm.Call("data", "ab")
+	errc := make(chan error)
+	go func() {
+		b := make([]byte, 1)
+		var err error
+		for err == nil {
+			_, err = c.Read(b)
+		}
+		errc <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	if err := <-errc; err != io.EOF {
+		t.Errorf("Read returned %v after Close, expected io.EOF", err)
+	}
+	if n := xterm.Get("disposed").Int(); n != 2 {
+		t.Errorf("%d subscriptions disposed, expected 2", n)
+	}
+}
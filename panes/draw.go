@@ -0,0 +1,103 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package panes
+
+import "github.com/LordEliasTM/pseudo-terminal-go/terminal"
+
+// layout sets the area of n and its children. Panes are separated by a
+// border one cell wide.
+func layout(n *node, x, y, width, height int) {
+	n.x, n.y, n.width, n.height = x, y, width, height
+	if n.pane != nil {
+		return
+	}
+	size := height
+	if n.vertical {
+		size = width
+	}
+	first := min(max(int(n.ratio*float64(size-1)+0.5), 1), size-2)
+	if size < 3 {
+		first = max(size-1, 0)
+	}
+	if n.vertical {
+		layout(n.a, x, y, first, height)
+		layout(n.b, x+first+1, y, max(width-first-1, 0), height)
+	} else {
+		layout(n.a, x, y, width, first)
+		layout(n.b, x, y+first+1, width, max(height-first-1, 0))
+	}
+}
+
+// Draw updates the terminal to show the panes.
+func (m *Mux) Draw() error {
+	m.lock.Lock()
+	var resized []func()
+	if m.root != nil {
+		width, height := m.screen.Size()
+		layout(m.root, 0, 0, width, height)
+		m.drawNode(m.root, &resized)
+	} else {
+		m.screen.Fill(' ', terminal.Style{})
+		m.screen.SetCursor(-1, -1)
+	}
+	err := m.screen.Flush()
+	m.lock.Unlock()
+
+	// The panes may draw themselves again in response, so this is done
+	// last.
+	for _, f := range resized {
+		f()
+	}
+	return err
+}
+
+// drawNode draws the panes and borders of n. m.lock must be held.
+func (m *Mux) drawNode(n *node, resized *[]func()) {
+	if n.pane == nil {
+		m.drawNode(n.a, resized)
+		m.drawNode(n.b, resized)
+		if n.vertical {
+			x := n.a.x + n.a.width
+			for y := n.y; y < n.y+n.height; y++ {
+				m.screen.SetCell(x, y, '│', m.BorderStyle)
+			}
+		} else {
+			y := n.a.y + n.a.height
+			for x := n.x; x < n.x+n.width; x++ {
+				m.screen.SetCell(x, y, '─', m.BorderStyle)
+			}
+		}
+		return
+	}
+
+	p := n.pane
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if w, h := p.screen.Size(); (w != n.width || h != n.height) && n.width > 0 && n.height > 0 {
+		p.screen.Resize(n.width, n.height)
+		if f := p.resized; f != nil {
+			width, height := n.width, n.height
+			*resized = append(*resized, func() { f(width, height) })
+		}
+	}
+	for y := 0; y < n.height; y++ {
+		for x := 0; x < n.width; x++ {
+			c := p.screen.Cell(x, y)
+			if c.Rune == 0 && x > 0 && terminal.RuneWidth(p.screen.Cell(x-1, y).Rune) == 2 {
+				// Covered by the wide rune to the left.
+				continue
+			}
+			m.screen.SetCell(n.x+x, n.y+y, c.Rune, c.Style)
+		}
+	}
+	if n == m.focus {
+		if x, y, visible := p.screen.Cursor(); visible {
+			m.screen.SetCursor(n.x+x, n.y+y)
+		} else {
+			m.screen.SetCursor(-1, -1)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package panes
+
+import "github.com/LordEliasTM/pseudo-terminal-go/terminal"
+
+// resizeStep is the number of cells a border is moved by a key binding.
+const resizeStep = 2
+
+// Run reads keys from the terminal and passes them to the pane with the focus,
+// or carries out key bindings. It returns when the last pane is removed, or
+// with the error that ended reading from the terminal.
+func (m *Mux) Run() error {
+	type chunk struct {
+		data []byte
+		err  error
+	}
+	chunks := make(chan chunk)
+	go func() {
+		for {
+			buf := make([]byte, 1024)
+			n, err := m.rw.Read(buf)
+			select {
+			case chunks <- chunk{buf[:n], err}:
+			case <-m.done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var d terminal.KeyDecoder
+	prefixed := false
+	for {
+		var c chunk
+		select {
+		case c = <-chunks:
+		case <-m.done:
+			return nil
+		}
+		d.Write(c.data)
+		for {
+			key, seq, ok := d.Next()
+			if !ok {
+				if p := d.Pending(); len(p) == 1 && p[0] == terminal.KeyEscape {
+					// Escape sequences arrive in one piece,
+					// so this is the Escape key.
+					key, seq, ok = d.Flush()
+				}
+			}
+			if !ok {
+				break
+			}
+			if prefixed {
+				prefixed = false
+				if key != m.Prefix {
+					m.bind(key)
+					continue
+				}
+			} else if key == m.Prefix {
+				prefixed = true
+				continue
+			}
+			if p := m.Focused(); p != nil {
+				p.input.Write(seq)
+			}
+		}
+		if c.err != nil {
+			return c.err
+		}
+	}
+}
+
+// bind carries out the key binding for key.
+func (m *Mux) bind(key int) {
+	switch key {
+	case terminal.KeyLeft:
+		m.moveFocus(-1, 0)
+	case terminal.KeyRight:
+		m.moveFocus(1, 0)
+	case terminal.KeyUp:
+		m.moveFocus(0, -1)
+	case terminal.KeyDown:
+		m.moveFocus(0, 1)
+	case 'o':
+		m.nextFocus()
+	case 'H':
+		m.moveBorder(true, -resizeStep)
+	case 'L':
+		m.moveBorder(true, resizeStep)
+	case 'K':
+		m.moveBorder(false, -resizeStep)
+	case 'J':
+		m.moveBorder(false, resizeStep)
+	case '%', '"':
+		if m.NewPane == nil {
+			return
+		}
+		if p, err := m.NewPane(); err == nil {
+			m.Split(p, key == '%')
+		}
+	case 'x':
+		if p := m.Focused(); p != nil {
+			m.Remove(p)
+		}
+	}
+}
+
+// moveFocus gives the focus to the pane next to the focused one in the
+// direction dx, dy, if there is one.
+func (m *Mux) moveFocus(dx, dy int) {
+	m.lock.Lock()
+	f := m.focus
+	if f == nil {
+		m.lock.Unlock()
+		return
+	}
+	// The point just past the edge of the focused pane, level with its
+	// top left corner.
+	x, y := f.x, f.y
+	switch {
+	case dx < 0:
+		x = f.x - 2
+	case dx > 0:
+		x = f.x + f.width + 1
+	case dy < 0:
+		y = f.y - 2
+	case dy > 0:
+		y = f.y + f.height + 1
+	}
+	m.walk(func(n *node) {
+		if x >= n.x && x < n.x+n.width && y >= n.y && y < n.y+n.height {
+			m.focus = n
+		}
+	})
+	m.lock.Unlock()
+	m.Draw()
+}
+
+// nextFocus gives the focus to the next pane, from the top left.
+func (m *Mux) nextFocus() {
+	m.lock.Lock()
+	var nodes []*node
+	m.walk(func(n *node) { nodes = append(nodes, n) })
+	for i, n := range nodes {
+		if n == m.focus {
+			m.focus = nodes[(i+1)%len(nodes)]
+			break
+		}
+	}
+	m.lock.Unlock()
+	m.Draw()
+}
+
+// moveBorder moves the border of the split closest to the focused pane, of
+// vertical borders if vertical is true, by delta cells.
+func (m *Mux) moveBorder(vertical bool, delta int) {
+	m.lock.Lock()
+	n := m.focus
+	for n != nil && (n.pane != nil || n.vertical != vertical) {
+		n = n.parent
+	}
+	if n != nil {
+		size := n.height
+		if vertical {
+			size = n.width
+		}
+		if size > 1 {
+			n.ratio = min(max(n.ratio+float64(delta)/float64(size-1), 0), 1)
+		}
+	}
+	m.lock.Unlock()
+	m.Draw()
+}
@@ -0,0 +1,218 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package panes
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// DefaultPrefix is the key that starts a key binding, Ctrl-B.
+const DefaultPrefix = 2
+
+// ErrNoPanes is returned by Split when the Mux has no panes left.
+var ErrNoPanes = errors.New("panes: no panes left")
+
+// node is a pane or a split of an area in two.
+type node struct {
+	pane   *Pane
+	parent *node
+	// For splits: vertical splits put a and b side by side, otherwise a
+	// is above b. ratio is the share of the area taken by a.
+	vertical bool
+	ratio    float64
+	a, b     *node
+	// x, y, width and height give the area of the node after the last
+	// layout.
+	x, y, width, height int
+}
+
+// Mux shows panes on a terminal and passes keys on to the pane with the
+// focus. The key bindings, typed after the prefix key, are:
+//
+//	Arrow keys   move the focus to the pane in that direction
+//	o            move the focus to the next pane
+//	H, J, K, L   move the border of the pane left, down, up or right
+//	%, "         split the pane side by side or one above the other
+//	x            remove the pane
+//
+// Typing the prefix key twice passes it on.
+type Mux struct {
+	lock   sync.Mutex
+	rw     io.ReadWriter
+	t      *terminal.Terminal
+	screen *terminal.Screen
+	root   *node
+	focus  *node
+	done   chan struct{}
+
+	// Prefix is the key that starts a key binding. It's DefaultPrefix
+	// unless changed before calling Run.
+	Prefix int
+	// NewPane, if not nil, is called to create the pane added when the
+	// user splits a pane.
+	NewPane func() (*Pane, error)
+	// BorderStyle is the style of the borders between panes.
+	BorderStyle terminal.Style
+}
+
+// New returns a Mux showing first on rw, which must be a terminal in raw mode
+// of the given size. It switches the terminal to the alternate screen until
+// Close is called.
+func New(rw io.ReadWriter, width, height int, first *Pane) (*Mux, error) {
+	t := terminal.NewTerminal(rw, "", false)
+	t.SetColorProfile(terminal.DetectColorProfile())
+	t.SetSize(width, height)
+	screen, err := t.NewScreen()
+	if err != nil {
+		return nil, err
+	}
+	m := &Mux{
+		rw:          rw,
+		t:           t,
+		screen:      screen,
+		done:        make(chan struct{}),
+		Prefix:      DefaultPrefix,
+		BorderStyle: terminal.Style{Faint: true},
+	}
+	m.root = &node{pane: first}
+	m.focus = m.root
+	m.attach(first)
+	m.Draw()
+	return m, nil
+}
+
+// Close switches the terminal back to the normal screen. The panes are left
+// running.
+func (m *Mux) Close() error {
+	return m.screen.Close()
+}
+
+func (m *Mux) attach(p *Pane) {
+	p.lock.Lock()
+	p.mux = m
+	p.lock.Unlock()
+}
+
+// Focused returns the pane with the focus, or nil if there are no panes.
+func (m *Mux) Focused() *Pane {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.focus == nil {
+		return nil
+	}
+	return m.focus.pane
+}
+
+// Focus gives the focus to p.
+func (m *Mux) Focus(p *Pane) {
+	m.lock.Lock()
+	if n := m.find(p); n != nil {
+		m.focus = n
+	}
+	m.lock.Unlock()
+	m.Draw()
+}
+
+// find returns the node of p, or nil. m.lock must be held.
+func (m *Mux) find(p *Pane) *node {
+	var found *node
+	m.walk(func(n *node) {
+		if n.pane == p {
+			found = n
+		}
+	})
+	return found
+}
+
+// walk calls f for each pane's node, from the top left. m.lock must be held.
+func (m *Mux) walk(f func(*node)) {
+	var visit func(n *node)
+	visit = func(n *node) {
+		if n == nil {
+			return
+		}
+		if n.pane != nil {
+			f(n)
+			return
+		}
+		visit(n.a)
+		visit(n.b)
+	}
+	visit(m.root)
+}
+
+// Split splits the area of the pane with the focus in two, side by side if
+// vertical is true, and gives the new half, and the focus, to p.
+func (m *Mux) Split(p *Pane, vertical bool) error {
+	m.lock.Lock()
+	if m.focus == nil {
+		m.lock.Unlock()
+		return ErrNoPanes
+	}
+	n := m.focus
+	old := &node{pane: n.pane, parent: n}
+	added := &node{pane: p, parent: n}
+	n.pane, n.vertical, n.ratio, n.a, n.b = nil, vertical, 0.5, old, added
+	m.focus = added
+	m.lock.Unlock()
+
+	m.attach(p)
+	m.Draw()
+	return nil
+}
+
+// Remove removes p, giving its area to the pane next to it.
+func (m *Mux) Remove(p *Pane) {
+	m.lock.Lock()
+	n := m.find(p)
+	if n == nil {
+		m.lock.Unlock()
+		return
+	}
+	if n.parent == nil {
+		m.root, m.focus = nil, nil
+		close(m.done)
+	} else {
+		parent := n.parent
+		sibling := parent.a
+		if sibling == n {
+			sibling = parent.b
+		}
+		*parent = node{pane: sibling.pane, parent: parent.parent, vertical: sibling.vertical, ratio: sibling.ratio, a: sibling.a, b: sibling.b}
+		if parent.a != nil {
+			parent.a.parent, parent.b.parent = parent, parent
+		}
+		if m.focus == n || m.focus == sibling {
+			m.focus = parent
+			for m.focus.pane == nil {
+				m.focus = m.focus.a
+			}
+		}
+	}
+	m.lock.Unlock()
+
+	p.lock.Lock()
+	p.mux = nil
+	closed := p.closed
+	p.lock.Unlock()
+	if closed != nil {
+		closed()
+	}
+	m.Draw()
+}
+
+// Resize changes the size of the terminal the panes are shown on.
+func (m *Mux) Resize(width, height int) {
+	m.t.SetSize(width, height)
+	m.lock.Lock()
+	m.screen.Resize(width, height)
+	m.lock.Unlock()
+	m.Draw()
+}
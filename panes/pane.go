@@ -0,0 +1,104 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package panes divides a terminal into panes, each showing the output of its
+// own program or Terminal, like a minimal terminal multiplexer. Keys typed are
+// passed to the pane that has the focus, and key bindings following a prefix
+// key move the focus and resize the panes.
+package panes
+
+import (
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/emulator"
+	"github.com/LordEliasTM/pseudo-terminal-go/pty"
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Pane is a part of the screen. What's written to it is shown in its area,
+// interpreted by an emulator, and the keys typed while it has the focus are
+// passed on to its input.
+type Pane struct {
+	lock   sync.Mutex
+	screen *emulator.Screen
+	input  io.Writer
+	// resized is called when the size of the pane changes.
+	resized func(width, height int)
+	// closed is called when the pane is removed.
+	closed func()
+	mux    *Mux
+}
+
+// NewPane returns a pane passing keys to input.
+func NewPane(input io.Writer) *Pane {
+	return &Pane{screen: emulator.New(80, 24), input: input}
+}
+
+// StartCommand starts cmd on a pseudo-terminal and returns a pane showing it.
+// The pane is removed from its Mux when the program ends, and the program is
+// killed if the pane is removed first.
+func StartCommand(cmd *exec.Cmd) (*Pane, error) {
+	f, err := pty.Start(cmd)
+	if err != nil {
+		return nil, err
+	}
+	p := NewPane(f)
+	p.resized = func(width, height int) {
+		pty.SetSize(f, width, height)
+	}
+	p.closed = func() {
+		cmd.Process.Kill()
+	}
+	go func() {
+		io.Copy(p, f)
+		cmd.Wait()
+		f.Close()
+		p.Remove()
+	}()
+	return p, nil
+}
+
+// NewTerminalPane returns a pane along with a Terminal, with the given prompt,
+// that reads the keys typed into the pane and writes to it.
+func NewTerminalPane(prompt string) (*Pane, *terminal.Terminal) {
+	r, w := io.Pipe()
+	p := NewPane(w)
+	t := terminal.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, p}, prompt, true)
+	p.resized = func(width, height int) {
+		t.SetSize(width, height)
+	}
+	p.closed = func() {
+		w.Close()
+	}
+	return p, t
+}
+
+// Write shows b in the pane.
+func (p *Pane) Write(b []byte) (n int, err error) {
+	p.lock.Lock()
+	p.screen.Write(b)
+	m := p.mux
+	p.lock.Unlock()
+
+	if m != nil {
+		m.Draw()
+	}
+	return len(b), nil
+}
+
+// Remove removes the pane from its Mux.
+func (p *Pane) Remove() {
+	p.lock.Lock()
+	m := p.mux
+	p.lock.Unlock()
+
+	if m != nil {
+		m.Remove(p)
+	}
+}
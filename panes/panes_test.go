@@ -0,0 +1,182 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package panes
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/emulator"
+)
+
+// display returns a terminal for a Mux that shows its output on an emulated
+// screen, and a writer for typing keys into it.
+func display(width, height int) (io.ReadWriter, *emulator.Screen, *io.PipeWriter) {
+	r, w := io.Pipe()
+	screen := emulator.New(width, height)
+	return struct {
+		io.Reader
+		io.Writer
+	}{r, screen}, screen, w
+}
+
+func TestSplit(t *testing.T) {
+	rw, screen, keys := display(11, 3)
+	defer keys.Close()
+	var in1, in2 bytes.Buffer
+	left, right := NewPane(&in1), NewPane(&in2)
+	m, err := New(rw, 11, 3, left)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left.Write([]byte("left"))
+	m.Split(right, true)
+	right.Write([]byte("right"))
+
+	want := []string{"left │right", "     │", "     │"}
+	if got := screen.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+	if x, y, _ := screen.Cursor(); x != 11-1 || y != 0 {
+		// The right pane is 5 cells wide, so its cursor waits at
+		// its last column.
+		t.Errorf("cursor at %d,%d, expected 10,0", x, y)
+	}
+	if m.Focused() != right {
+		t.Errorf("the new pane doesn't have the focus")
+	}
+}
+
+func TestRunKeys(t *testing.T) {
+	rw, _, keys := display(21, 5)
+	var in1, in2 bytes.Buffer
+	top, bottom := NewPane(&in1), NewPane(&in2)
+	m, err := New(rw, 21, 5, top)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Split(bottom, false)
+
+	errc := make(chan error)
+	go func() { errc <- m.Run() }()
+	keys.Write([]byte("ab\x1b[A"))
+	keys.Write([]byte("\x02"))
+	keys.Write([]byte("\x1b[A"))
+	keys.Write([]byte("cd\x02\x02"))
+	keys.Close()
+	if err := <-errc; err != io.EOF {
+		t.Fatalf("Run returned %v, expected EOF", err)
+	}
+
+	if got, want := in2.String(), "ab\x1b[A"; got != want {
+		t.Errorf("bottom pane got %q, expected %q", got, want)
+	}
+	if got, want := in1.String(), "cd\x02"; got != want {
+		t.Errorf("top pane got %q, expected %q", got, want)
+	}
+	if m.Focused() != top {
+		t.Errorf("the top pane doesn't have the focus")
+	}
+}
+
+func TestMoveBorder(t *testing.T) {
+	rw, screen, keys := display(21, 2)
+	defer keys.Close()
+	a, b := NewPane(io.Discard), NewPane(io.Discard)
+	m, err := New(rw, 21, 2, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Split(b, true)
+	m.moveBorder(true, -4)
+	if got := strings.IndexRune(screen.Line(0), '│'); got != 6 {
+		t.Errorf("border at column %d, expected 6", got)
+	}
+	if w, _ := b.screen.Size(); w != 14 {
+		t.Errorf("right pane is %d wide, expected 14", w)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	rw, screen, keys := display(11, 2)
+	defer keys.Close()
+	a, b := NewPane(io.Discard), NewPane(io.Discard)
+	m, err := New(rw, 11, 2, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Write([]byte("a"))
+	m.Split(b, true)
+	b.Remove()
+	if got := screen.Lines(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("got %q, expected the first pane only", got)
+	}
+	if m.Focused() != a {
+		t.Errorf("the remaining pane doesn't have the focus")
+	}
+
+	errc := make(chan error)
+	go func() { errc <- m.Run() }()
+	a.Remove()
+	if err := <-errc; err != nil {
+		t.Errorf("Run returned %v after the last pane was removed", err)
+	}
+}
+
+func TestTerminalPane(t *testing.T) {
+	rw, screen, keys := display(20, 4)
+	p, term := NewTerminalPane("> ")
+	if _, err := New(rw, 20, 4, p); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan string)
+	go func() {
+		line, _ := term.ReadLine()
+		done <- line
+	}()
+	go p.input.Write([]byte("hi\r"))
+	select {
+	case line := <-done:
+		if line != "hi" {
+			t.Errorf("got %q, expected hi", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+	if got := screen.Line(0); got != "> hi" {
+		t.Errorf("got %q, expected the line on the screen", got)
+	}
+	keys.Close()
+}
+
+func TestStartCommand(t *testing.T) {
+	rw, screen, keys := display(20, 4)
+	defer keys.Close()
+	p, err := StartCommand(exec.Command("sh", "-c", "echo hello; sleep 10"))
+	if err != nil {
+		t.Skipf("can't start a program on a pty: %v", err)
+	}
+	m, err := New(rw, 20, 4, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		m.lock.Lock()
+		line := screen.Line(0)
+		m.lock.Unlock()
+		if line == "hello" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %q, expected the program's output", line)
+		}
+	}
+	p.Remove()
+}
@@ -0,0 +1,175 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pty
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned by Expect if the output doesn't match in time.
+var ErrTimeout = errors.New("pty: timed out waiting for output")
+
+// Expect automates an interactive program running on a pseudo-terminal by
+// waiting for its output to match patterns and sending it input in response.
+type Expect struct {
+	cmd *exec.Cmd
+	pty *os.File
+
+	lock sync.Mutex
+	// buf holds the output that hasn't been matched yet.
+	buf []byte
+	// err is the error that ended the output, usually io.EOF.
+	err error
+	// changed is closed, and replaced, when buf or err change.
+	changed chan struct{}
+	// interact receives the output once Interact is called.
+	interact io.Writer
+}
+
+// Spawn starts the named program with the given arguments on a new
+// pseudo-terminal.
+func Spawn(name string, args ...string) (*Expect, error) {
+	return SpawnCommand(exec.Command(name, args...))
+}
+
+// SpawnCommand starts cmd on a new pseudo-terminal.
+func SpawnCommand(cmd *exec.Cmd) (*Expect, error) {
+	f, err := Start(cmd)
+	if err != nil {
+		return nil, err
+	}
+	e := &Expect{cmd: cmd, pty: f, changed: make(chan struct{})}
+	go e.read()
+	return e, nil
+}
+
+func (e *Expect) read() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := e.pty.Read(buf)
+
+		e.lock.Lock()
+		if e.interact != nil {
+			e.interact.Write(buf[:n])
+		} else {
+			e.buf = append(e.buf, buf[:n]...)
+		}
+		if err != nil {
+			// Linux reports EIO once the program and all its
+			// children have closed the terminal.
+			e.err = io.EOF
+		}
+		close(e.changed)
+		e.changed = make(chan struct{})
+		e.lock.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Expect waits until the output matches re and returns the text of the match
+// and its subexpressions, as FindSubmatch does. The output up to the end of the
+// match is consumed. If the output doesn't match within timeout, ErrTimeout is
+// returned; if the output ends first, io.EOF is.
+func (e *Expect) Expect(re *regexp.Regexp, timeout time.Duration) ([]string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for {
+		if m := re.FindSubmatchIndex(e.buf); m != nil {
+			match := make([]string, len(m)/2)
+			for i := range match {
+				if m[2*i] >= 0 {
+					match[i] = string(e.buf[m[2*i]:m[2*i+1]])
+				}
+			}
+			e.buf = e.buf[m[1]:]
+			return match, nil
+		}
+		if e.err != nil {
+			return nil, e.err
+		}
+
+		changed := e.changed
+		e.lock.Unlock()
+		select {
+		case <-changed:
+			e.lock.Lock()
+		case <-timer.C:
+			e.lock.Lock()
+			return nil, ErrTimeout
+		}
+	}
+}
+
+// ExpectString is like Expect, but waits for the literal text s.
+func (e *Expect) ExpectString(s string, timeout time.Duration) error {
+	_, err := e.Expect(regexp.MustCompile(regexp.QuoteMeta(s)), timeout)
+	return err
+}
+
+// Send writes s to the program's terminal, as if it was typed.
+func (e *Expect) Send(s string) error {
+	_, err := io.WriteString(e.pty, s)
+	return err
+}
+
+// Interact hands the program over to a user: the output not consumed by
+// Expect and everything after it is written to rw, and what's read from rw is
+// sent to the program. rw is usually the local terminal in raw mode. Interact
+// returns when the program's output ends; copying input stops with the next
+// read from rw after that.
+func (e *Expect) Interact(rw io.ReadWriter) error {
+	e.lock.Lock()
+	if _, err := rw.Write(e.buf); err != nil {
+		e.lock.Unlock()
+		return err
+	}
+	e.buf = nil
+	e.interact = rw
+	done := e.err != nil
+	e.lock.Unlock()
+
+	if !done {
+		go io.Copy(e.pty, rw)
+	}
+	for {
+		e.lock.Lock()
+		changed, done := e.changed, e.err != nil
+		e.lock.Unlock()
+		if done {
+			return nil
+		}
+		<-changed
+	}
+}
+
+// SetSize sets the size of the program's terminal.
+func (e *Expect) SetSize(width, height int) error {
+	return SetSize(e.pty, width, height)
+}
+
+// Wait waits for the program to exit and closes its terminal.
+func (e *Expect) Wait() error {
+	err := e.cmd.Wait()
+	e.pty.Close()
+	return err
+}
+
+// Close kills the program and closes its terminal.
+func (e *Expect) Close() error {
+	e.cmd.Process.Kill()
+	return e.Wait()
+}
@@ -0,0 +1,108 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pty
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func spawn(t *testing.T, script string) *Expect {
+	e, err := Spawn("sh", "-c", script)
+	if err != nil {
+		t.Skipf("can't start a program on a pty: %v", err)
+	}
+	return e
+}
+
+func TestExpect(t *testing.T) {
+	e := spawn(t, `printf 'Name: '; read name; echo "Hello, $name!"`)
+	defer e.Close()
+
+	if err := e.ExpectString("Name: ", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Send("Gopher\r"); err != nil {
+		t.Fatal(err)
+	}
+	m, err := e.Expect(regexp.MustCompile(`Hello, (\w+)!`), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m[1] != "Gopher" {
+		t.Errorf("got %q, expected Gopher", m[1])
+	}
+	if _, err := e.Expect(regexp.MustCompile("never"), time.Second); err != io.EOF {
+		t.Errorf("got %v, expected EOF", err)
+	}
+}
+
+func TestExpectTimeout(t *testing.T) {
+	e := spawn(t, "sleep 10")
+	defer e.Close()
+
+	if _, err := e.Expect(regexp.MustCompile("x"), 10*time.Millisecond); err != ErrTimeout {
+		t.Errorf("got %v, expected ErrTimeout", err)
+	}
+}
+
+type syncBuffer struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.Buffer.String()
+}
+
+func TestInteract(t *testing.T) {
+	e := spawn(t, `echo ready; read x; echo "got $x"`)
+	defer e.Close()
+
+	if err := e.ExpectString("ready", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	var out syncBuffer
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader("yes\r"), &out}
+	if err := e.Interact(rw); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "got yes") {
+		t.Errorf("got %q, expected the program's answer", out.String())
+	}
+}
+
+func TestSetSize(t *testing.T) {
+	ptmx, tty, err := Open()
+	if err != nil {
+		t.Skipf("can't open a pty: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+
+	if err := SetSize(ptmx, 100, 40); err != nil {
+		t.Fatal(err)
+	}
+	w, h, err := GetSize(tty)
+	if w != 100 || h != 40 || err != nil {
+		t.Errorf("got %d, %d, %v, expected 100, 40, nil", w, h, err)
+	}
+}
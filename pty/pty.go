@@ -0,0 +1,12 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pty runs programs on pseudo-terminals and automates them.
+package pty
+
+import "errors"
+
+// ErrUnsupported is returned on systems where pseudo-terminals aren't
+// supported.
+var ErrUnsupported = errors.New("pty: not supported on this system")
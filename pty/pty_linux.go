@@ -0,0 +1,101 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package pty
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Open returns a new pseudo-terminal, as its master side, ptmx, which a
+// program controls it through, and its slave side, tty, which is given to
+// the program running on it.
+func Open() (ptmx, tty *os.File, err error) {
+	ptmx, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unlock int32
+	if err = ioctl(ptmx, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		ptmx.Close()
+		return nil, nil, &os.PathError{Op: "unlockpt", Path: ptmx.Name(), Err: err}
+	}
+	var n uint32
+	if err = ioctl(ptmx, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		ptmx.Close()
+		return nil, nil, &os.PathError{Op: "ptsname", Path: ptmx.Name(), Err: err}
+	}
+	tty, err = os.OpenFile("/dev/pts/"+strconv.FormatUint(uint64(n), 10), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		ptmx.Close()
+		return nil, nil, err
+	}
+	return ptmx, tty, nil
+}
+
+// Start starts cmd on a new pseudo-terminal, as the leader of a new session
+// with the terminal as its controlling terminal, and returns the master side.
+// Standard input, output and error that aren't set are connected to the
+// terminal.
+func Start(cmd *exec.Cmd) (*os.File, error) {
+	ptmx, tty, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+
+	if cmd.Stdin == nil {
+		cmd.Stdin = tty
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = tty
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = tty
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	if err := cmd.Start(); err != nil {
+		ptmx.Close()
+		return nil, err
+	}
+	return ptmx, nil
+}
+
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// SetSize sets the size of the pseudo-terminal f, which may be either side,
+// in columns and rows. The program running on it receives a SIGWINCH.
+func SetSize(f *os.File, width, height int) error {
+	ws := winsize{rows: uint16(height), cols: uint16(width)}
+	return ioctl(f, syscall.TIOCSWINSZ, unsafe.Pointer(&ws))
+}
+
+// GetSize returns the size of the terminal f in columns and rows.
+func GetSize(f *os.File) (width, height int, err error) {
+	var ws winsize
+	if err := ioctl(f, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.cols), int(ws.rows), nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package pty
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Open returns a new pseudo-terminal. It is only supported on Linux.
+func Open() (ptmx, tty *os.File, err error) {
+	return nil, nil, ErrUnsupported
+}
+
+// Start starts cmd on a new pseudo-terminal. It is only supported on Linux.
+func Start(cmd *exec.Cmd) (*os.File, error) {
+	return nil, ErrUnsupported
+}
+
+// SetSize sets the size of the pseudo-terminal f.
+func SetSize(f *os.File, width, height int) error {
+	return ErrUnsupported
+}
+
+// GetSize returns the size of the terminal f.
+func GetSize(f *os.File) (width, height int, err error) {
+	return 0, 0, ErrUnsupported
+}
@@ -0,0 +1,218 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package readline offers the API of readline libraries, such as
+// github.com/chzyer/readline and github.com/peterh/liner, on top of a
+// terminal.Terminal, to ease moving programs written against them:
+//
+//	rl, err := readline.New()
+//	if err != nil {
+//		...
+//	}
+//	defer rl.Close()
+//	for {
+//		line, err := rl.Readline("> ")
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//		rl.AddHistory(line)
+//	}
+//
+// Unlike the Terminal, which remembers every line entered, a State only puts
+// the lines passed to AddHistory into the history.
+package readline
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Completer returns the completions of line, the text before the cursor,
+// each of which replaces it.
+type Completer func(line string) []string
+
+// WordCompleter returns the completions of the word under the cursor, which
+// ctx describes, unquoted. They're quoted as the cursor's context requires
+// when they're inserted, so a file name with spaces doesn't break the line.
+// Completions that may be completed further, such as directories, end in "/".
+type WordCompleter func(ctx terminal.TokenContext) []string
+
+// State reads lines from a Terminal.
+type State struct {
+	t     *terminal.Terminal
+	local bool
+
+	lock      sync.Mutex
+	history   []string
+	completer Completer
+	words     WordCompleter
+}
+
+// New returns a State on standard input and output, which are put into raw
+// mode, if they're a terminal, until Close is called.
+func New() (*State, error) {
+	t, err := terminal.NewWithStdInOut(true)
+	if err != nil {
+		return nil, err
+	}
+	s := NewTerminal(t)
+	s.local = true
+	return s, nil
+}
+
+// NewTerminal returns a State reading lines from t. It takes over t's
+// AutoCompleteCallback and history.
+func NewTerminal(t *terminal.Terminal) *State {
+	s := &State{t: t}
+	t.AutoCompleteCallback = s.complete
+	return s
+}
+
+// Terminal returns the Terminal that lines are read from.
+func (s *State) Terminal() *terminal.Terminal {
+	return s.t
+}
+
+// Readline shows prompt and returns the line the user enters. It returns
+// io.EOF when the user presses Ctrl-D on an empty line or the input ends, and
+// terminal.ErrInterrupted when they press Ctrl-C.
+func (s *State) Readline(prompt string) (string, error) {
+	s.lock.Lock()
+	s.t.SetHistory(s.history)
+	s.lock.Unlock()
+
+	s.t.SetPrompt(prompt)
+	return s.t.ReadLine()
+}
+
+// AddHistory appends line to the history, which the Up and Down keys go
+// through.
+func (s *State) AddHistory(line string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.history = append(s.history, line)
+}
+
+// ClearHistory empties the history.
+func (s *State) ClearHistory() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.history = nil
+}
+
+// History returns the lines in the history, oldest first.
+func (s *State) History() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string(nil), s.history...)
+}
+
+// SetCompleter sets the function completing the line when Tab is pressed.
+// A single completion replaces the text before the cursor. Several ones
+// extend it to their longest common prefix, or are listed above the prompt if
+// it's as long as that already.
+func (s *State) SetCompleter(c Completer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.completer = c
+	s.words = nil
+}
+
+// SetWordCompleter sets the function completing the word under the cursor
+// when Tab is pressed, in place of a Completer. A single completion replaces
+// the word, closing its quotes and adding a space after it unless it ends in
+// "/". Several ones extend it to their longest common prefix, or are listed
+// above the prompt if it's as long as that already.
+func (s *State) SetWordCompleter(c WordCompleter) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.words = c
+	s.completer = nil
+}
+
+// Close restores the terminal's settings, if New put it into raw mode.
+func (s *State) Close() error {
+	if s.local {
+		s.t.ReleaseFromStdInOut()
+	}
+	return nil
+}
+
+// complete is the AutoCompleteCallback of the Terminal.
+func (s *State) complete(line []byte, pos, key int) ([]byte, int) {
+	s.lock.Lock()
+	c, words := s.completer, s.words
+	s.lock.Unlock()
+	if key != '\t' {
+		return nil, 0
+	}
+	if words != nil {
+		return s.completeWord(words, line, pos)
+	}
+	if c == nil {
+		return nil, 0
+	}
+
+	head, tail := string(line[:pos]), string(line[pos:])
+	completions := c(head)
+	switch len(completions) {
+	case 0:
+		return nil, 0
+	case 1:
+		return []byte(completions[0] + tail), len(completions[0])
+	}
+	if prefix := commonPrefix(completions); len(prefix) > len(head) {
+		return []byte(prefix + tail), len(prefix)
+	}
+	s.t.Write([]byte(strings.Join(completions, "  ") + "\n"))
+	return line, pos
+}
+
+// completeWord completes the word under the cursor with words.
+func (s *State) completeWord(words WordCompleter, line []byte, pos int) ([]byte, int) {
+	ctx := terminal.Tokenize(string(line), pos)
+	completions := words(ctx)
+	var word string
+	switch len(completions) {
+	case 0:
+		return nil, 0
+	case 1:
+		word = completions[0]
+	default:
+		word = commonPrefix(completions)
+		if len(word) <= len(ctx.Prefix) {
+			s.t.Write([]byte(strings.Join(completions, "  ") + "\n"))
+			return line, pos
+		}
+	}
+	final := len(completions) == 1 && !strings.HasSuffix(word, "/")
+	newLine, newPos := ctx.Complete(string(line), pos, word, final)
+	return []byte(newLine), newPos
+}
+
+// commonPrefix returns the longest prefix of all of ss that doesn't end in
+// the middle of a character.
+func commonPrefix(ss []string) string {
+	prefix := ss[0]
+	for _, s := range ss[1:] {
+		i := 0
+		for i < len(prefix) && i < len(s) && prefix[i] == s[i] {
+			i++
+		}
+		for i > 0 && i < len(prefix) && !utf8.RuneStart(prefix[i]) {
+			i--
+		}
+		prefix = prefix[:i]
+	}
+	return prefix
+}
@@ -0,0 +1,125 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package readline
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+	"github.com/LordEliasTM/pseudo-terminal-go/terminaltest"
+)
+
+const up = "\x1b[A"
+
+func TestHistory(t *testing.T) {
+	tt, c := terminaltest.New("", 40, 10)
+	s := NewTerminal(tt)
+
+	c.Type("one\r", "two\r", up+"\r")
+	if line, err := s.Readline("> "); err != nil || line != "one" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	s.AddHistory("one")
+	if line, err := s.Readline("> "); err != nil || line != "two" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	// "two" wasn't added.
+	if line, err := s.Readline("> "); err != nil || line != "one" {
+		t.Errorf("got %q, %v, expected the line added to the history", line, err)
+	}
+
+	s.ClearHistory()
+	if h := s.History(); len(h) != 0 {
+		t.Errorf("got history %q after ClearHistory", h)
+	}
+	c.Type(up + "x\r")
+	if line, err := s.Readline("> "); err != nil || line != "x" {
+		t.Errorf("got %q, %v, expected an empty history", line, err)
+	}
+	if _, err := s.Readline("> "); err != io.EOF {
+		t.Errorf("got %v at the end of the input, expected io.EOF", err)
+	}
+}
+
+func TestCompleter(t *testing.T) {
+	tt, c := terminaltest.New("", 40, 10)
+	s := NewTerminal(tt)
+	s.SetCompleter(func(line string) []string {
+		var c []string
+		for _, w := range []string{"help", "hello", "quit"} {
+			if strings.HasPrefix(w, line) {
+				c = append(c, w)
+			}
+		}
+		return c
+	})
+
+	c.Type("q\t\r", "h\t\t\r")
+	if line, err := s.Readline("> "); err != nil || line != "quit" {
+		t.Errorf("got %q, %v, expected the only completion", line, err)
+	}
+	if line, err := s.Readline("> "); err != nil || line != "hel" {
+		t.Errorf("got %q, %v, expected the common prefix", line, err)
+	}
+	if out := c.Output(); !strings.Contains(out, "help  hello") {
+		t.Errorf("got %q, expected the completions to be listed", out)
+	}
+	if _, err := s.Readline("> "); err != io.EOF {
+		t.Errorf("got %v, expected io.EOF", err)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	tt, c := terminaltest.New("", 40, 10)
+	s := NewTerminal(tt)
+	c.Type("abc" + terminaltest.Ctrl('C'))
+	if _, err := s.Readline("> "); err != terminal.ErrInterrupted {
+		t.Errorf("got %v, expected terminal.ErrInterrupted", err)
+	}
+}
+
+func TestWordCompleter(t *testing.T) {
+	tt, c := terminaltest.New("", 60, 10)
+	s := NewTerminal(tt)
+	s.SetWordCompleter(func(ctx terminal.TokenContext) []string {
+		if ctx.Arg == 0 {
+			return []string{"cat"}
+		}
+		var c []string
+		for _, w := range []string{"my file", "my dir/", "it's"} {
+			if strings.HasPrefix(w, ctx.Prefix) {
+				c = append(c, w)
+			}
+		}
+		return c
+	})
+
+	c.Type("c\tm\t\td\tx\r", `cat "i`+"\t\r")
+	if line, err := s.Readline("> "); err != nil || line != `cat my\ dir/x` {
+		t.Errorf("got %q, %v, expected the completions to be escaped", line, err)
+	}
+	if line, err := s.Readline("> "); err != nil || line != `cat "it's" ` {
+		t.Errorf("got %q, %v, expected the completion to be quoted", line, err)
+	}
+}
+
+func TestCompleteMultibyte(t *testing.T) {
+	words := []string{"café", "cafè"}
+	tt, c := terminaltest.New("", 40, 10)
+	s := NewTerminal(tt)
+	s.SetCompleter(func(string) []string { return words })
+	c.Type("c\t\r")
+	if line, err := s.Readline("> "); err != nil || line != "caf" {
+		t.Errorf("got %q, %v, expected the prefix to end between characters", line, err)
+	}
+
+	s.SetWordCompleter(func(terminal.TokenContext) []string { return words })
+	c.Type("x c\t\r")
+	if line, err := s.Readline("> "); err != nil || line != "x caf" {
+		t.Errorf("got %q, %v, expected the prefix to end between characters", line, err)
+	}
+}
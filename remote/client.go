@@ -0,0 +1,75 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package remote
+
+import (
+	"errors"
+	"io"
+	"syscall"
+)
+
+// ErrNoExitStatus is returned by Client.Run when the server closes the stream
+// without sending the program's exit status.
+var ErrNoExitStatus = errors.New("remote: stream ended without an exit status")
+
+// Client is the side of the protocol with the user's terminal, which should be
+// in raw mode.
+type Client struct {
+	c *Conn
+}
+
+// NewClient returns a Client talking to the server on rw.
+func NewClient(rw io.ReadWriter) *Client {
+	return &Client{c: NewConn(rw)}
+}
+
+// Resize tells the server the size of the user's terminal. It should be sent
+// before Run and whenever the size changes.
+func (c *Client) Resize(width, height int) error {
+	return c.c.Send(&Frame{Type: FrameResize, Width: width, Height: height})
+}
+
+// Signal asks the server to send sig to the program.
+func (c *Client) Signal(sig syscall.Signal) error {
+	return c.c.Send(&Frame{Type: FrameSignal, Signal: int(sig)})
+}
+
+// Run sends what's read from stdin to the program and writes its output to
+// stdout until it ends, and returns its exit status. Reading from stdin
+// continues in the background after Run returns, until it fails.
+func (c *Client) Run(stdin io.Reader, stdout io.Writer) (exitStatus int, err error) {
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				if c.c.Send(&Frame{Type: FrameData, Data: buf[:n]}) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		fr, err := c.c.Receive()
+		if err == io.EOF {
+			return 0, ErrNoExitStatus
+		}
+		if err != nil {
+			return 0, err
+		}
+		switch fr.Type {
+		case FrameData:
+			if _, err := stdout.Write(fr.Data); err != nil {
+				return 0, err
+			}
+		case FrameExit:
+			return fr.ExitStatus, nil
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package remote runs programs and Terminals for clients on other machines,
+// as the remote execution feature of an orchestration tool does. The two
+// sides exchange the Frames described in remote.proto over any stream, such
+// as a TCP connection or a gRPC stream: the program's input and output,
+// resizes of the client's terminal, signals and the exit status.
+package remote
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// FrameType identifies the kind of a Frame.
+type FrameType int
+
+const (
+	// FrameData carries input or output in Frame.Data.
+	FrameData FrameType = iota + 1
+	// FrameResize carries the size of the client's terminal in
+	// Frame.Width and Frame.Height.
+	FrameResize
+	// FrameSignal carries a signal number for the program in Frame.Signal.
+	FrameSignal
+	// FrameExit carries the program's exit status in Frame.ExitStatus.
+	FrameExit
+)
+
+// Frame is a message of the protocol. Only the fields of its Type are used.
+type Frame struct {
+	Type FrameType
+
+	Data          []byte
+	Width, Height int
+	Signal        int
+	ExitStatus    int
+}
+
+// maxFrame limits the size of frames that are read.
+const maxFrame = 1 << 20
+
+var (
+	errFrameTooLarge = errors.New("remote: frame too large")
+	errBadFrame      = errors.New("remote: malformed frame")
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wire))
+}
+
+// appendInt32 appends an int32 field; negative numbers take ten bytes, as
+// protobuf requires.
+func appendInt32(b []byte, field, v int) []byte {
+	b = appendTag(b, field, wireVarint)
+	return binary.AppendUvarint(b, uint64(int64(int32(v))))
+}
+
+// Marshal returns the protobuf encoding of f.
+func (f *Frame) Marshal() []byte {
+	var b []byte
+	switch f.Type {
+	case FrameData:
+		b = appendTag(b, 1, wireBytes)
+		b = binary.AppendUvarint(b, uint64(len(f.Data)))
+		b = append(b, f.Data...)
+	case FrameResize:
+		var size []byte
+		if f.Width != 0 {
+			size = appendTag(size, 1, wireVarint)
+			size = binary.AppendUvarint(size, uint64(uint32(f.Width)))
+		}
+		if f.Height != 0 {
+			size = appendTag(size, 2, wireVarint)
+			size = binary.AppendUvarint(size, uint64(uint32(f.Height)))
+		}
+		b = appendTag(b, 2, wireBytes)
+		b = binary.AppendUvarint(b, uint64(len(size)))
+		b = append(b, size...)
+	case FrameSignal:
+		b = appendInt32(b, 3, f.Signal)
+	case FrameExit:
+		b = appendInt32(b, 4, f.ExitStatus)
+	}
+	return b
+}
+
+// field is a field of a protobuf message.
+type field struct {
+	num  int
+	wire int
+	// v is the value of a varint, and data the contents of a
+	// length-delimited field.
+	v    uint64
+	data []byte
+}
+
+// nextField parses the field at the start of b and returns the rest.
+func nextField(b []byte) (f field, rest []byte, err error) {
+	tag, n := binary.Uvarint(b)
+	if n <= 0 {
+		return f, nil, errBadFrame
+	}
+	b = b[n:]
+	f.num, f.wire = int(tag>>3), int(tag&7)
+	switch f.wire {
+	case wireVarint:
+		f.v, n = binary.Uvarint(b)
+		if n <= 0 {
+			return f, nil, errBadFrame
+		}
+		return f, b[n:], nil
+	case wireBytes:
+		l, n := binary.Uvarint(b)
+		if n <= 0 || uint64(len(b)-n) < l {
+			return f, nil, errBadFrame
+		}
+		f.data = b[n : n+int(l)]
+		return f, b[n+int(l):], nil
+	case wireFixed64:
+		if len(b) < 8 {
+			return f, nil, errBadFrame
+		}
+		return f, b[8:], nil
+	case wireFixed32:
+		if len(b) < 4 {
+			return f, nil, errBadFrame
+		}
+		return f, b[4:], nil
+	}
+	return f, nil, errBadFrame
+}
+
+// Unmarshal sets f from its protobuf encoding. Unknown fields are skipped; if
+// there are several fields of the oneof, the last one wins.
+func (f *Frame) Unmarshal(b []byte) error {
+	*f = Frame{}
+	for len(b) > 0 {
+		var fld field
+		var err error
+		fld, b, err = nextField(b)
+		if err != nil {
+			return err
+		}
+		switch {
+		case fld.num == 1 && fld.wire == wireBytes:
+			*f = Frame{Type: FrameData, Data: append([]byte(nil), fld.data...)}
+		case fld.num == 2 && fld.wire == wireBytes:
+			*f = Frame{Type: FrameResize}
+			for size := fld.data; len(size) > 0; {
+				var sf field
+				sf, size, err = nextField(size)
+				if err != nil {
+					return err
+				}
+				switch {
+				case sf.num == 1 && sf.wire == wireVarint:
+					f.Width = int(uint32(sf.v))
+				case sf.num == 2 && sf.wire == wireVarint:
+					f.Height = int(uint32(sf.v))
+				}
+			}
+		case fld.num == 3 && fld.wire == wireVarint:
+			*f = Frame{Type: FrameSignal, Signal: int(int32(fld.v))}
+		case fld.num == 4 && fld.wire == wireVarint:
+			*f = Frame{Type: FrameExit, ExitStatus: int(int32(fld.v))}
+		}
+	}
+	return nil
+}
+
+// Conn sends and receives Frames on a stream, each preceded by its length.
+type Conn struct {
+	r *bufio.Reader
+	w io.Writer
+
+	wlock sync.Mutex
+}
+
+// NewConn returns a Conn on rw.
+func NewConn(rw io.ReadWriter) *Conn {
+	return &Conn{r: bufio.NewReader(rw), w: rw}
+}
+
+// Send sends f. It may be called from several goroutines at once.
+func (c *Conn) Send(f *Frame) error {
+	msg := f.Marshal()
+	b := binary.AppendUvarint(make([]byte, 0, len(msg)+binary.MaxVarintLen32), uint64(len(msg)))
+	b = append(b, msg...)
+
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	_, err := c.w.Write(b)
+	return err
+}
+
+// Receive receives the next frame. Frames of unknown types, sent by a newer
+// version of the protocol, are skipped.
+func (c *Conn) Receive() (*Frame, error) {
+	for {
+		n, err := binary.ReadUvarint(c.r)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxFrame {
+			return nil, errFrameTooLarge
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(c.r, msg); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		f := new(Frame)
+		if err := f.Unmarshal(msg); err != nil {
+			return nil, err
+		}
+		if f.Type != 0 {
+			return f, nil
+		}
+	}
+}
+
+// String returns a description of f for debugging.
+func (f *Frame) String() string {
+	switch f.Type {
+	case FrameData:
+		return fmt.Sprintf("data %q", f.Data)
+	case FrameResize:
+		return fmt.Sprintf("resize %dx%d", f.Width, f.Height)
+	case FrameSignal:
+		return fmt.Sprintf("signal %d", f.Signal)
+	case FrameExit:
+		return fmt.Sprintf("exit %d", f.ExitStatus)
+	}
+	return "unknown"
+}
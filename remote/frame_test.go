@@ -0,0 +1,86 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package remote
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var frameTests = []struct {
+	frame Frame
+	wire  string
+}{
+	{Frame{Type: FrameData, Data: []byte("hi")}, "\x0a\x02hi"},
+	{Frame{Type: FrameData}, "\x0a\x00"},
+	{Frame{Type: FrameResize, Width: 300, Height: 24}, "\x12\x05\x08\xac\x02\x10\x18"},
+	{Frame{Type: FrameSignal, Signal: 15}, "\x18\x0f"},
+	{Frame{Type: FrameExit}, "\x20\x00"},
+	{Frame{Type: FrameExit, ExitStatus: -1}, "\x20\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01"},
+}
+
+func TestMarshal(t *testing.T) {
+	for _, test := range frameTests {
+		if got := string(test.frame.Marshal()); got != test.wire {
+			t.Errorf("%v: got %q, expected %q", &test.frame, got, test.wire)
+		}
+		var f Frame
+		if err := f.Unmarshal([]byte(test.wire)); err != nil {
+			t.Errorf("%q: %v", test.wire, err)
+		} else if !reflect.DeepEqual(f, test.frame) {
+			t.Errorf("%q: got %v, expected %v", test.wire, &f, &test.frame)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	// Unknown fields are skipped.
+	var f Frame
+	if err := f.Unmarshal([]byte("\x28\x01\x32\x01x\x18\x02\x3d\x00\x00\x00\x00")); err != nil {
+		t.Fatal(err)
+	}
+	if f.Type != FrameSignal || f.Signal != 2 {
+		t.Errorf("got %v, expected signal 2", &f)
+	}
+	for _, bad := range []string{"\x0a\x05hi", "\x18", "\x12\x02\x08"} {
+		if err := f.Unmarshal([]byte(bad)); err == nil {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+}
+
+func TestConn(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewConn(&buf)
+	for _, test := range frameTests {
+		c.Send(&test.frame)
+	}
+	// A frame of a type added later.
+	buf.WriteString("\x02\x28\x01")
+	c.Send(&Frame{Type: FrameSignal, Signal: 1})
+
+	for _, test := range frameTests {
+		f, err := c.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*f, test.frame) {
+			t.Errorf("got %v, expected %v", f, &test.frame)
+		}
+	}
+	if f, err := c.Receive(); err != nil || f.Type != FrameSignal {
+		t.Errorf("got %v, %v, expected the unknown frame to be skipped", f, err)
+	}
+	if _, err := c.Receive(); err != io.EOF {
+		t.Errorf("got %v at the end, expected io.EOF", err)
+	}
+
+	buf.WriteString("\x05\x0a")
+	if _, err := c.Receive(); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v for a truncated frame, expected io.ErrUnexpectedEOF", err)
+	}
+}
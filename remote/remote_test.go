@@ -0,0 +1,150 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package remote
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// output collects the program's output.
+type output struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *output) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(b)
+}
+
+func (o *output) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+func serve(t *testing.T, command string) (*Client, chan error) {
+	server, client := net.Pipe()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(server, exec.Command("sh", "-c", command))
+		server.Close()
+	}()
+	return NewClient(client), errc
+}
+
+func TestServe(t *testing.T) {
+	c, errc := serve(t, `stty size; read x; echo "got $x"; exit 3`)
+	c.Resize(100, 30)
+	stdin, typed := io.Pipe()
+	var out output
+	go func() {
+		for deadline := time.Now().Add(5 * time.Second); !strings.Contains(out.String(), "\n"); time.Sleep(5 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				break
+			}
+		}
+		typed.Write([]byte("yes\r"))
+	}()
+
+	status, err := c.Run(stdin, &out)
+	if err != nil {
+		select {
+		case serr := <-errc:
+			t.Skipf("can't run a program: %v", serr)
+		default:
+		}
+		t.Fatal(err)
+	}
+	if status != 3 {
+		t.Errorf("got exit status %d, expected 3", status)
+	}
+	if !strings.Contains(out.String(), "got yes") {
+		t.Errorf("got %q, expected the program's answer", out.String())
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	c, errc := serve(t, `echo ready; exec sleep 10`)
+	var out output
+	stdin, _ := io.Pipe()
+	go func() {
+		for deadline := time.Now().Add(5 * time.Second); !strings.Contains(out.String(), "ready") && time.Now().Before(deadline); {
+			time.Sleep(5 * time.Millisecond)
+		}
+		c.Signal(syscall.SIGTERM)
+	}()
+	status, err := c.Run(stdin, &out)
+	if err != nil {
+		select {
+		case serr := <-errc:
+			t.Skipf("can't run a program: %v", serr)
+		default:
+		}
+		t.Fatal(err)
+	}
+	if status != 128+int(syscall.SIGTERM) {
+		t.Errorf("got exit status %d, expected %d", status, 128+int(syscall.SIGTERM))
+	}
+}
+
+func TestServeTerminal(t *testing.T) {
+	server, client := net.Pipe()
+	go func() {
+		ServeTerminal(server, "> ", func(term *terminal.Terminal) int {
+			line, err := term.ReadLine()
+			if err == terminal.ErrInterrupted {
+				term.Write([]byte("interrupted\n"))
+				line, _ = term.ReadLine()
+			}
+			term.Write([]byte("hello " + line + "\n"))
+			return len(line)
+		})
+		server.Close()
+	}()
+
+	// net.Pipe doesn't buffer, and the terminal's output to the resize
+	// is only read by Run.
+	c := NewClient(client)
+	stdin, typed := io.Pipe()
+	go func() {
+		c.Resize(40, 10)
+		c.Signal(syscall.SIGINT)
+		typed.Write([]byte("world\r"))
+	}()
+	var out output
+	status, err := c.Run(stdin, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != 5 {
+		t.Errorf("got exit status %d, expected 5", status)
+	}
+	if got := out.String(); !strings.Contains(got, "interrupted\r\n") || !strings.HasSuffix(got, "hello world\r\n") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNoExitStatus(t *testing.T) {
+	server, client := net.Pipe()
+	server.Close()
+	if _, err := NewClient(client).Run(strings.NewReader(""), io.Discard); err != ErrNoExitStatus {
+		t.Errorf("got %v, expected ErrNoExitStatus", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package remote
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/pty"
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Serve runs cmd on a pseudo-terminal for the client on rw, until the program
+// ends and its exit status has been sent. The program's size starts out as
+// 80x24 and follows the client's resizes. If the client goes away, the
+// program is killed.
+func Serve(rw io.ReadWriter, cmd *exec.Cmd) error {
+	c := NewConn(rw)
+	f, err := pty.Start(cmd)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pty.SetSize(f, 80, 24)
+
+	go func() {
+		for {
+			fr, err := c.Receive()
+			if err != nil {
+				cmd.Process.Kill()
+				return
+			}
+			switch fr.Type {
+			case FrameData:
+				f.Write(fr.Data)
+			case FrameResize:
+				pty.SetSize(f, fr.Width, fr.Height)
+			case FrameSignal:
+				cmd.Process.Signal(syscall.Signal(fr.Signal))
+			}
+		}
+	}()
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if c.Send(&Frame{Type: FrameData, Data: buf[:n]}) != nil {
+				cmd.Process.Kill()
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return err
+	}
+	return c.Send(&Frame{Type: FrameExit, ExitStatus: exitStatus(cmd.ProcessState)})
+}
+
+// exitStatus returns the exit status of a program as a shell reports it:
+// 128 plus the signal number if it was killed by a signal.
+func exitStatus(state *os.ProcessState) int {
+	if code := state.ExitCode(); code >= 0 {
+		return code
+	}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return -1
+}
+
+// stream carries a Terminal's input and output in frames.
+type stream struct {
+	c *Conn
+	// pending holds input that didn't fit the buffer passed to Read.
+	pending []byte
+	t       *terminal.Terminal
+}
+
+// Read returns the input in data frames. Resizes are applied to the terminal,
+// and an interrupt signal is typed as Ctrl-C.
+func (s *stream) Read(b []byte) (n int, err error) {
+	for len(s.pending) == 0 {
+		fr, err := s.c.Receive()
+		if err != nil {
+			return 0, err
+		}
+		switch fr.Type {
+		case FrameData:
+			s.pending = fr.Data
+		case FrameResize:
+			if fr.Width > 0 && fr.Height > 0 {
+				s.t.SetSize(fr.Width, fr.Height)
+				s.t.Refresh()
+			}
+		case FrameSignal:
+			if syscall.Signal(fr.Signal) == syscall.SIGINT {
+				s.pending = []byte{terminal.KeyCtrlC}
+			}
+		}
+	}
+	n = copy(b, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *stream) Write(b []byte) (n int, err error) {
+	if err := s.c.Send(&Frame{Type: FrameData, Data: b}); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// ServeTerminal serves a Terminal using prompt to the client on rw. serve
+// interacts with the user through it, and the status it returns is sent to
+// the client as the exit status. The terminal's size follows the client's.
+func ServeTerminal(rw io.ReadWriter, prompt string, serve func(t *terminal.Terminal) int) error {
+	s := &stream{c: NewConn(rw)}
+	s.t = terminal.NewTerminal(s, prompt, true)
+	s.t.SetTranslateNewlines(true)
+	status := serve(s.t)
+	return s.c.Send(&Frame{Type: FrameExit, ExitStatus: status})
+}
@@ -0,0 +1,366 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Flag describes an option of a command, given as -name or --name.
+type Flag struct {
+	// Name is the flag's name, without dashes.
+	Name string
+	Help string
+	// Arg, if not empty, names the value the flag takes, given as
+	// -name value or -name=value. Flags without one are booleans.
+	Arg string
+}
+
+// Command is a command of a CommandSet.
+type Command struct {
+	// Name is the word the command is invoked with.
+	Name string
+	// Usage describes the command's arguments, e.g. "[file...]".
+	Usage string
+	// Help describes the command. Its first line is shown in the list
+	// of commands.
+	Help  string
+	Flags []Flag
+	// Run is called with the command's flags and arguments.
+	Run func(a *Args) error
+	// Complete, if not nil, returns the completions of an argument of
+	// the command, which ctx describes.
+	Complete func(ctx terminal.TokenContext) []string
+}
+
+// Args holds the flags and arguments a command was invoked with.
+type Args struct {
+	// Terminal is the terminal the command was entered on.
+	Terminal *terminal.Terminal
+	// Name is the name of the command.
+	Name string
+	// Words are the arguments following the flags.
+	Words []string
+
+	flags map[string]string
+}
+
+// Flag returns the value of the flag name and whether it was given. Boolean
+// flags that were given have the value "true".
+func (a *Args) Flag(name string) (string, bool) {
+	v, ok := a.flags[name]
+	return v, ok
+}
+
+// Bool reports whether the flag name was given.
+func (a *Args) Bool(name string) bool {
+	_, ok := a.flags[name]
+	return ok
+}
+
+// CommandSet is a set of commands, whose names, flags and help are used both
+// to complete command lines and for the "help" command, which every
+// CommandSet has unless a command of that name is registered. The zero value
+// is an empty set.
+type CommandSet struct {
+	lock     sync.Mutex
+	commands []*Command
+}
+
+// Register adds c to the set, replacing any command of the same name.
+func (s *CommandSet) Register(c Command) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if i := slices.IndexFunc(s.commands, func(o *Command) bool { return o.Name == c.Name }); i >= 0 {
+		s.commands[i] = &c
+		return
+	}
+	s.commands = append(s.commands, &c)
+}
+
+// Lookup returns the command called name, or nil if there is none.
+func (s *CommandSet) Lookup(name string) *Command {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, c := range s.commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	if name == "help" {
+		return s.helpCommand()
+	}
+	return nil
+}
+
+// Commands returns the commands of the set, sorted by name.
+func (s *CommandSet) Commands() []*Command {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	commands := slices.Clone(s.commands)
+	if !slices.ContainsFunc(commands, func(c *Command) bool { return c.Name == "help" }) {
+		commands = append(commands, s.helpCommand())
+	}
+	slices.SortFunc(commands, func(a, b *Command) int { return strings.Compare(a.Name, b.Name) })
+	return commands
+}
+
+// Exec runs the command line on t and returns the error of the command, or
+// of parsing the line.
+func (s *CommandSet) Exec(t *terminal.Terminal, line string) error {
+	words, err := split(line)
+	if err != nil || len(words) == 0 {
+		return err
+	}
+	c := s.Lookup(words[0])
+	if c == nil {
+		return fmt.Errorf("unknown command %q", words[0])
+	}
+	a, err := parseArgs(c, words[1:])
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.Name, err)
+	}
+	a.Terminal = t
+	if c.Run == nil {
+		return nil
+	}
+	return c.Run(a)
+}
+
+// Complete returns the completions of the word ctx describes, to be used as
+// a readline.WordCompleter. The first word of a line completes to command
+// names, and words starting with "-" before the command's arguments to its
+// flags. Arguments are completed by the command's Complete.
+func (s *CommandSet) Complete(ctx terminal.TokenContext) []string {
+	if ctx.Arg == 0 {
+		if ctx.Index > 0 {
+			// Past an operator, which commands don't take.
+			return nil
+		}
+		return s.names(ctx.Prefix)
+	}
+	words := ctx.Tokens[ctx.Index-ctx.Arg : ctx.Index]
+	c := s.Lookup(words[0].Text)
+	if c == nil {
+		return nil
+	}
+
+	inFlags := true
+	for i := 1; i < len(words) && inFlags; i++ {
+		w := words[i].Text
+		if w == "--" || !isFlag(w) {
+			inFlags = false
+			break
+		}
+		if f := c.flag(w); f != nil && f.Arg != "" && !strings.Contains(w, "=") {
+			if i == len(words)-1 {
+				// The word is the flag's value.
+				return nil
+			}
+			i++
+		}
+	}
+	if inFlags && strings.HasPrefix(ctx.Prefix, "-") {
+		dashes := "-"
+		if strings.HasPrefix(ctx.Prefix, "--") {
+			dashes = "--"
+		}
+		var flags []string
+		for _, f := range c.Flags {
+			if strings.HasPrefix(dashes+f.Name, ctx.Prefix) {
+				flags = append(flags, dashes+f.Name)
+			}
+		}
+		return flags
+	}
+	if c.Complete == nil {
+		return nil
+	}
+	return c.Complete(ctx)
+}
+
+// names returns the names of the commands starting with prefix.
+func (s *CommandSet) names(prefix string) []string {
+	var names []string
+	for _, c := range s.Commands() {
+		if strings.HasPrefix(c.Name, prefix) {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// Help returns the help of the command called name, or a list of all
+// commands with the first line of their help if name is empty, laid out to
+// fit into width columns for a terminal with profile p. Each line ends in
+// "\r\n".
+func (s *CommandSet) Help(p terminal.ColorProfile, width int, name string) (string, error) {
+	bold := terminal.Style{Bold: true}
+	if name == "" {
+		tab := terminal.Table{Wrap: true}
+		for _, c := range s.Commands() {
+			summary, _, _ := strings.Cut(c.Help, "\n")
+			tab.Rows = append(tab.Rows, []string{bold.Render(p, c.Name), summary})
+		}
+		return tab.Render(p, width), nil
+	}
+
+	c := s.Lookup(name)
+	if c == nil {
+		return "", fmt.Errorf("unknown command %q", name)
+	}
+	var b strings.Builder
+	b.WriteString(wrap("usage: "+bold.Render(p, c.Name)+synopsis(c), width))
+	if c.Help != "" {
+		b.WriteString("\r\n")
+		b.WriteString(wrap(c.Help, width))
+	}
+	if len(c.Flags) > 0 {
+		b.WriteString("\r\n")
+		tab := terminal.Table{Wrap: true}
+		for _, f := range c.Flags {
+			tab.Rows = append(tab.Rows, []string{"  " + flagUsage(f), f.Help})
+		}
+		b.WriteString(tab.Render(p, width))
+	}
+	return b.String(), nil
+}
+
+// helpCommand returns the built-in "help" command.
+func (s *CommandSet) helpCommand() *Command {
+	return &Command{
+		Name:  "help",
+		Usage: "[command]",
+		Help:  "Lists the commands, or describes the one named.",
+		Run: func(a *Args) error {
+			if len(a.Words) > 1 {
+				return errors.New("help: too many arguments")
+			}
+			var name string
+			if len(a.Words) == 1 {
+				name = a.Words[0]
+			}
+			width, _ := a.Terminal.Size()
+			text, err := s.Help(a.Terminal.ColorProfile(), width-1, name)
+			if err != nil {
+				return err
+			}
+			_, err = a.Terminal.Write([]byte(text))
+			return err
+		},
+		Complete: func(ctx terminal.TokenContext) []string {
+			if ctx.Arg != 1 {
+				return nil
+			}
+			return s.names(ctx.Prefix)
+		},
+	}
+}
+
+// flag returns the flag of c that the word w, which starts with "-", gives,
+// or nil.
+func (c *Command) flag(w string) *Flag {
+	name := strings.TrimPrefix(w[1:], "-")
+	name, _, _ = strings.Cut(name, "=")
+	for i := range c.Flags {
+		if c.Flags[i].Name == name {
+			return &c.Flags[i]
+		}
+	}
+	return nil
+}
+
+// isFlag reports whether the word w is a flag rather than an argument.
+func isFlag(w string) bool {
+	return len(w) >= 2 && w[0] == '-'
+}
+
+// synopsis returns the flags and arguments c takes, following its name.
+func synopsis(c *Command) string {
+	var b strings.Builder
+	for _, f := range c.Flags {
+		b.WriteString(" [" + flagUsage(f) + "]")
+	}
+	if c.Usage != "" {
+		b.WriteString(" " + c.Usage)
+	}
+	return b.String()
+}
+
+// flagUsage returns how f is given, e.g. "-f file".
+func flagUsage(f Flag) string {
+	if f.Arg == "" {
+		return "-" + f.Name
+	}
+	return "-" + f.Name + " " + f.Arg
+}
+
+// wrap returns text wrapped to width columns, with each line ending in
+// "\r\n".
+func wrap(text string, width int) string {
+	return strings.ReplaceAll(terminal.Wrap(text, width), "\n", "\r\n") + "\r\n"
+}
+
+// split returns the words of line.
+func split(line string) ([]string, error) {
+	ctx := terminal.Tokenize(line, len(line))
+	var words []string
+	for _, tok := range ctx.Tokens {
+		if tok.Operator {
+			return nil, fmt.Errorf("unexpected %q", line[tok.Start:tok.End])
+		}
+		if tok.Quote != terminal.NoQuote {
+			return nil, errors.New("unterminated quote")
+		}
+		words = append(words, tok.Text)
+	}
+	return words, nil
+}
+
+// parseArgs parses the flags of c at the start of words. Flags end at the
+// first word not starting with "-", or at "--".
+func parseArgs(c *Command, words []string) (*Args, error) {
+	a := &Args{Name: c.Name, flags: make(map[string]string)}
+	for len(words) > 0 {
+		w := words[0]
+		if w == "--" {
+			words = words[1:]
+			break
+		}
+		if !isFlag(w) {
+			break
+		}
+		words = words[1:]
+
+		f := c.flag(w)
+		if f == nil {
+			return nil, fmt.Errorf("unknown flag %q", w)
+		}
+		_, value, hasValue := strings.Cut(w, "=")
+		switch {
+		case f.Arg == "" && hasValue:
+			return nil, fmt.Errorf("flag -%s takes no value", f.Name)
+		case f.Arg == "":
+			value = "true"
+		case !hasValue:
+			if len(words) == 0 {
+				return nil, fmt.Errorf("flag -%s needs a value", f.Name)
+			}
+			value, words = words[0], words[1:]
+		}
+		a.flags[f.Name] = value
+	}
+	a.Words = words
+	return a, nil
+}
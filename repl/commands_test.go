@@ -0,0 +1,111 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package repl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+	"github.com/LordEliasTM/pseudo-terminal-go/terminaltest"
+)
+
+func testCommands() *CommandSet {
+	s := new(CommandSet)
+	s.Register(Command{
+		Name:  "copy",
+		Usage: "src dst",
+		Help:  "Copies src to dst.\nExisting files are kept unless -force is given.",
+		Flags: []Flag{
+			{Name: "force", Help: "overwrite dst"},
+			{Name: "format", Arg: "fmt", Help: "convert to fmt"},
+		},
+		Complete: func(terminal.TokenContext) []string { return []string{"file"} },
+	})
+	s.Register(Command{Name: "cd", Help: "Changes the directory."})
+	return s
+}
+
+func TestCommandSetComplete(t *testing.T) {
+	s := testCommands()
+	for _, test := range []struct {
+		line string
+		want []string
+	}{
+		{"c", []string{"cd", "copy"}},
+		{"h", []string{"help"}},
+		{"copy -f", []string{"-force", "-format"}},
+		{"copy --fo", []string{"--force", "--format"}},
+		{"copy -format ", nil},
+		{"copy -format=x ", []string{"file"}},
+		{"copy -force -fo", []string{"-force", "-format"}},
+		{"copy a -f", []string{"file"}},
+		{"copy -- -f", []string{"file"}},
+		{"help c", []string{"cd", "copy"}},
+		{"nope ", nil},
+		{"cd ; c", nil},
+	} {
+		got := s.Complete(terminal.Tokenize(test.line, len(test.line)))
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%q: got %q, expected %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestCommandSetHelp(t *testing.T) {
+	s := testCommands()
+
+	got, err := s.Help(terminal.NoColor, 40, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "cd    Changes the directory.\r\n" +
+		"copy  Copies src to dst.\r\n" +
+		"help  Lists the commands, or describes\r\n" +
+		"      the one named.\r\n"
+	if got != want {
+		t.Errorf("got list\n%q, expected\n%q", got, want)
+	}
+
+	got, err = s.Help(terminal.NoColor, 40, "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "usage: copy [-force] [-format fmt] src\r\n" +
+		"dst\r\n" +
+		"\r\n" +
+		"Copies src to dst.\r\n" +
+		"Existing files are kept unless -force is\r\n" +
+		"given.\r\n" +
+		"\r\n" +
+		"  -force       overwrite dst\r\n" +
+		"  -format fmt  convert to fmt\r\n"
+	if got != want {
+		t.Errorf("got help\n%q, expected\n%q", got, want)
+	}
+
+	if _, err := s.Help(terminal.NoColor, 40, "nope"); err == nil {
+		t.Error("got no error for an unknown command")
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	tt, c := terminaltest.New("", 40, 10)
+	tt.SetColorProfile(terminal.NoColor)
+	r := New(tt, "> ")
+	r.Register(Command{Name: "cd", Help: "Changes the directory."})
+
+	c.Type("help\r", "help cd\r", "help cd x\r")
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	out := c.Output()
+	for _, s := range []string{"cd    Changes the directory.", "usage: cd\r\n", "help: too many arguments"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("got %q, expected it to contain %q", out, s)
+		}
+	}
+}
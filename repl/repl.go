@@ -0,0 +1,133 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package repl runs a read-eval-print loop of commands on a terminal.Terminal.
+// Commands are registered with their flags and help, and the REPL reads
+// lines, splits them into words as a shell does, parses the flags and calls
+// the command's handler, printing any error it returns:
+//
+//	r := repl.New(t, "> ")
+//	r.Register(repl.Command{
+//		Name:  "greet",
+//		Help:  "Greets everyone named.",
+//		Flags: []repl.Flag{{Name: "loud", Help: "shout"}},
+//		Run: func(a *repl.Args) error {
+//			for _, name := range a.Words {
+//				if a.Bool("loud") {
+//					name = strings.ToUpper(name)
+//				}
+//				a.Terminal.Printf("hello, %s\n", name)
+//			}
+//			return nil
+//		},
+//	})
+//	if err := r.Run(); err != nil {
+//		...
+//	}
+//
+// Lines entered are added to the history, and Tab completes command names,
+// flags and, through Command.Complete, arguments. The "help" command lists
+// the commands, or describes the one named.
+package repl
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/readline"
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// ErrExit may be returned by a command to end Run without an error.
+var ErrExit = errors.New("repl: exit")
+
+// REPL reads commands from a Terminal and runs them.
+type REPL struct {
+	t      *terminal.Terminal
+	rl     *readline.State
+	prompt string
+
+	commands *CommandSet
+
+	lock       sync.Mutex
+	errorStyle terminal.Style
+}
+
+// New returns a REPL reading commands from t after showing prompt. It takes
+// over t's AutoCompleteCallback and history.
+func New(t *terminal.Terminal, prompt string) *REPL {
+	r := &REPL{
+		t:          t,
+		rl:         readline.NewTerminal(t),
+		prompt:     prompt,
+		commands:   new(CommandSet),
+		errorStyle: terminal.Style{Foreground: terminal.ANSIColor(1)},
+	}
+	r.rl.SetWordCompleter(r.commands.Complete)
+	return r
+}
+
+// Register adds c to the commands of the REPL, replacing any command of the
+// same name.
+func (r *REPL) Register(c Command) {
+	r.commands.Register(c)
+}
+
+// Commands returns the commands of the REPL.
+func (r *REPL) Commands() *CommandSet {
+	return r.commands
+}
+
+// SetErrorStyle sets the style errors are printed in, red by default.
+func (r *REPL) SetErrorStyle(s terminal.Style) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.errorStyle = s
+}
+
+// Run reads and runs commands until the input ends, the user presses Ctrl-D
+// on an empty line, or a command returns ErrExit, and then returns nil.
+// Errors of commands are printed, and Ctrl-C abandons the line being
+// entered. Any other error reading a line is returned.
+func (r *REPL) Run() error {
+	for {
+		line, err := r.rl.Readline(r.prompt)
+		switch {
+		case err == io.EOF:
+			return nil
+		case errors.Is(err, terminal.ErrInterrupted):
+			continue
+		case err != nil:
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		r.rl.AddHistory(line)
+
+		if err := r.Exec(line); errors.Is(err, ErrExit) {
+			return nil
+		} else if err != nil {
+			r.printError(err)
+		}
+	}
+}
+
+// Exec runs the command line and returns the error of the command, or of
+// parsing the line.
+func (r *REPL) Exec(line string) error {
+	return r.commands.Exec(r.t, line)
+}
+
+// printError prints err in the error style.
+func (r *REPL) printError(err error) {
+	r.lock.Lock()
+	s := r.errorStyle
+	r.lock.Unlock()
+
+	r.t.Println(terminal.StyledSpan{Text: "error: " + err.Error(), Style: s})
+}
@@ -0,0 +1,118 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package repl
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+	"github.com/LordEliasTM/pseudo-terminal-go/terminaltest"
+)
+
+func TestRun(t *testing.T) {
+	tt, c := terminaltest.New("", 60, 10)
+	r := New(tt, "> ")
+
+	var got []string
+	r.Register(Command{
+		Name:  "echo",
+		Flags: []Flag{{Name: "n"}, {Name: "sep", Arg: "string"}},
+		Run: func(a *Args) error {
+			sep, ok := a.Flag("sep")
+			if !ok {
+				sep = " "
+			}
+			s := strings.Join(a.Words, sep)
+			if !a.Bool("n") {
+				s += "\n"
+			}
+			got = append(got, s)
+			return nil
+		},
+	})
+	r.Register(Command{Name: "fail", Run: func(*Args) error { return errors.New("boom") }})
+	r.Register(Command{Name: "quit", Run: func(*Args) error { return ErrExit }})
+
+	c.Type(
+		"echo 'a b' c\r",
+		"echo -n --sep=, x y\r",
+		"echo -sep - -- -x\r",
+		"fail\r",
+		"nope\r",
+		"echo -v\r",
+		"quit\r",
+		"echo unreached\r",
+	)
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := []string{"a b c\n", "x,y", "-x\n"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+	out := c.Output()
+	for _, msg := range []string{"error: boom", `error: unknown command "nope"`, `error: echo: unknown flag "-v"`} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("got %q, expected %q to be printed", out, msg)
+		}
+	}
+	if strings.Contains(out, "unreached") {
+		t.Errorf("got %q, expected Run to stop at quit", out)
+	}
+}
+
+func TestRunHistory(t *testing.T) {
+	tt, c := terminaltest.New("", 60, 10)
+	r := New(tt, "> ")
+	n := 0
+	r.Register(Command{Name: "count", Run: func(*Args) error { n++; return nil }})
+
+	c.Type("count\r", "\r", "\x1b[A\r")
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("ran count %d times, expected the history to repeat it", n)
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	tt, c := terminaltest.New("", 60, 10)
+	r := New(tt, "> ")
+	var got []string
+	r.Register(Command{
+		Name: "open",
+		Run:  func(a *Args) error { got = a.Words; return nil },
+		Complete: func(ctx terminal.TokenContext) []string {
+			return []string{"my file"}
+		},
+	})
+	r.Register(Command{Name: "quit"})
+
+	c.Type("o\tm\t\r")
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := []string{"my file"}; !slices.Equal(got, want) {
+		t.Errorf("got arguments %q, expected %q", got, want)
+	}
+}
+
+func TestExecErrors(t *testing.T) {
+	tt, _ := terminaltest.New("", 60, 10)
+	r := New(tt, "> ")
+	r.Register(Command{Name: "cmd", Flags: []Flag{{Name: "f", Arg: "file"}, {Name: "b"}}})
+
+	for _, line := range []string{"cmd 'open", "cmd ; cmd", "cmd -f", "cmd -b=1"} {
+		if err := r.Exec(line); err == nil {
+			t.Errorf("Exec(%q) succeeded, expected an error", line)
+		}
+	}
+	if err := r.Exec("  "); err != nil {
+		t.Errorf("Exec of an empty line: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && !ppc64 && !ppc64le
+
+package serial
+
+// cbaud masks the speed in the control flags.
+const cbaud = 0010017
@@ -0,0 +1,122 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package serial drives consoles on serial lines, such as the ones of
+// embedded devices on /dev/ttyUSB0. Open sets up the port, and Conn paces the
+// output to the line speed, so that devices with small buffers and no flow
+// control keep up, and makes the Enter key work whichever line ending the
+// other side sends.
+package serial
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// ErrUnsupported is returned on systems where serial ports aren't supported.
+var ErrUnsupported = errors.New("serial: not supported on this system")
+
+// ErrBaudRate is returned by Open for a line speed the system doesn't
+// support.
+var ErrBaudRate = errors.New("serial: unsupported baud rate")
+
+// chunkSize is the number of bytes Conn writes at once. Larger writes are
+// split, and each chunk waits until the line has had time to send the ones
+// before it.
+const chunkSize = 64
+
+// Conn is a console on a serial line. What's read from it is the input with
+// "\r\n" and a lone "\n" turned into "\r", the Enter key.
+type Conn struct {
+	rw   io.ReadWriter
+	baud int
+
+	// crlf is set after a carriage return is read; a line feed following
+	// it is dropped.
+	crlf bool
+	// ready is the time the line finishes sending what was written.
+	ready time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewConn returns a Conn on rw, usually a port returned by Open, that paces
+// its output to baud bits per second. A baud of 0 turns pacing off.
+func NewConn(rw io.ReadWriter, baud int) *Conn {
+	return &Conn{rw: rw, baud: baud, now: time.Now, sleep: time.Sleep}
+}
+
+// NewTerminal returns a Terminal using prompt on a new Conn. A serial line
+// doesn't tell the size of the screen at the other end, so it's left at
+// the terminal package's default until SetSize is called.
+func NewTerminal(rw io.ReadWriter, baud int, prompt string) (*terminal.Terminal, *Conn) {
+	c := NewConn(rw, baud)
+	t := terminal.NewTerminal(c, prompt, true)
+	// Consoles expect "\r\n", and the port doesn't translate output.
+	t.SetTranslateNewlines(true)
+	return t, c
+}
+
+// Read reads input from the line.
+func (c *Conn) Read(b []byte) (n int, err error) {
+	for n == 0 && err == nil && len(b) > 0 {
+		var m int
+		m, err = c.rw.Read(b)
+		for _, ch := range b[:m] {
+			crlf := c.crlf
+			c.crlf = ch == '\r'
+			switch {
+			case ch == '\n' && crlf:
+				continue
+			case ch == '\n':
+				ch = '\r'
+			}
+			b[n] = ch
+			n++
+		}
+		if m == 0 {
+			break
+		}
+	}
+	return n, err
+}
+
+// Write writes b to the line, waiting as long as it takes to send at the
+// line speed.
+func (c *Conn) Write(b []byte) (n int, err error) {
+	if c.baud <= 0 {
+		return c.rw.Write(b)
+	}
+	for n < len(b) {
+		chunk := b[n:]
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		now := c.now()
+		if wait := c.ready.Sub(now); wait > 0 {
+			c.sleep(wait)
+			now = c.ready
+		}
+		m, err := c.rw.Write(chunk)
+		n += m
+		// A byte takes 10 bits on the line, with the start and stop
+		// bits.
+		c.ready = now.Add(time.Duration(m) * 10 * time.Second / time.Duration(c.baud))
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Drain waits until the line has had time to send everything written.
+func (c *Conn) Drain() {
+	if wait := c.ready.Sub(c.now()); wait > 0 {
+		c.sleep(wait)
+	}
+}
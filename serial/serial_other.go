@@ -0,0 +1,14 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package serial
+
+import "os"
+
+// Open opens a serial port. It is only supported on Linux.
+func Open(name string, baud int) (*os.File, error) {
+	return nil, ErrUnsupported
+}
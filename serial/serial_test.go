@@ -0,0 +1,129 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package serial
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/pty"
+)
+
+func TestRead(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"a\r\nb\nc\rd", "a\rb\rc\rd"},
+		{"\r\n\r\n", "\r\r"},
+		{"\n\n", "\r\r"},
+	} {
+		// Reading a byte at a time checks that line endings split
+		// across reads are handled.
+		for _, r := range []io.Reader{strings.NewReader(test.in), iotest.OneByteReader(strings.NewReader(test.in))} {
+			c := NewConn(struct {
+				io.Reader
+				io.Writer
+			}{r, io.Discard}, 0)
+			b, err := io.ReadAll(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.want {
+				t.Errorf("read %q from %q, expected %q", b, test.in, test.want)
+			}
+		}
+	}
+}
+
+func TestWritePacing(t *testing.T) {
+	var out bytes.Buffer
+	c := NewConn(struct {
+		io.Reader
+		io.Writer
+	}{nil, &out}, 9600)
+	now := time.Unix(0, 0)
+	var slept []time.Duration
+	c.now = func() time.Time { return now }
+	c.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+
+	// 96 bytes take 100ms at 9600 baud; the second chunk waits until the
+	// first one is sent.
+	n, err := c.Write(bytes.Repeat([]byte("x"), 96))
+	if n != 96 || err != nil {
+		t.Fatalf("Write returned %d, %v", n, err)
+	}
+	if out.Len() != 96 {
+		t.Errorf("wrote %d bytes, expected 96", out.Len())
+	}
+	chunk := chunkSize * 10 * time.Second / 9600
+	if len(slept) != 1 || slept[0] != chunk {
+		t.Errorf("slept %v, expected %v", slept, chunk)
+	}
+
+	// Time passing on its own counts.
+	now = now.Add(time.Second)
+	slept = nil
+	c.Write([]byte("y"))
+	c.Drain()
+	if len(slept) != 1 || slept[0] != 10*time.Second/9600 {
+		t.Errorf("slept %v, expected only for the last byte", slept)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("can't open a pseudo-terminal: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+
+	if _, err := Open(tty.Name(), 12345); err != ErrBaudRate {
+		t.Errorf("Open returned %v for an odd baud rate, expected ErrBaudRate", err)
+	}
+	f, err := Open(tty.Name(), 115200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// In raw mode, "\n" isn't turned into "\r\n" and input isn't echoed.
+	f.Write([]byte("a\n"))
+	ptmx.Write([]byte("b"))
+	b := make([]byte, 10)
+	n, err := ptmx.Read(b)
+	if err != nil || string(b[:n]) != "a\n" {
+		t.Errorf("read %q, %v from the other side, expected a\\n", b[:n], err)
+	}
+	n, err = f.Read(b)
+	if err != nil || string(b[:n]) != "b" {
+		t.Errorf("read %q, %v, expected b", b[:n], err)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	in := strings.NewReader("hello\r\n")
+	var out bytes.Buffer
+	term, _ := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{in, &out}, 0, "> ")
+	line, err := term.ReadLine()
+	if err != nil || line != "hello" {
+		t.Errorf("got %q, %v, expected hello", line, err)
+	}
+	// The line feed after the carriage return isn't taken as a second
+	// line.
+	if line, err := term.ReadLine(); err != io.EOF {
+		t.Errorf("got %q, %v, expected io.EOF", line, err)
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package session runs programs on pseudo-terminals that outlive the
+// connections of their users. A client, such as the channel of an SSH
+// connection or a web console, attaches to a session, and when it disconnects
+// the program keeps running until another client attaches and sees the screen
+// as it was left. Observers can watch a session at the same time without
+// typing into it.
+package session
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/emulator"
+	"github.com/LordEliasTM/pseudo-terminal-go/pty"
+)
+
+// ErrEnded is returned by Attach when the program of the session has ended.
+var ErrEnded = errors.New("session: program ended")
+
+// Session is a program running on a pseudo-terminal, with its screen kept in
+// an emulator.
+type Session struct {
+	id  string
+	cmd *exec.Cmd
+	pty *os.File
+
+	lock   sync.Mutex
+	screen *emulator.Screen
+	// client is the attached client, if any, and detached is closed when
+	// it's detached.
+	client   io.ReadWriter
+	detached chan struct{}
+	// observers maps the clients watching the session to channels that
+	// are closed when they stop.
+	observers map[io.ReadWriter]chan struct{}
+	done      chan struct{}
+}
+
+// Start starts cmd on a pseudo-terminal of the given size in a new session.
+func Start(cmd *exec.Cmd, width, height int) (*Session, error) {
+	f, err := pty.Start(cmd)
+	if err != nil {
+		return nil, err
+	}
+	pty.SetSize(f, width, height)
+	s := &Session{
+		cmd:    cmd,
+		pty:    f,
+		screen: emulator.New(width, height),
+		done:   make(chan struct{}),
+	}
+	go s.pump()
+	return s, nil
+}
+
+// ID returns the session's ID within its Manager.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// pump copies the program's output to the screen, the attached client and
+// the observers.
+func (s *Session) pump() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.pty.Read(buf)
+		s.lock.Lock()
+		s.screen.Write(buf[:n])
+		if s.client != nil {
+			if _, werr := s.client.Write(buf[:n]); werr != nil {
+				s.detach()
+			}
+		}
+		for o := range s.observers {
+			if _, werr := o.Write(buf[:n]); werr != nil {
+				s.unwatch(o)
+			}
+		}
+		s.lock.Unlock()
+		if err != nil {
+			break
+		}
+	}
+	s.cmd.Wait()
+	s.pty.Close()
+
+	// done is closed first, so that Attach and Watch see why the client
+	// was detached.
+	s.lock.Lock()
+	close(s.done)
+	s.detach()
+	for o := range s.observers {
+		s.unwatch(o)
+	}
+	s.lock.Unlock()
+}
+
+// Attach connects a client to the session. The screen is drawn on it as it
+// is now, then the program's output is passed to it and what's read from it is
+// sent to the program. The client must be a terminal, in raw mode, of the
+// size of the session. A client attached before is detached.
+//
+// Attach returns nil when the client is detached, either by Detach or
+// another client, or because reading from or writing to it fails, as it does
+// when it disconnects. It returns ErrEnded when the program has ended.
+func (s *Session) Attach(client io.ReadWriter) error {
+	s.lock.Lock()
+	select {
+	case <-s.done:
+		s.lock.Unlock()
+		return ErrEnded
+	default:
+	}
+	s.detach()
+	if _, err := client.Write(s.screen.Render()); err != nil {
+		s.lock.Unlock()
+		return nil
+	}
+	detached := make(chan struct{})
+	s.client, s.detached = client, detached
+	s.lock.Unlock()
+
+	go s.readInput(client)
+
+	select {
+	case <-detached:
+		select {
+		case <-s.done:
+			return ErrEnded
+		default:
+			return nil
+		}
+	case <-s.done:
+		return ErrEnded
+	}
+}
+
+// readInput sends what's read from client to the program while the client is
+// attached. Input read after it's detached is dropped.
+func (s *Session) readInput(client io.ReadWriter) {
+	buf := make([]byte, 1024)
+	for {
+		n, err := client.Read(buf)
+
+		s.lock.Lock()
+		attached := s.client == client
+		if attached && err != nil {
+			s.detach()
+		}
+		s.lock.Unlock()
+
+		if !attached {
+			return
+		}
+		if n > 0 {
+			s.pty.Write(buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// detach detaches the attached client, if any. s.lock must be held.
+func (s *Session) detach() {
+	if s.client == nil {
+		return
+	}
+	s.client = nil
+	close(s.detached)
+}
+
+// Detach detaches the attached client, if any, leaving the program running.
+func (s *Session) Detach() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.detach()
+}
+
+// Watch connects a read-only observer to the session, e.g. for someone
+// following along while another user works in it. Like an attached client, the
+// observer sees the screen as it is now and then the program's output, but
+// what's read from it is dropped. Any number of observers can watch a session
+// at the same time, alongside the attached client. Writing to a slow observer
+// holds up the program's output for everyone.
+//
+// Watch returns nil when reading from or writing to the observer fails, as it
+// does when it disconnects, and ErrEnded when the program has ended.
+func (s *Session) Watch(observer io.ReadWriter) error {
+	s.lock.Lock()
+	select {
+	case <-s.done:
+		s.lock.Unlock()
+		return ErrEnded
+	default:
+	}
+	if _, err := observer.Write(s.screen.Render()); err != nil {
+		s.lock.Unlock()
+		return nil
+	}
+	stopped := make(chan struct{})
+	if s.observers == nil {
+		s.observers = make(map[io.ReadWriter]chan struct{})
+	}
+	s.observers[observer] = stopped
+	s.lock.Unlock()
+
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			if _, err := observer.Read(buf); err != nil {
+				break
+			}
+		}
+		s.lock.Lock()
+		if s.observers[observer] == stopped {
+			s.unwatch(observer)
+		}
+		s.lock.Unlock()
+	}()
+
+	select {
+	case <-stopped:
+		select {
+		case <-s.done:
+			return ErrEnded
+		default:
+			return nil
+		}
+	case <-s.done:
+		return ErrEnded
+	}
+}
+
+// unwatch stops sending output to observer. s.lock must be held.
+func (s *Session) unwatch(observer io.ReadWriter) {
+	if stopped, ok := s.observers[observer]; ok {
+		delete(s.observers, observer)
+		close(stopped)
+	}
+}
+
+// Observers returns the number of observers watching the session.
+func (s *Session) Observers() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.observers)
+}
+
+// Resize changes the size of the session's terminal, and its screen, after the
+// client's terminal changed size.
+func (s *Session) Resize(width, height int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.screen.Resize(width, height)
+	return pty.SetSize(s.pty, width, height)
+}
+
+// Screen returns the text shown on the session's screen.
+func (s *Session) Screen() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.screen.String()
+}
+
+// Done returns a channel that's closed when the program has ended.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Kill ends the program.
+func (s *Session) Kill() error {
+	return s.cmd.Process.Kill()
+}
+
+// Manager keeps track of running sessions by ID, so that clients can attach
+// to them again later.
+type Manager struct {
+	lock     sync.Mutex
+	sessions map[string]*Session
+	nextID   int
+}
+
+// NewManager returns a Manager without any sessions.
+func NewManager() *Manager {
+	return &Manager{sessions: make(map[string]*Session)}
+}
+
+// Start starts cmd in a new session, which is forgotten when the program
+// ends.
+func (m *Manager) Start(cmd *exec.Cmd, width, height int) (*Session, error) {
+	s, err := Start(cmd, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.lock.Lock()
+	m.nextID++
+	s.id = strconv.Itoa(m.nextID)
+	m.sessions[s.id] = s
+	m.lock.Unlock()
+
+	go func() {
+		<-s.done
+		m.lock.Lock()
+		delete(m.sessions, s.id)
+		m.lock.Unlock()
+	}()
+	return s, nil
+}
+
+// Get returns the session with the given ID, or nil if there's none.
+func (m *Manager) Get(id string) *Session {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.sessions[id]
+}
+
+// List returns the IDs of the running sessions, oldest first.
+func (m *Manager) List() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ids := make([]string, 0, len(m.sessions))
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+	return ids
+}
@@ -0,0 +1,152 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package session
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type client struct {
+	*io.PipeReader
+	in  *io.PipeWriter
+	mu  sync.Mutex
+	out bytes.Buffer
+}
+
+func newClient() *client {
+	r, w := io.Pipe()
+	return &client{PipeReader: r, in: w}
+}
+
+func (c *client) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.out.Write(b)
+}
+
+func (c *client) output() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.out.String()
+}
+
+// waitFor waits until cond is true.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDetachAndAttach(t *testing.T) {
+	m := NewManager()
+	s, err := m.Start(exec.Command("sh", "-c", `echo hello; read x; echo "got $x"`), 40, 10)
+	if err != nil {
+		t.Skipf("can't start a program on a pty: %v", err)
+	}
+	defer s.Kill()
+	if ids := m.List(); len(ids) != 1 || m.Get(ids[0]) != s {
+		t.Fatalf("got sessions %v", ids)
+	}
+	waitFor(t, "output", func() bool { return strings.Contains(s.Screen(), "hello") })
+
+	// The first client disconnects.
+	first := newClient()
+	errc := make(chan error)
+	go func() { errc <- s.Attach(first) }()
+	first.in.Close()
+	if err := <-errc; err != nil {
+		t.Fatalf("Attach returned %v after disconnecting, expected nil", err)
+	}
+
+	// The second one sees the screen as it was and continues.
+	second := newClient()
+	go func() { errc <- s.Attach(second) }()
+	waitFor(t, "the screen to be drawn", func() bool { return strings.Contains(second.output(), "hello") })
+	second.in.Write([]byte("yes\r"))
+	if err := <-errc; err != ErrEnded {
+		t.Fatalf("Attach returned %v after the program ended, expected ErrEnded", err)
+	}
+	if !strings.Contains(second.output(), "got yes") {
+		t.Errorf("got %q, expected the program's answer", second.output())
+	}
+	waitFor(t, "the session to be forgotten", func() bool { return len(m.List()) == 0 })
+}
+
+func TestAttachReplacesClient(t *testing.T) {
+	s, err := Start(exec.Command("sleep", "10"), 40, 10)
+	if err != nil {
+		t.Skipf("can't start a program on a pty: %v", err)
+	}
+	defer s.Kill()
+
+	first := newClient()
+	errc := make(chan error)
+	go func() { errc <- s.Attach(first) }()
+	waitFor(t, "the first client to attach", func() bool { return first.output() != "" })
+	go s.Attach(newClient())
+	if err := <-errc; err != nil {
+		t.Errorf("Attach returned %v when replaced, expected nil", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	s, err := Start(exec.Command("sh", "-c", `echo hello; read x; echo "got $x"; read x`), 40, 10)
+	if err != nil {
+		t.Skipf("can't start a program on a pty: %v", err)
+	}
+	defer s.Kill()
+	waitFor(t, "output", func() bool { return strings.Contains(s.Screen(), "hello") })
+
+	writer, first, second := newClient(), newClient(), newClient()
+	attached := make(chan error, 1)
+	go func() { attached <- s.Attach(writer) }()
+	watched := make(chan error, 2)
+	go func() { watched <- s.Watch(first) }()
+	go func() { watched <- s.Watch(second) }()
+	waitFor(t, "the observers", func() bool { return s.Observers() == 2 })
+	for _, o := range []*client{first, second} {
+		if !strings.Contains(o.output(), "hello") {
+			t.Errorf("observer got %q, expected the screen to be drawn", o.output())
+		}
+	}
+
+	// Observers can't type.
+	first.in.Write([]byte("no\r"))
+	writer.in.Write([]byte("yes\r"))
+	waitFor(t, "the answer", func() bool { return strings.Contains(second.output(), "got yes") })
+	if strings.Contains(s.Screen(), "no") {
+		t.Errorf("got %q, expected the observer's input to be dropped", s.Screen())
+	}
+
+	// One observer leaves.
+	first.in.Close()
+	if err := <-watched; err != nil {
+		t.Errorf("Watch returned %v after disconnecting, expected nil", err)
+	}
+	if n := s.Observers(); n != 1 {
+		t.Errorf("got %d observers, expected 1", n)
+	}
+
+	s.Kill()
+	if err := <-watched; err != ErrEnded {
+		t.Errorf("Watch returned %v after the program ended, expected ErrEnded", err)
+	}
+	if err := <-attached; err != ErrEnded {
+		t.Errorf("Attach returned %v after the program ended, expected ErrEnded", err)
+	}
+	if err := s.Watch(newClient()); err != ErrEnded {
+		t.Errorf("Watch returned %v for an ended session, expected ErrEnded", err)
+	}
+}
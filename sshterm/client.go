@@ -0,0 +1,139 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sshterm
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// ErrPtyRefused is returned by RunShell when the server doesn't give the
+// session a pseudo-terminal.
+var ErrPtyRefused = errors.New("sshterm: server refused a pseudo-terminal")
+
+// ClientSession is the client's side of an SSH session. A
+// golang.org/x/crypto/ssh Session satisfies it.
+type ClientSession interface {
+	SendRequest(name string, wantReply bool, payload []byte) (bool, error)
+	StdinPipe() (io.WriteCloser, error)
+	StdoutPipe() (io.Reader, error)
+	Shell() error
+	Wait() error
+}
+
+// Terminal modes sent with the pty-req, from RFC 4254.
+const (
+	modeEnd    = 0
+	modeEcho   = 53
+	modeISpeed = 128
+	modeOSpeed = 129
+)
+
+// ptyRequest returns the payload of a pty-req.
+func ptyRequest(term string, width, height int) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(term)))
+	b = append(b, term...)
+	b = binary.BigEndian.AppendUint32(b, uint32(width))
+	b = binary.BigEndian.AppendUint32(b, uint32(height))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = binary.BigEndian.AppendUint32(b, 0)
+
+	var modes []byte
+	for _, m := range []struct {
+		op    byte
+		value uint32
+	}{{modeEcho, 1}, {modeISpeed, 38400}, {modeOSpeed, 38400}} {
+		modes = append(modes, m.op)
+		modes = binary.BigEndian.AppendUint32(modes, m.value)
+	}
+	modes = append(modes, modeEnd)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(modes)))
+	return append(b, modes...)
+}
+
+// windowChange returns the payload of a window-change request.
+func windowChange(width, height int) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(width))
+	b = binary.BigEndian.AppendUint32(b, uint32(height))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	return binary.BigEndian.AppendUint32(b, 0)
+}
+
+// RunShell runs an interactive shell in session on the local terminal, on
+// standard input and output, like the ssh command does, and returns the
+// result of the session's Wait. The shell gets a pseudo-terminal of the local
+// terminal's size and type, which follows when the local one is resized. The
+// local terminal is in raw mode in the meantime, so it can also be used
+// between ReadLine calls on a Terminal from NewWithStdInOut.
+//
+// Standard input is read in the background, and a key typed after the shell
+// has ended is lost.
+func RunShell(session ClientSession) error {
+	fd := int(os.Stdin.Fd())
+	width, height, err := terminal.GetSize(fd)
+	if err != nil {
+		width, height = 80, 24
+	}
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer terminal.Restore(fd, state)
+
+	sizes, stop := watchSize(fd)
+	defer stop()
+	return runShell(session, os.Stdin, os.Stdout, os.Getenv("TERM"), width, height, sizes)
+}
+
+// runShell runs the shell for RunShell, sending the sizes received from
+// sizes as window changes.
+func runShell(session ClientSession, in io.Reader, out io.Writer, term string, width, height int, sizes <-chan [2]int) error {
+	if term == "" {
+		term = "vt100"
+	}
+	ok, err := session.SendRequest("pty-req", true, ptyRequest(term, width, height))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPtyRefused
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.Shell(); err != nil {
+		return err
+	}
+
+	go io.Copy(stdin, in)
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(out, stdout)
+		close(copied)
+	}()
+	waited := make(chan error, 1)
+	go func() {
+		waited <- session.Wait()
+	}()
+
+	for {
+		select {
+		case size := <-sizes:
+			session.SendRequest("window-change", false, windowChange(size[0], size[1]))
+		case err := <-waited:
+			<-copied
+			return err
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sshterm
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSession is a server that echoes the input of the shell until it reads
+// "exit".
+type fakeSession struct {
+	refuse bool
+
+	mu       sync.Mutex
+	requests []request
+
+	stdinR, stdoutR *io.PipeReader
+	stdinW, stdoutW *io.PipeWriter
+	resized         chan struct{}
+	done            chan struct{}
+}
+
+func newSession() *fakeSession {
+	s := &fakeSession{resized: make(chan struct{}, 1), done: make(chan struct{})}
+	s.stdinR, s.stdinW = io.Pipe()
+	s.stdoutR, s.stdoutW = io.Pipe()
+	return s
+}
+
+func (s *fakeSession) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	s.mu.Lock()
+	s.requests = append(s.requests, request{name, payload})
+	s.mu.Unlock()
+	if name == "window-change" {
+		s.resized <- struct{}{}
+	}
+	return !s.refuse, nil
+}
+
+func (s *fakeSession) StdinPipe() (io.WriteCloser, error) { return s.stdinW, nil }
+func (s *fakeSession) StdoutPipe() (io.Reader, error)     { return s.stdoutR, nil }
+
+func (s *fakeSession) Shell() error {
+	go func() {
+		defer close(s.done)
+		defer s.stdoutW.Close()
+		var line []byte
+		b := make([]byte, 1)
+		for {
+			if _, err := s.stdinR.Read(b); err != nil {
+				return
+			}
+			s.stdoutW.Write(b)
+			line = append(line, b[0])
+			if bytes.HasSuffix(line, []byte("exit")) {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *fakeSession) Wait() error {
+	<-s.done
+	return nil
+}
+
+func TestRunShell(t *testing.T) {
+	s := newSession()
+	in, typed := io.Pipe()
+	var out bytes.Buffer
+	sizes := make(chan [2]int)
+	errc := make(chan error)
+	go func() { errc <- runShell(s, in, &out, "xterm", 100, 30, sizes) }()
+
+	typed.Write([]byte("ls\r"))
+	sizes <- [2]int{120, 40}
+	<-s.resized
+	typed.Write([]byte("exit"))
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "ls\rexit" {
+		t.Errorf("got output %q, expected the echoed input", got)
+	}
+
+	if len(s.requests) != 2 {
+		t.Fatalf("got requests %q", s.requests)
+	}
+	pty := s.requests[0]
+	if pty.name != "pty-req" || !strings.HasPrefix(string(pty.payload), "\x00\x00\x00\x05xterm\x00\x00\x00\x64\x00\x00\x00\x1e") {
+		t.Errorf("got %q, expected a pty-req for a 100x30 xterm", pty)
+	}
+	if !bytes.HasSuffix(pty.payload, []byte{modeEnd}) {
+		t.Errorf("pty-req modes aren't terminated: %q", pty.payload)
+	}
+	resize := s.requests[1]
+	if resize.name != "window-change" || !bytes.Equal(resize.payload, windowChange(120, 40)) {
+		t.Errorf("got %q, expected a window change to 120x40", resize)
+	}
+
+	// The server side of the package understands the requests.
+	srv := New(newChannel(), "")
+	if !srv.HandleRequest(pty.name, pty.payload) || srv.Term() != "xterm" {
+		t.Errorf("the pty-req wasn't understood")
+	}
+}
+
+func TestPtyRefused(t *testing.T) {
+	s := newSession()
+	s.refuse = true
+	if err := runShell(s, strings.NewReader(""), io.Discard, "", 80, 24, nil); err != ErrPtyRefused {
+		t.Errorf("got %v, expected ErrPtyRefused", err)
+	}
+	if !strings.Contains(string(s.requests[0].payload), "vt100") {
+		t.Errorf("got %q, expected vt100 without TERM", s.requests[0].payload)
+	}
+}
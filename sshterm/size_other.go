@@ -0,0 +1,13 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package sshterm
+
+// watchSize returns a channel delivering the size of the terminal fd when it
+// changes. Changes aren't noticed on this system.
+func watchSize(fd int) (sizes <-chan [2]int, stop func()) {
+	return nil, func() {}
+}
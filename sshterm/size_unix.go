@@ -0,0 +1,46 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package sshterm
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// watchSize returns a channel delivering the size of the terminal fd when
+// it changes, until stop is called.
+func watchSize(fd int) (sizes <-chan [2]int, stop func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+	ch := make(chan [2]int)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sig:
+				width, height, err := terminal.GetSize(fd)
+				if err != nil {
+					continue
+				}
+				select {
+				case ch <- [2]int{width, height}:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch, func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
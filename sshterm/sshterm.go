@@ -0,0 +1,186 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sshterm binds a Terminal to the session channel of an SSH server,
+// taking care of the requests that concern the terminal. It doesn't import an
+// SSH implementation: a golang.org/x/crypto/ssh Channel satisfies Channel, and
+// its requests are passed to HandleRequest:
+//
+//	t := sshterm.New(channel, "> ")
+//	go func() {
+//		for req := range requests {
+//			req.Reply(t.HandleRequest(req.Type, req.Payload), nil)
+//		}
+//	}()
+//	for {
+//		line, err := t.ReadLine()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+//	t.Exit(0)
+//
+// On the client's side, RunShell connects the local terminal to a shell in an
+// SSH session.
+package sshterm
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Channel is the session channel of an SSH connection.
+type Channel interface {
+	io.ReadWriteCloser
+	// SendRequest sends a channel request to the client.
+	SendRequest(name string, wantReply bool, payload []byte) (bool, error)
+}
+
+// Terminal is a terminal.Terminal on an SSH channel.
+type Terminal struct {
+	*terminal.Terminal
+	ch Channel
+
+	lock sync.Mutex
+	// term is the value of the client's TERM variable, from its pty-req.
+	term string
+	// pty is closed when the client has requested a pseudo-terminal.
+	pty    chan struct{}
+	hasPty bool
+}
+
+// conn reads from an SSH channel for the terminal.
+type conn struct {
+	Channel
+}
+
+// Read returns io.EOF once the channel can't be read any more: the client
+// sent EOF, or the channel or connection was closed. The terminal passes it on
+// from ReadLine, as it does when the user types Ctrl-D.
+func (c conn) Read(b []byte) (int, error) {
+	n, err := c.Channel.Read(b)
+	if err != nil {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// New returns a Terminal using prompt that reads from and writes to ch. Its
+// size is set when the client requests a pseudo-terminal, so requests must be
+// handled by HandleRequest before the terminal is used.
+func New(ch Channel, prompt string) *Terminal {
+	t := &Terminal{
+		Terminal: terminal.NewTerminal(conn{ch}, prompt, true),
+		ch:       ch,
+		pty:      make(chan struct{}),
+	}
+	// There's no tty driver on the server to turn "\n" into "\r\n".
+	t.SetTranslateNewlines(true)
+	return t
+}
+
+// HandleRequest handles a request sent by the client on the channel and
+// reports whether it was accepted, which is to be passed on in the reply.
+//
+// A "pty-req" sets the terminal's size and type, and a "window-change" its
+// size, repainting the line being edited. A "shell" is accepted, as the
+// program using the terminal is the shell. Anything else is declined.
+func (t *Terminal) HandleRequest(typ string, payload []byte) bool {
+	switch typ {
+	case "pty-req":
+		// string TERM, uint32 columns, uint32 rows, uint32 width
+		// and height in pixels, string modes.
+		term, rest, ok := parseString(payload)
+		if !ok {
+			return false
+		}
+		width, height, ok := parseSize(rest)
+		if !ok {
+			return false
+		}
+		t.lock.Lock()
+		t.term = term
+		if !t.hasPty {
+			t.hasPty = true
+			close(t.pty)
+		}
+		t.lock.Unlock()
+		if width > 0 && height > 0 {
+			t.SetSize(width, height)
+		}
+		return true
+	case "window-change":
+		// uint32 columns, uint32 rows, uint32 width and height in
+		// pixels.
+		width, height, ok := parseSize(payload)
+		if !ok {
+			return false
+		}
+		if width > 0 && height > 0 {
+			t.SetSize(width, height)
+		}
+		return true
+	case "shell":
+		return true
+	}
+	return false
+}
+
+// parseString parses an SSH string: its length as a uint32, then its bytes.
+func parseString(b []byte) (s string, rest []byte, ok bool) {
+	if len(b) < 4 {
+		return "", nil, false
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(n) {
+		return "", nil, false
+	}
+	return string(b[4 : 4+n]), b[4+n:], true
+}
+
+// parseSize parses the columns and rows at the start of b.
+func parseSize(b []byte) (width, height int, ok bool) {
+	if len(b) < 8 {
+		return 0, 0, false
+	}
+	width = int(binary.BigEndian.Uint32(b))
+	height = int(binary.BigEndian.Uint32(b[4:]))
+	// Guard against absurd sizes, which would make the terminal allocate
+	// huge buffers.
+	const maxSize = 1 << 16
+	if width > maxSize || height > maxSize {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// Term returns the terminal type from the client's pty-req, such as "xterm",
+// or "" if it hasn't requested a pseudo-terminal.
+func (t *Terminal) Term() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.term
+}
+
+// Pty returns a channel that's closed when the client has requested a
+// pseudo-terminal. Clients that run a command without one, like "ssh host
+// command", never do.
+func (t *Terminal) Pty() <-chan struct{} {
+	return t.pty
+}
+
+// Exit sends the program's exit status to the client and closes the channel.
+func (t *Terminal) Exit(status int) error {
+	payload := binary.BigEndian.AppendUint32(nil, uint32(status))
+	if _, err := t.ch.SendRequest("exit-status", false, payload); err != nil {
+		t.ch.Close()
+		return err
+	}
+	return t.ch.Close()
+}
@@ -0,0 +1,173 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sshterm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminaltest"
+)
+
+type request struct {
+	name    string
+	payload []byte
+}
+
+type fakeChannel struct {
+	*io.PipeReader
+	in *io.PipeWriter
+
+	mu       sync.Mutex
+	screen   *terminaltest.Screen
+	requests []request
+	closed   bool
+}
+
+func newChannel() *fakeChannel {
+	r, w := io.Pipe()
+	return &fakeChannel{PipeReader: r, in: w, screen: terminaltest.NewScreen(80, 24)}
+}
+
+func (c *fakeChannel) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.screen.Write(b)
+}
+
+func (c *fakeChannel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, request{name, payload})
+	return false, nil
+}
+
+func (c *fakeChannel) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+// waitFor waits until row y of the screen shows want.
+func (c *fakeChannel) waitFor(t *testing.T, y int, want string) {
+	t.Helper()
+	var got string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		c.mu.Lock()
+		got = c.screen.Line(y)
+		c.mu.Unlock()
+		if got == want {
+			return
+		}
+	}
+	t.Fatalf("got row %d %q, expected %q", y, got, want)
+}
+
+func ptyReq(term string, width, height uint32) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(term)))
+	b = append(b, term...)
+	for _, n := range []uint32{width, height, 0, 0, 0} {
+		b = binary.BigEndian.AppendUint32(b, n)
+	}
+	return b
+}
+
+func TestHandleRequest(t *testing.T) {
+	ch := newChannel()
+	ch.screen = terminaltest.NewScreen(10, 5)
+	term := New(ch, "> ")
+
+	select {
+	case <-term.Pty():
+		t.Fatal("Pty is closed before a pty-req")
+	default:
+	}
+	if !term.HandleRequest("pty-req", ptyReq("xterm-256color", 10, 5)) {
+		t.Fatal("pty-req was declined")
+	}
+	if got := term.Term(); got != "xterm-256color" {
+		t.Errorf("got TERM %q, expected xterm-256color", got)
+	}
+	select {
+	case <-term.Pty():
+	default:
+		t.Error("Pty isn't closed after a pty-req")
+	}
+	if !term.HandleRequest("shell", nil) {
+		t.Error("shell was declined")
+	}
+	if term.HandleRequest("exec", []byte("\x00\x00\x00\x02ls")) {
+		t.Error("exec was accepted")
+	}
+	if term.HandleRequest("pty-req", []byte{0, 0, 0, 9, 'x'}) {
+		t.Error("a truncated pty-req was accepted")
+	}
+	if term.HandleRequest("window-change", []byte{0, 0, 1}) {
+		t.Error("a truncated window-change was accepted")
+	}
+
+	// The line wraps at the width sent by the client.
+	done := make(chan string)
+	go func() {
+		line, _ := term.ReadLine()
+		done <- line
+	}()
+	ch.in.Write([]byte("abcdefghij"))
+	ch.waitFor(t, 1, "ij")
+
+	// After a window change it's repainted on one row.
+	ch.mu.Lock()
+	ch.screen = terminaltest.NewScreen(40, 5)
+	ch.mu.Unlock()
+	size := []byte{0, 0, 0, 40, 0, 0, 0, 5, 0, 0, 0, 0, 0, 0, 0, 0}
+	if !term.HandleRequest("window-change", size) {
+		t.Fatal("window-change was declined")
+	}
+	ch.in.Write([]byte("\r"))
+	if line := <-done; line != "abcdefghij" {
+		t.Errorf("got %q, expected abcdefghij", line)
+	}
+	ch.waitFor(t, 0, "> abcdefghij")
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("ssh: disconnected") }
+
+func TestEOF(t *testing.T) {
+	ch := newChannel()
+	term := New(struct {
+		failingReader
+		*fakeChannel
+	}{fakeChannel: ch}, "> ")
+	term.HandleRequest("pty-req", ptyReq("xterm", 80, 24))
+	if _, err := term.ReadLine(); err != io.EOF {
+		t.Errorf("ReadLine returned %v, expected io.EOF", err)
+	}
+}
+
+func TestExit(t *testing.T) {
+	ch := newChannel()
+	term := New(ch, "> ")
+	term.Write([]byte("bye\n"))
+	if err := term.Exit(3); err != nil {
+		t.Fatal(err)
+	}
+	if len(ch.requests) != 1 || ch.requests[0].name != "exit-status" || !bytes.Equal(ch.requests[0].payload, []byte{0, 0, 0, 3}) {
+		t.Errorf("got requests %q, expected exit-status 3", ch.requests)
+	}
+	if !ch.closed {
+		t.Error("the channel isn't closed")
+	}
+	if x, y := ch.screen.Cursor(); x != 0 || y != 1 {
+		t.Errorf("cursor at %d,%d after a newline, expected 0,1", x, y)
+	}
+}
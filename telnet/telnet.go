@@ -0,0 +1,338 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package telnet serves a Terminal to telnet clients, including the ones of
+// MUD players. Conn wraps the network connection, negotiating character at a
+// time input with the server doing the echoing, and picking up the window size
+// the client reports.
+package telnet
+
+import (
+	"io"
+	"sync"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Telnet commands, from RFC 854.
+const (
+	cmdSE   = 240
+	cmdIP   = 244
+	cmdSB   = 250
+	cmdWILL = 251
+	cmdWONT = 252
+	cmdDO   = 253
+	cmdDONT = 254
+	cmdIAC  = 255
+)
+
+// Telnet options.
+const (
+	optEcho     = 1  // RFC 857
+	optSGA      = 3  // Suppress Go Ahead, RFC 858
+	optNAWS     = 31 // Negotiate About Window Size, RFC 1073
+	optLinemode = 34 // RFC 1184
+)
+
+// linemodeMode is the LINEMODE suboption setting the mode; a mode of 0 turns
+// off the client's line editing and signal trapping.
+const linemodeMode = 1
+
+// The state of an option on one side of the connection, as in RFC 1143.
+const (
+	optNo = iota
+	optYes
+	// optWantYes means the option was requested and the other side hasn't
+	// answered yet.
+	optWantYes
+)
+
+// The states of the input parser.
+const (
+	stateData = iota
+	// stateCR follows a carriage return, which may be followed by a line
+	// feed or NUL that's dropped.
+	stateCR
+	stateIAC
+	// stateOption expects the option following WILL, WONT, DO or DONT.
+	stateOption
+	stateSB
+	stateSBIAC
+)
+
+// maxSubnegotiation limits the length of subnegotiations that are kept.
+const maxSubnegotiation = 64
+
+// Conn is a telnet connection. What's read from it is the user's input,
+// stripped of telnet commands, with the line ending sent for the Enter key
+// turned into a carriage return. What's written to it is escaped as telnet
+// requires.
+type Conn struct {
+	rw io.ReadWriter
+
+	// wlock serializes writes, which come from both the terminal and Read
+	// answering the client.
+	wlock sync.Mutex
+
+	// The following are used by Read.
+	buf   []byte
+	state int
+	verb  byte
+	sb    []byte
+	// us and him are the states of the options on the server's side and
+	// the client's side.
+	us, him [256]uint8
+
+	lock          sync.Mutex
+	width, height int
+	resized       func(width, height int)
+}
+
+// NewConn starts negotiating with the client on rw: the server will echo and
+// suppress go-aheads, and the client is asked to report its window size and
+// to leave line editing to the server.
+func NewConn(rw io.ReadWriter) (*Conn, error) {
+	c := &Conn{rw: rw, buf: make([]byte, 1024)}
+	c.us[optEcho] = optWantYes
+	c.us[optSGA] = optWantYes
+	c.him[optSGA] = optWantYes
+	c.him[optNAWS] = optWantYes
+	c.him[optLinemode] = optWantYes
+	err := c.send(
+		cmdIAC, cmdWILL, optEcho,
+		cmdIAC, cmdWILL, optSGA,
+		cmdIAC, cmdDO, optSGA,
+		cmdIAC, cmdDO, optNAWS,
+		cmdIAC, cmdDO, optLinemode,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewTerminal returns a Terminal using prompt on a new telnet connection on
+// rw. The terminal's size follows the window size reported by the client.
+func NewTerminal(rw io.ReadWriter, prompt string) (*terminal.Terminal, *Conn, error) {
+	c, err := NewConn(rw)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := terminal.NewTerminal(c, prompt, true)
+	// Telnet ends lines with "\r\n".
+	t.SetTranslateNewlines(true)
+	c.OnResize(func(width, height int) {
+		t.SetSize(width, height)
+		t.Refresh()
+	})
+	return t, c, nil
+}
+
+// OnResize sets a function to be called from Read when the client reports its
+// window size. If it was reported before, f is called right away.
+func (c *Conn) OnResize(f func(width, height int)) {
+	c.lock.Lock()
+	c.resized = f
+	width, height := c.width, c.height
+	c.lock.Unlock()
+
+	if f != nil && width > 0 && height > 0 {
+		f(width, height)
+	}
+}
+
+// Size returns the window size reported by the client, or zeros if it hasn't
+// reported one.
+func (c *Conn) Size() (width, height int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.width, c.height
+}
+
+// send writes b to the client as it is.
+func (c *Conn) send(b ...byte) error {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	_, err := c.rw.Write(b)
+	return err
+}
+
+// Write writes b to the client, doubling IAC bytes and following carriage
+// returns that don't end a line with NUL.
+func (c *Conn) Write(b []byte) (n int, err error) {
+	out := make([]byte, 0, len(b)+8)
+	for i, ch := range b {
+		out = append(out, ch)
+		switch {
+		case ch == cmdIAC:
+			out = append(out, cmdIAC)
+		case ch == '\r' && (i+1 == len(b) || b[i+1] != '\n'):
+			out = append(out, 0)
+		}
+	}
+
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	if _, err := c.rw.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Read reads the user's input. Telnet commands are handled and removed; an
+// Interrupt Process command is turned into Ctrl-C.
+func (c *Conn) Read(b []byte) (n int, err error) {
+	for n == 0 && len(b) > 0 {
+		m := len(b)
+		if m > len(c.buf) {
+			m = len(c.buf)
+		}
+		m, err = c.rw.Read(c.buf[:m])
+		for _, ch := range c.buf[:m] {
+			if ch, ok := c.parse(ch); ok {
+				b[n] = ch
+				n++
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return n, err
+}
+
+// parse advances the input parser by one byte and returns the byte of input
+// it yields, if any.
+func (c *Conn) parse(ch byte) (byte, bool) {
+	switch c.state {
+	case stateCR:
+		c.state = stateData
+		if ch == '\n' || ch == 0 {
+			return 0, false
+		}
+		return c.parse(ch)
+	case stateData:
+		switch ch {
+		case cmdIAC:
+			c.state = stateIAC
+			return 0, false
+		case '\r':
+			c.state = stateCR
+		}
+		return ch, true
+	case stateIAC:
+		c.state = stateData
+		switch ch {
+		case cmdIAC:
+			return cmdIAC, true
+		case cmdWILL, cmdWONT, cmdDO, cmdDONT:
+			c.verb = ch
+			c.state = stateOption
+		case cmdSB:
+			c.sb = c.sb[:0]
+			c.state = stateSB
+		case cmdIP:
+			return terminal.KeyCtrlC, true
+		}
+	case stateOption:
+		c.state = stateData
+		c.negotiate(c.verb, ch)
+	case stateSB:
+		if ch == cmdIAC {
+			c.state = stateSBIAC
+		} else if len(c.sb) < maxSubnegotiation {
+			c.sb = append(c.sb, ch)
+		}
+	case stateSBIAC:
+		switch ch {
+		case cmdSE:
+			c.state = stateData
+			c.subnegotiation(c.sb)
+		case cmdIAC:
+			c.state = stateSB
+			if len(c.sb) < maxSubnegotiation {
+				c.sb = append(c.sb, cmdIAC)
+			}
+		default:
+			// A command in the middle of the subnegotiation,
+			// which can't be valid.
+			c.state = stateData
+		}
+	}
+	return 0, false
+}
+
+// supported reports whether the option may be enabled on the given side.
+func supported(client bool, opt byte) bool {
+	if client {
+		return opt == optSGA || opt == optNAWS || opt == optLinemode
+	}
+	return opt == optEcho || opt == optSGA
+}
+
+// negotiate answers an option command from the client, following RFC 1143 so
+// that requests aren't answered in an endless loop.
+func (c *Conn) negotiate(verb, opt byte) {
+	var state *uint8
+	var accept, refuse byte
+	switch verb {
+	case cmdWILL, cmdWONT:
+		state, accept, refuse = &c.him[opt], cmdDO, cmdDONT
+	default:
+		state, accept, refuse = &c.us[opt], cmdWILL, cmdWONT
+	}
+
+	switch verb {
+	case cmdWILL, cmdDO:
+		switch *state {
+		case optWantYes:
+			*state = optYes
+		case optNo:
+			if !supported(verb == cmdWILL, opt) {
+				c.send(cmdIAC, refuse, opt)
+				return
+			}
+			*state = optYes
+			c.send(cmdIAC, accept, opt)
+		default:
+			return
+		}
+		if verb == cmdWILL && opt == optLinemode {
+			c.send(cmdIAC, cmdSB, optLinemode, linemodeMode, 0, cmdIAC, cmdSE)
+		}
+	case cmdWONT, cmdDONT:
+		switch *state {
+		case optYes:
+			*state = optNo
+			c.send(cmdIAC, refuse, opt)
+		case optWantYes:
+			*state = optNo
+		}
+	}
+}
+
+// subnegotiation handles the parameters of an option sent by the client.
+func (c *Conn) subnegotiation(b []byte) {
+	if len(b) != 5 || b[0] != optNAWS {
+		return
+	}
+	width := int(b[1])<<8 | int(b[2])
+	height := int(b[3])<<8 | int(b[4])
+	if width == 0 || height == 0 {
+		// The client doesn't know.
+		return
+	}
+
+	c.lock.Lock()
+	c.width, c.height = width, height
+	f := c.resized
+	c.lock.Unlock()
+
+	if f != nil {
+		f(width, height)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeConn reads from in and writes to out.
+type fakeConn struct {
+	in  io.Reader
+	out bytes.Buffer
+}
+
+func (c *fakeConn) Read(b []byte) (int, error)  { return c.in.Read(b) }
+func (c *fakeConn) Write(b []byte) (int, error) { return c.out.Write(b) }
+
+const negotiation = "\xff\xfb\x01\xff\xfb\x03\xff\xfd\x03\xff\xfd\x1f\xff\xfd\x22"
+
+func TestNegotiation(t *testing.T) {
+	fc := &fakeConn{in: strings.NewReader(
+		// The client agrees to everything but LINEMODE, and
+		// asks for an option that isn't supported.
+		"\xff\xfd\x01\xff\xfd\x03\xff\xfb\x03\xff\xfb\x1f\xff\xfc\x22" +
+			"\xff\xfb\x18" +
+			"hi")}
+	c, err := NewConn(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fc.out.String(); got != negotiation {
+		t.Fatalf("sent %q, expected %q", got, negotiation)
+	}
+	fc.out.Reset()
+
+	b, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hi" {
+		t.Errorf("read %q, expected hi", b)
+	}
+	// Only the unsupported option is answered.
+	if got, want := fc.out.String(), "\xff\xfe\x18"; got != want {
+		t.Errorf("sent %q, expected %q", got, want)
+	}
+}
+
+func TestLinemode(t *testing.T) {
+	fc := &fakeConn{in: strings.NewReader("\xff\xfb\x22x")}
+	c, _ := NewConn(fc)
+	fc.out.Reset()
+	b := make([]byte, 10)
+	n, _ := c.Read(b)
+	if string(b[:n]) != "x" {
+		t.Errorf("read %q, expected x", b[:n])
+	}
+	if got, want := fc.out.String(), "\xff\xfa\x22\x01\x00\xff\xf0"; got != want {
+		t.Errorf("sent %q, expected %q", got, want)
+	}
+}
+
+func TestReadData(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"a\r\nb\r\x00c\rd", "a\rb\rc\rd"},
+		{"\xff\xff", "\xff"},
+		{"a\xff\xf4b", "a\x03b"},
+		// Subnegotiations and commands that aren't handled are
+		// dropped.
+		{"a\xff\xfa\x18\x00xterm\xff\xff\xff\xf0b\xff\xf1c", "abc"},
+	} {
+		c, _ := NewConn(&fakeConn{in: strings.NewReader(test.in)})
+		b, err := io.ReadAll(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.want {
+			t.Errorf("read %q from %q, expected %q", b, test.in, test.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	fc := &fakeConn{in: strings.NewReader("")}
+	c, _ := NewConn(fc)
+	fc.out.Reset()
+	in := "a\r\nb\rc\xff\r"
+	n, err := c.Write([]byte(in))
+	if n != len(in) || err != nil {
+		t.Errorf("Write returned %d, %v", n, err)
+	}
+	if got, want := fc.out.String(), "a\r\nb\r\x00c\xff\xff\r\x00"; got != want {
+		t.Errorf("sent %q, expected %q", got, want)
+	}
+}
+
+func TestWindowSize(t *testing.T) {
+	// The window size arrives with the first line, with the height
+	// containing an IAC byte.
+	fc := &fakeConn{in: strings.NewReader("\xff\xfa\x1f\x00\x0a\x00\xff\xff\xff\xf0abcdefghij\r\n")}
+	term, c, err := NewTerminal(fc, "> ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := term.ReadLine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "abcdefghij" {
+		t.Errorf("got %q, expected abcdefghij", line)
+	}
+	if w, h := c.Size(); w != 10 || h != 255 {
+		t.Errorf("got size %dx%d, expected 10x255", w, h)
+	}
+	// The prompt was repainted after the resize.
+	if out := fc.out.String(); !strings.Contains(out, "\r\x00\x1b[K> ") {
+		t.Errorf("got %q, expected the prompt to be repainted", out)
+	}
+
+	called := false
+	c.OnResize(func(width, height int) { called = width == 10 && height == 255 })
+	if !called {
+		t.Error("OnResize didn't report the known size")
+	}
+}
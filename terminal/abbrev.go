@@ -0,0 +1,43 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+// SetAbbreviation makes abbr expand to expansion, as abbreviations do in
+// fish: when abbr is typed as the name of a command, the first word of the
+// line or one after an operator such as "|", it's replaced by expansion once
+// space or Enter is pressed. Ctrl-Space inserts a space without expanding it.
+// An empty expansion removes the abbreviation.
+func (t *Terminal) SetAbbreviation(abbr, expansion string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if expansion == "" {
+		delete(t.abbreviations, abbr)
+		return
+	}
+	if t.abbreviations == nil {
+		t.abbreviations = make(map[string]string)
+	}
+	t.abbreviations[abbr] = expansion
+}
+
+// expandAbbreviation expands the abbreviation right before the cursor, if
+// there's one. t.lock must be held.
+func (t *Terminal) expandAbbreviation() {
+	if t.secret || t.mask != nil {
+		return
+	}
+	line := string(t.line)
+	ctx := Tokenize(line, t.pos)
+	if ctx.Arg != 0 || ctx.Quote != NoQuote || ctx.Index == len(ctx.Tokens) || ctx.Tokens[ctx.Index].End != t.pos {
+		return
+	}
+	expansion, ok := t.abbreviations[line[ctx.Start:t.pos]]
+	if !ok {
+		return
+	}
+	newLine := line[:ctx.Start] + expansion + line[t.pos:]
+	t.setLine([]byte(newLine), ctx.Start+len(expansion))
+}
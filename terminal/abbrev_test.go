@@ -0,0 +1,34 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+func TestAbbreviation(t *testing.T) {
+	c := &MockTerminal{
+		toSend: []byte("gco main\r" +
+			"echo gco | gco\r" +
+			"gco\x00x\r" +
+			"'gco' gst\r" +
+			"gcox\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetAbbreviation("gco", "git checkout")
+	ss.SetAbbreviation("gst", "git status")
+	ss.SetAbbreviation("gst", "")
+
+	for _, want := range []string{
+		"git checkout main",
+		"echo gco | git checkout",
+		"gco x",
+		"'gco' gst",
+		"gcox",
+	} {
+		if line, err := ss.ReadLine(); err != nil || line != want {
+			t.Errorf("got %q, %v, expected %q", line, err, want)
+		}
+	}
+}
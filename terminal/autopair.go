@@ -0,0 +1,90 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+// autoPairs maps the characters that open a pair to those closing it.
+var autoPairs = map[byte]byte{
+	'(':  ')',
+	'[':  ']',
+	'{':  '}',
+	'"':  '"',
+	'\'': '\'',
+}
+
+// SetAutoPair turns the pairing of brackets and quotes on or off. It's off by
+// default. When it's on, typing (, [, {, " or ' inserts the closing character
+// too, with the cursor between them, unless the cursor is in front of a word.
+// Quotes aren't paired right after a letter or digit, as in "it's". Typing
+// the closing character in front of the same one moves over it, and
+// Backspace between an empty pair deletes both.
+func (t *Terminal) SetAutoPair(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.autoPair = on
+}
+
+// pairKey handles key if it opens or closes a pair, and reports whether it
+// did. t.lock must be held.
+func (t *Terminal) pairKey(key int) bool {
+	if t.secret || t.mask != nil || key < 0 || key > 127 {
+		return false
+	}
+	var next, prev byte
+	if t.pos < len(t.line) {
+		next = t.line[t.pos]
+	}
+	if t.pos > 0 {
+		prev = t.line[t.pos-1]
+	}
+
+	if key == KeyBackspace {
+		if closer, ok := autoPairs[prev]; !ok || next != closer {
+			return false
+		}
+		newLine := append(append([]byte(nil), t.line[:t.pos-1]...), t.line[t.pos+1:]...)
+		t.setLine(newLine, t.pos-1)
+		return true
+	}
+
+	c := byte(key)
+	if next == c && isCloser(c) {
+		t.pos++
+		t.moveCursorToPos(t.pos)
+		return true
+	}
+	closer, ok := autoPairs[c]
+	if !ok {
+		return false
+	}
+	if next != 0 && next != ' ' && !isCloser(next) {
+		return false
+	}
+	if closer == c && isWordByte(prev) {
+		return false
+	}
+	if len(t.line)+2 > maxLineLength {
+		return false
+	}
+	t.insert([]byte{c, closer})
+	t.pos--
+	t.moveCursorToPos(t.pos)
+	return true
+}
+
+// isCloser reports whether c closes a pair.
+func isCloser(c byte) bool {
+	for _, closer := range autoPairs {
+		if c == closer {
+			return true
+		}
+	}
+	return false
+}
+
+// isWordByte reports whether c is an ASCII letter or digit.
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
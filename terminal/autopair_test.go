@@ -0,0 +1,33 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+var autoPairTests = []struct {
+	in, want string
+}{
+	{"f(x\r", "f(x)"},
+	{"f(x)\r", "f(x)"},
+	{"f(\x7f\r", "f"},
+	{`say "hi" x` + "\r", `say "hi" x`},
+	{"it's\r", "it's"},
+	{"[{a}]b\r", "[{a}]b"},
+	{"ab\x1b[D\x1b[D(\r", "(ab"},
+}
+
+func TestAutoPair(t *testing.T) {
+	for _, test := range autoPairTests {
+		c := &MockTerminal{
+			toSend:       []byte(test.in),
+			bytesPerRead: 1,
+		}
+		ss := NewTerminal(c, "> ", true)
+		ss.SetAutoPair(true)
+		if line, err := ss.ReadLine(); err != nil || line != test.want {
+			t.Errorf("typing %q gave %q, %v, expected %q", test.in, line, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// SetBidi turns the reordering of right-to-left text for display on or off.
+// It's off by default, for terminals that apply the bidirectional algorithm
+// themselves. When it's on, the line is still edited in logical order, the
+// order it's typed and returned in, but runs of Hebrew, Arabic and other
+// right-to-left scripts are shown reversed, as they're read, with the cursor
+// on the character that follows it in logical order.
+//
+// This is a basic version of the Unicode bidirectional algorithm: the line is
+// taken to be left-to-right, a run of right-to-left text extends over the
+// spaces, punctuation and numbers between its letters, numbers in it are kept
+// left-to-right, and explicit direction marks aren't interpreted. Each
+// logical line is reordered before it's wrapped.
+func (t *Terminal) SetBidi(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.bidi = on
+}
+
+// rtlRanges lists the blocks of right-to-left scripts.
+var rtlRanges = [][2]rune{
+	{0x0590, 0x08ff}, // Hebrew, Arabic, Syriac, Thaana, NKo, ...
+	{0xfb1d, 0xfdff}, // Hebrew and Arabic presentation forms
+	{0xfe70, 0xfeff},
+	{0x10800, 0x10fff},
+	{0x1e800, 0x1efff},
+}
+
+// isRTL reports whether r is a strong right-to-left character.
+func isRTL(r rune) bool {
+	for _, rng := range rtlRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return !isDigit(r) && !unicode.In(r, unicode.Mn, unicode.Me)
+		}
+	}
+	return false
+}
+
+// isLTR reports whether r is a strong left-to-right character.
+func isLTR(r rune) bool {
+	return unicode.IsLetter(r) && !isRTL(r)
+}
+
+// isDigit reports whether r is a European or Arabic-Indic digit, which are
+// shown left-to-right within right-to-left text.
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 0x660 && r <= 0x669 || r >= 0x6f0 && r <= 0x6f9
+}
+
+// hasRTL reports whether b holds right-to-left text.
+func hasRTL(b []byte) bool {
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r >= 0x590 && isRTL(r) {
+			return true
+		}
+		b = b[size:]
+	}
+	return false
+}
+
+// visualOrder returns the indices of runes in the order they're shown.
+func visualOrder(runes []rune) []int {
+	order := make([]int, len(runes))
+	for i := range order {
+		order[i] = i
+	}
+	for i := 0; i < len(runes); i++ {
+		if !isRTL(runes[i]) {
+			continue
+		}
+		// The run ends at its last right-to-left letter, and the
+		// combining marks on it.
+		last := i
+		for j := i; j < len(runes) && !isLTR(runes[j]); j++ {
+			if isRTL(runes[j]) {
+				last = j
+			}
+		}
+		end := last + 1
+		for end < len(runes) && unicode.In(runes[end], unicode.Mn, unicode.Me) {
+			end++
+		}
+		reverseRun(runes, order, i, end)
+		i = end - 1
+	}
+	return order
+}
+
+// reverseRun reverses the order of runes[start:end], keeping numbers and
+// characters with their combining marks in their order.
+func reverseRun(runes []rune, order []int, start, end int) {
+	var units [][2]int
+	for i := start; i < end; {
+		j := i + 1
+		if isDigit(runes[i]) {
+			for j < end && (isDigit(runes[j]) || j+1 < end && (runes[j] == '.' || runes[j] == ',') && isDigit(runes[j+1])) {
+				j++
+			}
+		}
+		for j < end && unicode.In(runes[j], unicode.Mn, unicode.Me) {
+			j++
+		}
+		units = append(units, [2]int{i, j})
+		i = j
+	}
+	k := start
+	for u := len(units) - 1; u >= 0; u-- {
+		for i := units[u][0]; i < units[u][1]; i++ {
+			order[k] = i
+			k++
+		}
+	}
+}
+
+// reorder returns b, a row of the line, in the order it's shown, and the
+// number of bytes of it shown before the character at byte offset pos.
+func reorder(b []byte, pos int) (visual []byte, before int) {
+	runes := []rune(string(b))
+	offsets := make([]int, len(runes)+1)
+	for i, n := 0, 0; i < len(runes); i++ {
+		offsets[i] = n
+		_, size := utf8.DecodeRune(b[n:])
+		n += size
+	}
+	offsets[len(runes)] = len(b)
+
+	visual = make([]byte, 0, len(b))
+	before = -1
+	for _, i := range visualOrder(runes) {
+		if offsets[i] == pos {
+			before = len(visual)
+		}
+		visual = append(visual, b[offsets[i]:offsets[i+1]]...)
+	}
+	if before < 0 {
+		before = len(visual)
+	}
+	return visual, before
+}
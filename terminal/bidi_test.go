@@ -0,0 +1,44 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+var reorderTests = []struct {
+	in, want string
+}{
+	{"hello", "hello"},
+	{"שלום", "םולש"},
+	{"say שלום עולם now", "say םלוע םולש now"},
+	{"ב 123 א", "א 123 ב"},
+	{"אָב", "באָ"},
+	{"(שלום)", "(םולש)"},
+}
+
+func TestReorder(t *testing.T) {
+	for _, test := range reorderTests {
+		if got, _ := reorder([]byte(test.in), 0); string(got) != test.want {
+			t.Errorf("reorder(%q) = %q, expected %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestBidiCursor(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(40, 5)
+	ss.SetBidi(true)
+	line := "ab אבג cd"
+	// The cursor is on the character following it: after "ab " that's
+	// א, which is shown rightmost in its run.
+	for _, test := range []struct{ pos, x int }{
+		{0, 2}, {3, 7}, {len("ab א"), 6}, {len("ab אבג"), 8}, {len(line), 11},
+	} {
+		ss.SetLine(line, test.pos)
+		if ss.cursorX != test.x {
+			t.Errorf("cursor at %d at position %d, expected %d", ss.cursorX, test.pos, test.x)
+		}
+	}
+}
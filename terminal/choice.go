@@ -0,0 +1,222 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNoOptions is returned by Select when there is nothing to choose from.
+var ErrNoOptions = errors.New("terminal: no options to select from")
+
+// maxSelectRows is the greatest number of options Select shows at once.
+const maxSelectRows = 10
+
+// selectState holds the state of a Select call.
+type selectState struct {
+	prompt  string
+	options []string
+	filter  []byte
+	// matches holds the indexes of the options matching filter, sel the
+	// index into matches of the selected one and top that of the first
+	// one shown.
+	matches  []int
+	sel, top int
+}
+
+// Select shows prompt followed by a list of options below it and lets the user
+// pick one with the arrow keys and Enter. Typing filters the list to the
+// options containing the text typed, ignoring case. It returns the index of
+// the option picked, or ErrInterrupted if the user pressed Ctrl-C.
+func (t *Terminal) Select(prompt string, options []string) (int, error) {
+	if len(options) == 0 {
+		return -1, ErrNoOptions
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err := t.startReading(); err != nil {
+		return -1, err
+	}
+	defer t.doneReading()
+
+	s := &selectState{prompt: prompt, options: options}
+	s.match()
+	for {
+		t.drawSelect(s)
+		key, b, err := t.readKey()
+		if err != nil {
+			t.endSelect(s.prompt)
+			return -1, err
+		}
+
+		switch key {
+		case KeyUp:
+			if s.sel == 0 {
+				t.invalidKey()
+				continue
+			}
+			s.sel--
+		case KeyDown:
+			if s.sel+1 >= len(s.matches) {
+				t.invalidKey()
+				continue
+			}
+			s.sel++
+		case KeyBackspace:
+			if len(s.filter) == 0 {
+				t.invalidKey()
+				continue
+			}
+			_, size := utf8.DecodeLastRune(s.filter)
+			s.filter = s.filter[:len(s.filter)-size]
+			s.match()
+		case KeyEnter:
+			if len(s.matches) == 0 {
+				t.invalidKey()
+				continue
+			}
+			choice := s.matches[s.sel]
+			t.endSelect(s.prompt + options[choice])
+			return choice, nil
+		case KeyCtrlC:
+			t.endSelect(s.prompt + "^C")
+			return -1, ErrInterrupted
+		case KeyCtrlD:
+			t.endSelect(s.prompt)
+			return -1, io.EOF
+		case KeyRune:
+			s.filter = append(s.filter, b...)
+			s.match()
+		default:
+			if !isPrintable(key) {
+				t.invalidKey()
+				continue
+			}
+			s.filter = append(s.filter, byte(key))
+			s.match()
+		}
+		s.top = max(min(s.top, s.sel), s.sel-maxSelectRows+1)
+	}
+}
+
+// match updates the options matching the filter and selects the first one.
+func (s *selectState) match() {
+	s.matches = s.matches[:0]
+	filter := strings.ToLower(string(s.filter))
+	for i, option := range s.options {
+		if strings.Contains(strings.ToLower(option), filter) {
+			s.matches = append(s.matches, i)
+		}
+	}
+	s.sel, s.top = 0, 0
+}
+
+// drawSelect paints the prompt and filter with the matching options below
+// them, and leaves the cursor after the filter.
+func (t *Terminal) drawSelect(s *selectState) {
+	t.queue(hideCursor)
+	t.clearLines()
+	t.maxLine = 0
+	t.writeText([]byte(s.prompt))
+	t.writeText(s.filter)
+	x, y := t.cursorX, t.cursorY
+
+	for i := s.top; i < len(s.matches) && i < s.top+maxSelectRows; i++ {
+		t.writeText(newline)
+		marker := "  "
+		if i == s.sel {
+			marker = "> "
+		}
+		t.writeText([]byte(truncate(marker+s.options[s.matches[i]], t.termWidth-1)))
+	}
+	if len(s.matches) == 0 {
+		t.writeText(newline)
+		t.writeText([]byte("  (no matches)"))
+	}
+
+	t.move(t.cursorY-y, 0, 0, 0)
+	t.moveToColumn(t.cursorX, x)
+	t.cursorX, t.cursorY = x, y
+	t.queue(showCursor)
+	t.flush()
+}
+
+// endSelect replaces the list shown by drawSelect with result and moves to
+// the next row.
+func (t *Terminal) endSelect(result string) {
+	t.clearLines()
+	t.queue([]byte(result))
+	t.queue([]byte("\r\n"))
+	t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+	if len(t.footer) > 0 {
+		t.drawFooter(false)
+	}
+	t.flush()
+}
+
+// Confirm asks a yes or no question. It shows prompt followed by [Y/n] or
+// [y/N], depending on the default answer def, which is picked by Enter. A
+// single key press of y or n answers the question. It returns ErrInterrupted
+// if the user pressed Ctrl-C.
+func (t *Terminal) Confirm(prompt string, def bool) (bool, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err := t.startReading(); err != nil {
+		return false, err
+	}
+	defer t.doneReading()
+
+	hint := "[y/N] "
+	if def {
+		hint = "[Y/n] "
+	}
+	prompt += hint
+	t.queue(hideCursor)
+	t.clearLines()
+	t.maxLine = 0
+	t.writeText([]byte(prompt))
+	t.queue(showCursor)
+	t.flush()
+
+	for {
+		key, _, err := t.readKey()
+		if err != nil {
+			t.endSelect(prompt)
+			return false, err
+		}
+
+		answer := def
+		switch key {
+		case 'y', 'Y':
+			answer = true
+		case 'n', 'N':
+			answer = false
+		case KeyEnter:
+		case KeyCtrlC:
+			t.endSelect(prompt + "^C")
+			return false, ErrInterrupted
+		case KeyCtrlD:
+			t.endSelect(prompt)
+			return false, io.EOF
+		default:
+			t.invalidKey()
+			t.flush()
+			continue
+		}
+
+		if answer {
+			t.endSelect(prompt + "yes")
+		} else {
+			t.endSelect(prompt + "no")
+		}
+		return answer, nil
+	}
+}
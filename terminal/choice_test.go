@@ -0,0 +1,105 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+var colors = []string{"red", "green", "blue", "black"}
+
+var selectTests = []struct {
+	in     string
+	choice int
+	err    error
+}{
+	{"\r", 0, nil},
+	{"\x1b[B\x1b[B\r", 2, nil},
+	{"\x1b[A\x1b[B\r", 1, nil},
+	{"bl\x1b[B\r", 3, nil},
+	{"BLA\r", 3, nil},
+	{"x\r\177\r", 0, nil},
+	{"\x03", -1, ErrInterrupted},
+	{"", -1, io.EOF},
+}
+
+func TestSelect(t *testing.T) {
+	for i, test := range selectTests {
+		c := &MockTerminal{toSend: []byte(test.in)}
+		ss := NewTerminal(c, "> ", true)
+		choice, err := ss.Select("Color: ", colors)
+		if choice != test.choice || err != test.err {
+			t.Errorf("test %d: got %d, %v, expected %d, %v", i, choice, err, test.choice, test.err)
+		}
+	}
+}
+
+func TestSelectRunes(t *testing.T) {
+	options := []string{"café", "日本", "cafe"}
+	for _, test := range []struct {
+		in     string
+		choice int
+	}{
+		{"é\r", 0},
+		{"日\r", 1},
+		// Backspace removes the whole character.
+		{"é\x7fe\r", 2},
+	} {
+		c := &MockTerminal{toSend: []byte(test.in), bytesPerRead: 1}
+		ss := NewTerminal(c, "> ", true)
+		if choice, err := ss.Select("? ", options); choice != test.choice || err != nil {
+			t.Errorf("%q: got %d, %v, expected %d", test.in, choice, err, test.choice)
+		}
+	}
+}
+
+func TestSelectOutput(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("g\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.Select("Color: ", colors)
+	out := string(c.received)
+	first := "\x1b[?25l\x1b[KColor: \r\n> red\r\n  green\r\n  blue\r\n  black\x1b[4A\x1b[?25h"
+	if !strings.HasPrefix(out, first) {
+		t.Errorf("got %q, expected it to start with %q", out, first)
+	}
+	if !strings.HasSuffix(out, "Color: green\r\n") {
+		t.Errorf("got %q, expected the choice at the end", out)
+	}
+	if ss.cursorX != 0 || ss.cursorY != 0 || ss.maxLine != 0 {
+		t.Errorf("cursor state not reset")
+	}
+}
+
+var confirmTests = []struct {
+	in     string
+	def    bool
+	answer bool
+	err    error
+	out    string
+}{
+	{"y", false, true, nil, "Delete? [y/N] yes\n"},
+	{"N", true, false, nil, "Delete? [Y/n] no\n"},
+	{"\r", true, true, nil, "Delete? [Y/n] yes\n"},
+	{"\r", false, false, nil, "Delete? [y/N] no\n"},
+	{"xY", false, true, nil, "Delete? [y/N] yes\n"},
+	{"\x03", true, false, ErrInterrupted, "Delete? [Y/n] ^C\n"},
+	{"", true, false, io.EOF, "Delete? [Y/n] \n"},
+}
+
+func TestConfirm(t *testing.T) {
+	for i, test := range confirmTests {
+		c := &MockTerminal{toSend: []byte(test.in)}
+		ss := NewTerminal(c, "> ", true)
+		answer, err := ss.Confirm("Delete? ", test.def)
+		if answer != test.answer || err != test.err {
+			t.Errorf("test %d: got %v, %v, expected %v, %v", i, answer, err, test.answer, test.err)
+		}
+		if out := Strip(string(c.received)); !strings.HasSuffix(out, test.out) {
+			t.Errorf("test %d: got %q, expected it to end with %q", i, out, test.out)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	saveCursor    = []byte{KeyEscape, '7'}
+	restoreCursor = []byte{KeyEscape, '8'}
+	queryCursor   = []byte{KeyEscape, '[', '6', 'n'}
+)
+
+// SaveCursor makes the terminal remember the position of the cursor, e.g.
+// before drawing elsewhere on the screen. RestoreCursor moves it back.
+func (t *Terminal) SaveCursor() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(saveCursor)
+	return t.flush()
+}
+
+// RestoreCursor moves the cursor back to where it was when SaveCursor was
+// called.
+func (t *Terminal) RestoreCursor() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(restoreCursor)
+	return t.flush()
+}
+
+// QueryCursorPosition asks the terminal where the cursor is and waits at most
+// timeout for the answer. The column x and row y count from 0 at the top left
+// corner of the screen.
+func (t *Terminal) QueryCursorPosition(timeout time.Duration) (x, y int, err error) {
+	reply, err := t.query(queryCursor, func(seq []byte) bool {
+		_, _, ok := parseCursorReply(seq)
+		return ok
+	}, timeout)
+	if err != nil {
+		return 0, 0, err
+	}
+	x, y, _ = parseCursorReply(reply)
+	return x, y, nil
+}
+
+// parseCursorReply parses a cursor position report, ESC [ row ; column R.
+func parseCursorReply(seq []byte) (x, y int, ok bool) {
+	params, ok := bytes.CutPrefix(seq, []byte{KeyEscape, '['})
+	if !ok {
+		return
+	}
+	if params, ok = bytes.CutSuffix(params, []byte{'R'}); !ok {
+		return
+	}
+	row, col, ok := bytes.Cut(params, []byte{';'})
+	if !ok {
+		return
+	}
+	r, err1 := strconv.Atoi(string(row))
+	c, err2 := strconv.Atoi(string(col))
+	if err1 != nil || err2 != nil || r < 1 || c < 1 {
+		return 0, 0, false
+	}
+	return c - 1, r - 1, true
+}
+
+var clearScreen = []byte{KeyEscape, '[', 'H', KeyEscape, '[', '2', 'J'}
+
+// editing reports whether the prompt and line being edited are on the screen.
+func (t *Terminal) editing() bool {
+	return t.reading && !t.readingRaw || t.cursorX != 0 || t.cursorY != 0
+}
+
+// ClearScreen clears the whole screen. If a line is being edited, the prompt
+// and line are painted again at the top.
+func (t *Terminal) ClearScreen() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(hideCursor)
+	t.queue(clearScreen)
+	if t.editing() {
+		t.repaint()
+	} else {
+		t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+		if len(t.footer) > 0 {
+			t.drawFooter(false)
+		}
+	}
+	t.drawStatusLine()
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// ClearLine clears the row the cursor is on. If a line is being edited, the
+// text entered so far is discarded, leaving the prompt on an otherwise empty
+// row.
+func (t *Terminal) ClearLine() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(hideCursor)
+	editing := t.editing()
+	t.clearLines()
+	t.line = t.line[:0]
+	t.pos = 0
+	if editing {
+		t.repaint()
+	} else {
+		t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+		if len(t.footer) > 0 {
+			t.drawFooter(false)
+		}
+	}
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// ClearToEndOfScreen clears the screen below the cursor, or, if a line is
+// being edited, below the line, e.g. to remove a list of completions printed
+// underneath it.
+func (t *Terminal) ClearToEndOfScreen() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.editing() {
+		t.clearToEndOfScreen()
+		return t.flush()
+	}
+
+	t.queue(hideCursor)
+	t.moveCursorToPos(len(t.line))
+	t.clearToEndOfScreen()
+	t.maxLine = t.cursorY
+	t.moveCursorToPos(t.pos)
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// errBadScrollRegion is returned by SetScrollRegion for rows that are out of
+// range.
+var errBadScrollRegion = errors.New("terminal: invalid scroll region")
+
+// SetScrollRegion confines scrolling to the rows top to bottom, counting from
+// 0 and inclusive, so that rows outside of them, e.g. a status area at the top
+// or bottom of the screen, stay put while the prompt and output scroll. The
+// cursor stays where it is.
+func (t *Terminal) SetScrollRegion(top, bottom int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if top < 0 || bottom <= top || bottom >= t.termHeight {
+		return errBadScrollRegion
+	}
+	// Setting the region moves the cursor to the top left corner.
+	t.queue(saveCursor)
+	t.outBuf = append(t.outBuf, KeyEscape, '[')
+	t.outBuf = strconv.AppendInt(t.outBuf, int64(top+1), 10)
+	t.outBuf = append(t.outBuf, ';')
+	t.outBuf = strconv.AppendInt(t.outBuf, int64(bottom+1), 10)
+	t.outBuf = append(t.outBuf, 'r')
+	t.queue(restoreCursor)
+	return t.flush()
+}
+
+var resetScrollRegion = []byte{KeyEscape, '[', 'r'}
+
+// ResetScrollRegion lets the whole screen scroll again.
+func (t *Terminal) ResetScrollRegion() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(saveCursor)
+	t.queue(resetScrollRegion)
+	t.queue(restoreCursor)
+	return t.flush()
+}
@@ -0,0 +1,109 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaveCursor(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SaveCursor()
+	ss.RestoreCursor()
+	if want := "\x1b7\x1b8"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestQueryCursorPosition(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("a\x1b[12;40Rb\r")}
+	ss := NewTerminal(c, "> ", true)
+	x, y, err := ss.QueryCursorPosition(time.Second)
+	if x != 39 || y != 11 || err != nil {
+		t.Errorf("got %d, %d, %v, expected 39, 11", x, y, err)
+	}
+	if want := "\x1b[6n"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if line, err := ss.ReadLine(); line != "ab" || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, "ab")
+	}
+}
+
+var cursorReplyTests = []struct {
+	in   string
+	x, y int
+	ok   bool
+}{
+	{"\x1b[1;1R", 0, 0, true},
+	{"\x1b[24;80R", 79, 23, true},
+	{"\x1b[1;2A", 0, 0, false},
+	{"\x1b[;2R", 0, 0, false},
+	{"\x1b[0;0R", 0, 0, false},
+}
+
+func TestParseCursorReply(t *testing.T) {
+	for i, test := range cursorReplyTests {
+		x, y, ok := parseCursorReply([]byte(test.in))
+		if x != test.x || y != test.y || ok != test.ok {
+			t.Errorf("test %d: got %d, %d, %v, expected %d, %d, %v", i, x, y, ok, test.x, test.y, test.ok)
+		}
+	}
+}
+
+func TestClearScreen(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab")}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadLine()
+	c.received = nil
+	ss.ClearScreen()
+	if want := "\x1b[?25l\x1b[H\x1b[2J> ab\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if ss.cursorX != 4 || ss.cursorY != 0 {
+		t.Errorf("cursor at %d, %d, expected 4, 0", ss.cursorX, ss.cursorY)
+	}
+}
+
+func TestClearLine(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab")}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadLine()
+	c.received = nil
+	ss.ClearLine()
+	if want := "\x1b[?25l\r\x1b[K> \x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	c.toSend = []byte("c\r")
+	if line, _ := ss.ReadLine(); line != "c" {
+		t.Errorf("got %q, expected %q", line, "c")
+	}
+}
+
+func TestClearToEndOfScreen(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\x1b[D")}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadLine()
+	c.received = nil
+	ss.ClearToEndOfScreen()
+	if want := "\x1b[?25l\x1b[C\x1b[J\x1b[D\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestScrollRegion(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	if err := ss.SetScrollRegion(1, 24); err == nil {
+		t.Errorf("region past the bottom of the screen accepted")
+	}
+	ss.SetScrollRegion(1, 22)
+	ss.ResetScrollRegion()
+	if want := "\x1b7\x1b[2;23r\x1b8\x1b7\x1b[r\x1b8"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
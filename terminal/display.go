@@ -0,0 +1,84 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// tabStop is the distance between tab stops, which terminals set every eight
+// columns.
+const tabStop = 8
+
+// display returns how b, which is part of the line and holds no newlines, is
+// shown when written from column x, so that the cursor can be tracked and
+// nothing in the line is taken for a command by the terminal. Tabs are
+// expanded to spaces up to the next tab stop, stopping at the end of the row
+// like the terminal's own tabs do. Other control characters are shown in caret
+// notation, like ^C for Ctrl-C or ^[ for Escape, C1 control characters as
+// \x85, and bytes that aren't valid UTF-8 as \xff.
+func (t *Terminal) display(b []byte, x int) []byte {
+	if !needsDisplay(b) {
+		return b
+	}
+	if x >= t.termWidth {
+		// The cursor is past the end of the row, so the next character
+		// starts a new one.
+		x = 0
+	}
+	out := make([]byte, 0, len(b)+tabStop)
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		var shown []byte
+		switch {
+		case r == '\t':
+			n := max(min(tabStop-x%tabStop, t.termWidth-x), 0)
+			shown = bytes.Repeat([]byte{' '}, n)
+		case r < 0x20 || r == 0x7f:
+			shown = []byte{'^', byte(r) ^ 0x40}
+		case r == utf8.RuneError && size == 1:
+			shown = hexByte(b[0])
+		case r >= 0x80 && r < 0xa0:
+			shown = hexByte(byte(r))
+		default:
+			shown = b[:size]
+		}
+		for len(shown) > 0 {
+			r, n := utf8.DecodeRune(shown)
+			width := runeWidth(r)
+			if x+width > t.termWidth {
+				x = 0
+			}
+			out = append(out, shown[:n]...)
+			x += width
+			if x >= t.termWidth {
+				x = 0
+			}
+			shown = shown[n:]
+		}
+		b = b[size:]
+	}
+	return out
+}
+
+// needsDisplay reports whether b, which is part of the line, has to be
+// changed by display to be shown.
+func needsDisplay(b []byte) bool {
+	for _, c := range b {
+		if c < 0x20 || c >= 0x7f {
+			// Multi-byte characters are mostly shown as they are,
+			// but have to be checked.
+			return true
+		}
+	}
+	return false
+}
+
+// hexByte returns c as it's shown when it can't be printed, e.g. \x9b.
+func hexByte(c byte) []byte {
+	const digits = "0123456789abcdef"
+	return []byte{'\\', 'x', digits[c>>4], digits[c&0xf]}
+}
@@ -0,0 +1,81 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+var displayTests = []struct {
+	in   string
+	x    int
+	want string
+}{
+	{"plain", 0, "plain"},
+	{"a\tb", 2, "a     b"},
+	{"\t\t", 0, "                "},
+	{"x\ty", 17, "x  y"},
+	{"a\x03b\x1b[31m\x7f", 0, "a^Cb^[[31m^?"},
+	{"\xffé\u0085", 0, `\xffé\x85`},
+	{"\x01\t", 0, "^A      "},
+}
+
+func TestDisplay(t *testing.T) {
+	ss := NewTerminal(&MockTerminal{}, "> ", true)
+	ss.SetSize(20, 5)
+	for _, test := range displayTests {
+		if got := string(ss.display([]byte(test.in), test.x)); got != test.want {
+			t.Errorf("display(%q, %d) = %q, expected %q", test.in, test.x, got, test.want)
+		}
+	}
+}
+
+func TestControlCursor(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(20, 5)
+	ss.SetLine("a\x1b[2Jb", 5)
+	if x, y := ss.cursorX, ss.cursorY; x != 8 || y != 0 {
+		t.Errorf("cursor tracked at %d,%d, expected 8,0", x, y)
+	}
+	if bytes.Contains(c.received, []byte("\x1b[2J")) {
+		t.Errorf("got %q, expected the escape to be shown in caret notation", c.received)
+	}
+}
+
+func TestTabCursor(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(20, 5)
+	ss.SetLine("a\tb", 3)
+	if x, y := ss.posToXY(3); x != 9 || y != 0 {
+		t.Errorf("cursor at %d,%d after a tab, expected 9,0", x, y)
+	}
+	if x, y := ss.cursorX, ss.cursorY; x != 9 || y != 0 {
+		t.Errorf("cursor tracked at %d,%d, expected 9,0", x, y)
+	}
+	for _, b := range c.received {
+		if b == '\t' {
+			t.Fatalf("got %q, expected the tab to be expanded", c.received)
+		}
+	}
+}
+
+func TestTabNarrowTerminal(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("日\t\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "", true)
+	ss.SetSize(1, 5)
+	ss.SetTabAction(TabInsertTab, TabInsertTab, 0)
+	if line, err := ss.ReadLine(); err != nil || line != "日\t" {
+		t.Errorf("got %q, %v", line, err)
+	}
+	if got := string(ss.display([]byte("\t"), 3)); got != " " {
+		t.Errorf("got %q for a tab past the end of the row, expected a space", got)
+	}
+}
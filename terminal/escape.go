@@ -0,0 +1,147 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+)
+
+// escapeLength returns the length of the escape sequence at the start of b,
+// which must begin with KeyEscape, or -1 if b only holds the beginning of one.
+// Sequences are delimited as described in ECMA-48: control sequences end in a
+// final byte in the range 0x40-0x7e, and control strings (OSC, DCS, SOS, PM
+// and APC) end with ST or, for OSC, BEL.
+func escapeLength(b []byte) int {
+	if len(b) < 2 {
+		return -1
+	}
+
+	switch b[1] {
+	case '[':
+		for i := 2; i < len(b); i++ {
+			switch c := b[i]; {
+			case c >= 0x40 && c <= 0x7e:
+				return i + 1
+			case c < 0x20:
+				// A control character aborts the sequence.
+				return i
+			}
+		}
+		return -1
+	case ']', 'P', 'X', '^', '_':
+		for i := 2; i < len(b); i++ {
+			switch b[i] {
+			case 7:
+				if b[1] == ']' {
+					return i + 1
+				}
+			case KeyEscape:
+				if i+1 == len(b) {
+					return -1
+				}
+				if b[i+1] == '\\' {
+					return i + 2
+				}
+			}
+		}
+		return -1
+	}
+
+	if b[1] < 0x20 {
+		// A lone escape followed by another control character.
+		return 1
+	}
+	// Intermediate bytes followed by a final byte, as in ESC ( B.
+	for i := 1; i < len(b); i++ {
+		if b[i] < 0x20 || b[i] > 0x2f {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// isStrippedControl reports whether c is a control character that is removed
+// when stripping escape sequences. Newlines and tabs are kept.
+func isStrippedControl(c byte) bool {
+	return (c < 0x20 || c == 0x7f) && c != '\n' && c != '\t'
+}
+
+// stripEscapes appends b to dst without any escape sequences or control
+// characters. It returns the new dst and the tail of b holding an incomplete
+// escape sequence, if any.
+func stripEscapes(dst, b []byte) ([]byte, []byte) {
+	for len(b) > 0 {
+		i := 0
+		for i < len(b) && !isStrippedControl(b[i]) {
+			i++
+		}
+		dst = append(dst, b[:i]...)
+		b = b[i:]
+		if len(b) == 0 {
+			break
+		}
+
+		if b[0] != KeyEscape {
+			b = b[1:]
+			continue
+		}
+		n := escapeLength(b)
+		if n < 0 {
+			return dst, b
+		}
+		b = b[n:]
+	}
+	return dst, nil
+}
+
+// Strip returns s with all escape sequences and control characters, other
+// than newlines and tabs, removed.
+func Strip(s string) string {
+	out, _ := stripEscapes(make([]byte, 0, len(s)), []byte(s))
+	return string(out)
+}
+
+// maxPartialEscape is the length up to which StripWriter waits for the end of
+// an escape sequence. An unterminated control string would otherwise hold
+// back everything written after it.
+const maxPartialEscape = 4096
+
+// StripWriter is an io.Writer that removes all escape sequences and control
+// characters, other than newlines and tabs, from the data written to it
+// before passing it on. It's useful for logging a session to a file or for
+// destinations that aren't terminals.
+type StripWriter struct {
+	w io.Writer
+	// partial holds an escape sequence that was split across writes.
+	partial []byte
+	buf     []byte
+}
+
+// NewStripWriter returns a StripWriter writing to w.
+func NewStripWriter(w io.Writer) *StripWriter {
+	return &StripWriter{w: w}
+}
+
+func (s *StripWriter) Write(data []byte) (n int, err error) {
+	in := data
+	if len(s.partial) > 0 {
+		in = append(s.partial, data...)
+	}
+
+	var rest []byte
+	s.buf, rest = stripEscapes(s.buf[:0], in)
+	for len(rest) > maxPartialEscape {
+		// Give up on the sequence and pass on what follows the escape.
+		s.buf, rest = stripEscapes(s.buf, rest[1:])
+	}
+	s.partial = append(s.partial[:0], rest...)
+
+	if len(s.buf) > 0 {
+		if _, err = s.w.Write(s.buf); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
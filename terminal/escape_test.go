@@ -0,0 +1,78 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var escapeLengthTests = []struct {
+	in string
+	n  int
+}{
+	{"\x1b", -1},
+	{"\x1b[", -1},
+	{"\x1b[31m", 5},
+	{"\x1b[1;31mfoo", 7},
+	{"\x1b[3~", 4},
+	{"\x1b[?25l", 6},
+	{"\x1b]0;title\x07rest", 10},
+	{"\x1b]0;title\x1b\\rest", 11},
+	{"\x1b]0;tit", -1},
+	{"\x1bP1$r\x1b", -1},
+	{"\x1b(Bx", 3},
+	{"\x1bb", 2},
+	{"\x1b\x1b", 1},
+}
+
+func TestEscapeLength(t *testing.T) {
+	for i, test := range escapeLengthTests {
+		if n := escapeLength([]byte(test.in)); n != test.n {
+			t.Errorf("test %d (%q): got %d, expected %d", i, test.in, n, test.n)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	in := "\x1b[1;31mred\x1b[0m\r\n\x1b]0;title\x07tab\there\x08\n"
+	if out := Strip(in); out != "red\ntab\there\n" {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestStripWriter(t *testing.T) {
+	in := "a\x1b[31mb\x1b]2;some title\x1b\\c\r\n\x1b[0md"
+	for chunk := 1; chunk <= len(in); chunk++ {
+		var buf bytes.Buffer
+		w := NewStripWriter(&buf)
+		for i := 0; i < len(in); i += chunk {
+			end := min(i+chunk, len(in))
+			if n, err := w.Write([]byte(in[i:end])); n != end-i || err != nil {
+				t.Fatalf("Write returned %d, %v", n, err)
+			}
+		}
+		if out := buf.String(); out != "abc\nd" {
+			t.Errorf("chunk size %d: got %q", chunk, out)
+		}
+	}
+}
+
+func TestStripWriterUnterminated(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewStripWriter(&buf)
+	w.Write([]byte("a\x1b]2;"))
+	for i := 0; i < maxPartialEscape; i += 100 {
+		w.Write(bytes.Repeat([]byte{'x'}, 100))
+	}
+	if len(w.partial) > maxPartialEscape {
+		t.Errorf("holding %d bytes, expected at most %d", len(w.partial), maxPartialEscape)
+	}
+	w.Write([]byte("\r\nb"))
+	if out := buf.String(); !strings.HasPrefix(out, "a") || !strings.HasSuffix(out, "x\nb") {
+		t.Errorf("got %q, expected the text after the escape to be passed on", out)
+	}
+}
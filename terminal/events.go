@@ -0,0 +1,242 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"sync"
+)
+
+// EventType identifies the kind of an Event.
+type EventType int
+
+const (
+	// EventKey is a key press; Event.Key holds the key, and Event.Text
+	// the character for KeyRune.
+	EventKey EventType = iota
+	// EventResize is sent by SetSize when the size of the terminal
+	// changes; Event.Width and Event.Height hold the new size.
+	EventResize
+	// EventPaste is text pasted into a terminal that supports bracketed
+	// paste; Event.Text holds the text.
+	EventPaste
+	// EventCustom is an event posted by the application with PostEvent;
+	// Event.Data holds its value.
+	EventCustom
+	// EventError is the last event, sent when reading from the terminal
+	// fails; Event.Err holds the error.
+	EventError
+)
+
+// Event is something an interactive program has to react to.
+type Event struct {
+	Type EventType
+
+	Key           int
+	Width, Height int
+	Text          string
+	Data          interface{}
+	Err           error
+}
+
+var (
+	enableBracketedPaste  = []byte{KeyEscape, '[', '?', '2', '0', '0', '4', 'h'}
+	disableBracketedPaste = []byte{KeyEscape, '[', '?', '2', '0', '0', '4', 'l'}
+	pasteStart            = []byte{KeyEscape, '[', '2', '0', '0', '~'}
+	pasteEnd              = []byte{KeyEscape, '[', '2', '0', '1', '~'}
+)
+
+// eventBuffer is the number of events that can be waiting to be received.
+const eventBuffer = 64
+
+// eventStream is the channel returned by Events, along with what's needed to
+// close it while events are being posted.
+type eventStream struct {
+	ch chan Event
+	// done is closed when the stream is, to abort posts in progress.
+	done chan struct{}
+	// senders counts the posts in progress, which must end before ch is
+	// closed.
+	senders sync.WaitGroup
+	// closed is set once done is closed. It's guarded by
+	// Terminal.eventLock.
+	closed bool
+}
+
+// Events starts reading from the terminal in the background and returns a
+// channel delivering key presses, pastes, resizes and events posted with
+// PostEvent, so that an interactive program can handle them all in one loop.
+// Bracketed paste is enabled, so that pasted text arrives as a single event.
+// The channel is closed after an EventError, or by StopEvents. Until
+// StopEvents is called, ReadLine returns ErrConcurrentRead.
+func (t *Terminal) Events() <-chan Event {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.events != nil {
+		return t.events.ch
+	}
+	s := &eventStream{ch: make(chan Event, eventBuffer), done: make(chan struct{})}
+	t.eventLock.Lock()
+	t.events = s
+	t.eventLock.Unlock()
+	t.reading = true
+	t.queue(enableBracketedPaste)
+	t.flush()
+	go t.readEvents(s)
+	return s.ch
+}
+
+// StopEvents ends what Events started: reading in the background stops,
+// bracketed paste is disabled again and the channel is closed, once any
+// events being posted have been received or dropped. Input that arrives
+// afterwards is left for ReadLine.
+func (t *Terminal) StopEvents() error {
+	t.lock.Lock()
+	s := t.events
+	if s == nil {
+		t.lock.Unlock()
+		return nil
+	}
+	t.eventLock.Lock()
+	t.events = nil
+	t.eventLock.Unlock()
+	t.doneReading()
+	t.queue(disableBracketedPaste)
+	err := t.flush()
+	t.lock.Unlock()
+
+	t.closeEvents(s)
+	return err
+}
+
+// PostEvent delivers an EventCustom carrying data through the channel returned
+// by Events. It blocks while the channel is full, so a program shouldn't post
+// many events from the loop that receives them.
+func (t *Terminal) PostEvent(data interface{}) {
+	t.post(Event{Type: EventCustom, Data: data})
+}
+
+// post sends ev to the events channel, unless it's closed or doesn't exist.
+// It gives up if the channel is closed while it waits for room.
+func (t *Terminal) post(ev Event) {
+	t.eventLock.Lock()
+	s := t.events
+	if s == nil || s.closed {
+		t.eventLock.Unlock()
+		return
+	}
+	s.senders.Add(1)
+	t.eventLock.Unlock()
+	defer s.senders.Done()
+
+	select {
+	case s.ch <- ev:
+	case <-s.done:
+	}
+}
+
+// closeEvents closes s once the posts in progress have ended.
+func (t *Terminal) closeEvents(s *eventStream) {
+	t.eventLock.Lock()
+	if s.closed {
+		t.eventLock.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	t.eventLock.Unlock()
+
+	s.senders.Wait()
+	close(s.ch)
+}
+
+// readEvents reads and decodes input for s until reading fails or s is
+// closed. The reads are those of startRead, so that one still going on when
+// s is closed hands its input to whoever reads next.
+func (t *Terminal) readEvents(s *eventStream) {
+	var events []Event
+	for {
+		t.lock.Lock()
+		if t.inFlight == nil {
+			t.startRead()
+		}
+		ch := t.inFlight
+		t.lock.Unlock()
+
+		var r readResult
+		select {
+		case r = <-ch:
+		case <-s.done:
+			return
+		}
+
+		t.lock.Lock()
+		t.inFlight = nil
+		t.addInput(r.data)
+		if t.events != s {
+			// StopEvents was called while the input arrived.
+			t.readErr = r.err
+			t.lock.Unlock()
+			return
+		}
+		events = t.decodeEvents(events[:0])
+		t.lock.Unlock()
+
+		for _, ev := range events {
+			t.post(ev)
+		}
+		if r.err != nil {
+			t.post(Event{Type: EventError, Err: r.err})
+			t.closeEvents(s)
+			return
+		}
+	}
+}
+
+// decodeEvents appends the events in t.remainder to events. Incomplete key
+// sequences and pastes are left in t.remainder, but a lone Escape at its end
+// is taken for the Escape key, as in readKey.
+func (t *Terminal) decodeEvents(events []Event) []Event {
+	rest := t.remainder
+	for len(rest) > 0 {
+		if len(rest) == 1 && rest[0] == KeyEscape {
+			// Escape sequences arrive in one piece, so this is
+			// the Escape key.
+			events = append(events, Event{Type: EventKey, Key: KeyEscape})
+			rest = nil
+			break
+		}
+		if bytes.HasPrefix(rest, pasteStart) {
+			end := bytes.Index(rest, pasteEnd)
+			if end < 0 {
+				break
+			}
+			events = append(events, Event{Type: EventPaste, Text: string(rest[len(pasteStart):end])})
+			rest = rest[end+len(pasteEnd):]
+			continue
+		}
+		if rest[0] == KeyEscape {
+			if n := escapeLength(rest); n > 0 && t.answer(rest[:n]) {
+				rest = rest[n:]
+				continue
+			}
+		}
+
+		var key int
+		seq := rest
+		key, rest = bytesToKey(rest)
+		if key < 0 {
+			break
+		}
+		ev := Event{Type: EventKey, Key: key}
+		if key == KeyRune {
+			ev.Text = string(seq[:len(seq)-len(rest)])
+		}
+		events = append(events, ev)
+	}
+	t.saveRemainder(rest)
+	return events
+}
@@ -0,0 +1,139 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	r, w := io.Pipe()
+	c := &MockTerminal{}
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, c}, "> ", true)
+
+	events := ss.Events()
+	go func() {
+		w.Write([]byte("a\x1b[A\xc3"))
+		w.Write([]byte("\xa9\x1b[200~hi\r\nthere\x1b[201~"))
+	}()
+
+	var got []Event
+	for len(got) < 4 {
+		got = append(got, <-events)
+	}
+	ss.SetSize(100, 30)
+	ss.PostEvent("tick")
+	w.CloseWithError(io.EOF)
+	for ev := range events {
+		got = append(got, ev)
+	}
+	want := []Event{
+		{Type: EventKey, Key: 'a'},
+		{Type: EventKey, Key: KeyUp},
+		{Type: EventKey, Key: KeyRune, Text: "\u00e9"},
+		{Type: EventPaste, Text: "hi\r\nthere"},
+		{Type: EventResize, Width: 100, Height: 30},
+		{Type: EventCustom, Data: "tick"},
+		{Type: EventError, Err: io.EOF},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+	if want := "\x1b[?2004h"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	// Events posted afterwards are dropped.
+	ss.PostEvent("late")
+}
+
+func TestEventsEscape(t *testing.T) {
+	r, w := io.Pipe()
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, &MockTerminal{}}, "> ", true)
+
+	events := ss.Events()
+	go func() {
+		w.Write([]byte("\x1b"))
+		w.Write([]byte("x"))
+	}()
+	want := []Event{{Type: EventKey, Key: KeyEscape}, {Type: EventKey, Key: 'x'}}
+	for _, want := range want {
+		if got := <-events; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, expected %+v", got, want)
+		}
+	}
+	w.CloseWithError(io.EOF)
+	for range events {
+	}
+}
+
+func TestStopEvents(t *testing.T) {
+	r, w := io.Pipe()
+	c := &MockTerminal{}
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, c}, "> ", true)
+
+	events := ss.Events()
+	go w.Write([]byte("a"))
+	if ev := <-events; ev.Key != 'a' {
+		t.Fatalf("got %+v, expected a", ev)
+	}
+	if err := ss.StopEvents(); err != nil {
+		t.Fatal(err)
+	}
+	for ev := range events {
+		t.Errorf("got %+v after StopEvents", ev)
+	}
+	if want := "\x1b[?2004h\x1b[?2004l"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	// Input is read by ReadLine again.
+	go w.Write([]byte("hi\r"))
+	if line, err := ss.ReadLine(); line != "hi" || err != nil {
+		t.Errorf("got %q, %v, expected hi", line, err)
+	}
+	w.Close()
+}
+
+func TestStopEventsWhilePosting(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, &MockTerminal{}}, "> ", true)
+
+	ss.Events()
+	for i := 0; i < eventBuffer; i++ {
+		ss.PostEvent(i)
+	}
+	// Nobody receives, so these block until StopEvents.
+	posted := make(chan bool)
+	go func() {
+		ss.PostEvent("blocked")
+		posted <- true
+	}()
+	go func() {
+		ss.SetSize(10, 10)
+		posted <- true
+	}()
+	ss.SetPrompt("$ ")
+	if err := ss.StopEvents(); err != nil {
+		t.Fatal(err)
+	}
+	<-posted
+	<-posted
+}
@@ -0,0 +1,44 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+// SetFlowControl turns software flow control in the line editor on or off.
+//
+// By default it's off: MakeRaw turns off the tty's handling of XOFF and XON,
+// and Ctrl-S and Ctrl-Q are passed to the AutoCompleteCallback like other
+// keys, so they can be bound, and are otherwise ignored.
+//
+// With flow control on, Ctrl-S pauses the output, as the tty does outside raw
+// mode, and Ctrl-Q resumes it. The output, including the echo of what's typed,
+// is held meanwhile, and written once it's resumed. The keys are only seen
+// while a line is read.
+func (t *Terminal) SetFlowControl(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.flowControl = on
+	if !on {
+		t.pauseOutput(false)
+	}
+}
+
+// OutputPaused reports whether the output was paused with Ctrl-S.
+func (t *Terminal) OutputPaused() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.outputPaused
+}
+
+// pauseOutput pauses or resumes the output, writing what's been held.
+func (t *Terminal) pauseOutput(pause bool) {
+	if t.outputPaused == pause {
+		return
+	}
+	t.outputPaused = pause
+	if !pause {
+		t.flush()
+	}
+}
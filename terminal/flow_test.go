@@ -0,0 +1,63 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlowControl(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("a\x13bc\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetFlowControl(true)
+	line, err := ss.ReadLine()
+	if err != nil || line != "abc" {
+		t.Fatalf("got %q, %v, expected \"abc\"", line, err)
+	}
+	if !ss.OutputPaused() {
+		t.Error("Ctrl-S didn't pause the output")
+	}
+	if out := string(c.received); !strings.Contains(out, "a") || strings.Contains(out, "b") {
+		t.Errorf("got %q, expected the output to be held after Ctrl-S", out)
+	}
+
+	c.toSend = []byte("\x11d\r")
+	if line, err = ss.ReadLine(); err != nil || line != "d" {
+		t.Fatalf("got %q, %v, expected \"d\"", line, err)
+	}
+	if ss.OutputPaused() {
+		t.Error("Ctrl-Q didn't resume the output")
+	}
+	if out := string(c.received); !strings.Contains(out, "bc") {
+		t.Errorf("got %q, expected the held output after Ctrl-Q", out)
+	}
+}
+
+func TestFlowControlOff(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("a\x13b\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	var keys []int
+	ss.AutoCompleteCallback = func(line []byte, pos, key int) ([]byte, int) {
+		keys = append(keys, key)
+		return nil, 0
+	}
+	line, err := ss.ReadLine()
+	if err != nil || line != "ab" {
+		t.Fatalf("got %q, %v, expected \"ab\"", line, err)
+	}
+	if len(keys) != 3 || keys[1] != KeyCtrlS {
+		t.Errorf("got keys %v, expected Ctrl-S to be passed to the callback", keys)
+	}
+	if ss.OutputPaused() {
+		t.Error("Ctrl-S paused the output with flow control off")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// guarded holds the local terminals in raw mode, whose settings are restored
+// by RestoreTerminals.
+var guarded struct {
+	sync.Mutex
+	terms map[*Terminal]struct{}
+}
+
+// guard registers t, which has been put into raw mode, with RestoreTerminals.
+func guard(t *Terminal) {
+	guarded.Lock()
+	defer guarded.Unlock()
+
+	if guarded.terms == nil {
+		guarded.terms = make(map[*Terminal]struct{})
+	}
+	guarded.terms[t] = struct{}{}
+}
+
+// unguard forgets t, whose settings have been restored.
+func unguard(t *Terminal) {
+	guarded.Lock()
+	defer guarded.Unlock()
+
+	delete(guarded.terms, t)
+}
+
+// RestoreTerminals restores the settings of the terminals that
+// NewWithStdInOut put into raw mode and shows the cursor, leaving the user's
+// shell usable. It doesn't wait for the terminals' locks, as it's meant for a
+// program that's going down and may hold them.
+//
+// Exit, RestoreOnPanic and RestoreOnSignal call RestoreTerminals for the cases
+// in which ReleaseFromStdInOut isn't reached.
+func RestoreTerminals() {
+	guarded.Lock()
+	defer guarded.Unlock()
+
+	for t := range guarded.terms {
+		Restore(t.ttyFd, t.cooked)
+		t.c.Write(showCursor)
+	}
+}
+
+// Exit restores the terminals' settings, as RestoreTerminals does, and exits
+// the program with code. Programs using NewWithStdInOut call it instead of
+// os.Exit, which doesn't run deferred calls.
+func Exit(code int) {
+	RestoreTerminals()
+	os.Exit(code)
+}
+
+// RestoreOnPanic restores the terminals' settings, as RestoreTerminals does,
+// if the program panics, and lets the panic continue, so its message is
+// printed on a usable terminal. It must be deferred at the start of main and
+// of each goroutine that may panic:
+//
+//	defer terminal.RestoreOnPanic()
+func RestoreOnPanic() {
+	if r := recover(); r != nil {
+		RestoreTerminals()
+		panic(r)
+	}
+}
+
+// RestoreOnSignal restores the terminals' settings, as RestoreTerminals does,
+// when the program receives one of sigs, by default os.Interrupt and SIGTERM,
+// and then lets the signal take its default action, usually ending the
+// program. Raw mode turns off the signals from keys like Ctrl-C, so they come
+// from other programs, such as kill. Calling stop undoes RestoreOnSignal.
+//
+// A program that handles the signals itself calls RestoreTerminals instead.
+func RestoreOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			RestoreTerminals()
+			signal.Reset(sig)
+			// Signal the program again, for the default action; where
+			// that isn't possible, exit as the action would.
+			if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+				os.Exit(1)
+			}
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/pty"
+)
+
+func TestRestoreOnPanic(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("can't open a pseudo-terminal: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+	fd := int(tty.Fd())
+
+	ss := NewTerminal(tty, "> ", true)
+	if ss.cooked, err = MakeRaw(fd); err != nil {
+		t.Fatal(err)
+	}
+	ss.ttyFd = fd
+	guard(ss)
+	defer ss.ReleaseFromStdInOut()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v, expected the panic to continue", r)
+			}
+		}()
+		defer RestoreOnPanic()
+		panic("boom")
+	}()
+
+	b := make([]byte, 64)
+	if n, _ := ptmx.Read(b); string(b[:n]) != string(showCursor) {
+		t.Errorf("got %q, expected the cursor to be shown", b[:n])
+	}
+	tty.Write([]byte("b\n"))
+	if n, _ := ptmx.Read(b); string(b[:n]) != "b\r\n" {
+		t.Errorf("got %q after the panic, expected the settings to be restored", b[:n])
+	}
+
+	ss.ReleaseFromStdInOut()
+	guarded.Lock()
+	if _, ok := guarded.terms[ss]; ok {
+		t.Error("ReleaseFromStdInOut didn't forget the terminal")
+	}
+	guarded.Unlock()
+}
@@ -0,0 +1,259 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SetHistoryExpansion turns bash-style history expansion on or off. It's off
+// by default. When it's on, references to earlier lines are replaced when
+// Enter is pressed, and the line is shown and returned expanded:
+//
+//	!!        the previous line
+//	!n        line n of the history, counting from 1
+//	!-n       the nth line back
+//	!prefix   the latest line starting with prefix
+//	!?text?   the latest line containing text
+//	!$ !^ !*  the last, first, and all but the first word of the previous line
+//	^old^new  the previous line, with old replaced by new
+//
+// Words of a line are picked with a colon after the reference, as in !!:2,
+// !-2:$ or !ls:*. The expansion character isn't special in single quotes,
+// after a backslash, or followed by a space, "=" or "(". If a reference can't
+// be expanded, the error is shown and the line is left to be edited.
+func (t *Terminal) SetHistoryExpansion(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.historyExpansion = on
+	if t.historyChars == [2]byte{} {
+		t.historyChars = [2]byte{'!', '^'}
+	}
+}
+
+// SetHistoryChars sets the characters starting a reference to an earlier line
+// and a quick substitution, by default '!' and '^'. A quick of 0 turns quick
+// substitution off.
+func (t *Terminal) SetHistoryChars(expand, quick byte) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.historyChars = [2]byte{expand, quick}
+}
+
+// SetHistoryVerify makes a line that history expansion changed be left to be
+// edited, like bash's histverify option, rather than returned right away, so
+// the expansion can be checked before pressing Enter again.
+func (t *Terminal) SetHistoryVerify(verify bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.historyVerify = verify
+}
+
+// expandLine performs history expansion on the line when Enter is pressed,
+// and reports whether the line is to be accepted. t.lock must be held.
+func (t *Terminal) expandLine() bool {
+	history := make([]string, len(t.history))
+	for i, h := range t.history {
+		history[i] = string(h)
+	}
+	expanded, err := expandHistory(string(t.line), history, t.historyChars[0], t.historyChars[1])
+	if err != nil {
+		t.write([]byte(err.Error() + "\r\n"))
+		t.invalidKey()
+		return false
+	}
+	if expanded == string(t.line) {
+		return true
+	}
+	t.setLine([]byte(expanded), len(expanded))
+	return !t.historyVerify
+}
+
+// expandHistory expands the references to lines of history in line.
+func expandHistory(line string, history []string, expand, quick byte) (string, error) {
+	if quick != 0 && len(line) > 0 && line[0] == quick {
+		return quickSubstitution(line, history, quick)
+	}
+
+	var b strings.Builder
+	inSingle, inDouble := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && !inSingle && i+1 < len(line):
+			b.WriteString(line[i : i+2])
+			i++
+			continue
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		}
+		if c != expand || inSingle || expand == 0 {
+			b.WriteByte(c)
+			continue
+		}
+		text, n, err := expandReference(line[i:], history, inDouble)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text)
+		i += n - 1
+	}
+	return b.String(), nil
+}
+
+// expandReference expands the reference at the start of s, which begins with
+// the expansion character, and returns its length.
+func expandReference(s string, history []string, inDouble bool) (text string, n int, err error) {
+	if len(s) == 1 || strings.IndexByte(" \t\n=(", s[1]) >= 0 || inDouble && s[1] == '"' {
+		return s[:1], 1, nil
+	}
+
+	notFound := func(ref string) error {
+		return fmt.Errorf("%s: event not found", ref)
+	}
+	last := func() (string, error) {
+		if len(history) == 0 {
+			return "", notFound(s[:n])
+		}
+		return history[len(history)-1], nil
+	}
+
+	var event string
+	switch c := s[1]; {
+	case c == s[0]:
+		n = 2
+		event, err = last()
+	case c == '$' || c == '^' || c == '*':
+		// Shorthands for words of the previous line.
+		n = 2
+		if event, err = last(); err == nil {
+			text, err = pickWords(event, s[1:2], s[:n])
+		}
+		return text, n, err
+	case c == '-' || c >= '0' && c <= '9':
+		n = 2
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		num, perr := strconv.Atoi(s[1:n])
+		switch {
+		case perr != nil:
+			return "", 0, notFound(s[:n])
+		case num > 0 && num <= len(history):
+			event = history[num-1]
+		case num < 0 && -num <= len(history):
+			event = history[len(history)+num]
+		default:
+			return "", 0, notFound(s[:n])
+		}
+	case c == '?':
+		end := strings.IndexByte(s[2:], '?')
+		if end < 0 {
+			n = len(s)
+		} else {
+			n = 2 + end + 1
+		}
+		text := strings.TrimSuffix(s[2:n], "?")
+		if event, err = latest(history, s[:n], func(h string) bool { return strings.Contains(h, text) }); err != nil {
+			return "", 0, err
+		}
+		return event, n, nil
+	default:
+		n = 1
+		for n < len(s) && strings.IndexByte(" \t\n:'\";&|", s[n]) < 0 {
+			n++
+		}
+		prefix := s[1:n]
+		if prefix == "" {
+			return s[:1], 1, nil
+		}
+		event, err = latest(history, s[:n], func(h string) bool { return strings.HasPrefix(h, prefix) })
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	// A word designator follows a colon.
+	if n+1 < len(s) && s[n] == ':' {
+		end := n + 1
+		if c := s[end]; c == '$' || c == '^' || c == '*' {
+			end++
+		} else {
+			for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+				end++
+			}
+		}
+		if end > n+1 {
+			text, err = pickWords(event, s[n+1:end], s[:end])
+			return text, end, err
+		}
+	}
+	return event, n, nil
+}
+
+// latest returns the latest line of history for which match is true.
+func latest(history []string, ref string, match func(string) bool) (string, error) {
+	for i := len(history) - 1; i >= 0; i-- {
+		if match(history[i]) {
+			return history[i], nil
+		}
+	}
+	return "", fmt.Errorf("%s: event not found", ref)
+}
+
+// pickWords returns the words of line picked by a word designator: a number,
+// counting from 0, "$" for the last, "^" for the first argument, or "*" for
+// all arguments.
+func pickWords(line, designator, ref string) (string, error) {
+	words := strings.Fields(line)
+	switch designator {
+	case "$":
+		if len(words) > 0 {
+			return words[len(words)-1], nil
+		}
+	case "^":
+		if len(words) > 1 {
+			return words[1], nil
+		}
+	case "*":
+		if len(words) > 1 {
+			return strings.Join(words[1:], " "), nil
+		}
+		return "", nil
+	default:
+		if i, err := strconv.Atoi(designator); err == nil && i < len(words) {
+			return words[i], nil
+		}
+	}
+	return "", fmt.Errorf("%s: bad word specifier", ref)
+}
+
+// quickSubstitution expands ^old^new^, which repeats the previous line with
+// old replaced by new.
+func quickSubstitution(line string, history []string, quick byte) (string, error) {
+	if len(history) == 0 {
+		return "", fmt.Errorf("%s: event not found", line)
+	}
+	parts := strings.SplitN(line[1:], string(quick), 3)
+	old, repl, rest := parts[0], "", ""
+	if len(parts) > 1 {
+		repl = parts[1]
+	}
+	if len(parts) > 2 {
+		rest = parts[2]
+	}
+	prev := history[len(history)-1]
+	if old == "" || !strings.Contains(prev, old) {
+		return "", fmt.Errorf("%s: substitution failed", line)
+	}
+	return strings.Replace(prev, old, repl, 1) + rest, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+var expandHistoryTests = []struct {
+	in, want, err string
+}{
+	{in: "echo hi", want: "echo hi"},
+	{in: "sudo !!", want: "sudo ls -l /tmp"},
+	{in: "!1", want: "make test"},
+	{in: "!-2", want: "git status"},
+	{in: "!git", want: "git status"},
+	{in: "!?tes?", want: "make test"},
+	{in: "cd !$", want: "cd /tmp"},
+	{in: "echo !*", want: "echo -l /tmp"},
+	{in: "echo !^", want: "echo -l"},
+	{in: "echo !make:1 !!:0", want: "echo test ls"},
+	{in: "^/tmp^/var^ -a", want: "ls -l /var -a"},
+	{in: "echo '!!' \\!! ! != !(x)", want: "echo '!!' \\!! ! != !(x)"},
+	{in: `echo "!!"`, want: `echo "ls -l /tmp"`},
+	{in: "!nope", err: "!nope: event not found"},
+	{in: "!9", err: "!9: event not found"},
+	{in: "!!:7", err: "!!:7: bad word specifier"},
+	{in: "^x^y", err: "^x^y: substitution failed"},
+}
+
+func TestExpandHistory(t *testing.T) {
+	history := []string{"make test", "git status", "ls -l /tmp"}
+	for _, test := range expandHistoryTests {
+		got, err := expandHistory(test.in, history, '!', '^')
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expandHistory(%q) returned %q, %v, expected error %q", test.in, got, err, test.err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("expandHistory(%q) = %q, %v, expected %q", test.in, got, err, test.want)
+		}
+	}
+}
+
+func TestHistoryExpansion(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ls\recho !!\r!x\r\x7f\x7fls\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetHistoryExpansion(true)
+	for _, want := range []string{"ls", "echo ls", "ls"} {
+		if line, err := ss.ReadLine(); err != nil || line != want {
+			t.Errorf("got %q, %v, expected %q", line, err, want)
+		}
+	}
+	if out := string(c.received); !strings.Contains(out, "!x: event not found") {
+		t.Errorf("got %q, expected the error to be shown", out)
+	}
+	if h := ss.GetHistory(); h[1] != "echo ls" {
+		t.Errorf("got history %q, expected the expanded line", h)
+	}
+}
+
+func TestHistoryVerify(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ls\r%%\r -a\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetHistoryExpansion(true)
+	ss.SetHistoryChars('%', 0)
+	ss.SetHistoryVerify(true)
+	ss.ReadLine()
+	if line, err := ss.ReadLine(); err != nil || line != "ls -a" {
+		t.Errorf("got %q, %v, expected the expanded line to be edited", line, err)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "slices"
+
+// An Action tells the line editor what to do with a key after a hook has seen
+// it.
+type Action int
+
+const (
+	// Continue passes the key on to the next hook, and after the last one
+	// to the line editor.
+	Continue Action = iota
+	// Consume stops the key from being processed any further.
+	Consume
+)
+
+// keyHook is a registered hook. Hooks are compared by identity, so that the
+// same function can be registered more than once.
+type keyHook struct {
+	f func(state LineState) Action
+}
+
+// RegisterKeyHook registers hook to be called each time key is pressed while
+// a line is being read, before the line editor acts on it. Keys remapped with
+// BindKey are seen as the key they're bound to. The hook is passed the state
+// of the line and may observe the key, or handle it and consume it. Hooks for
+// the same key are called in the order they were registered, until one of
+// them returns Consume. A hook is called without the terminal locked, so it
+// may use SetLine, InsertText or Write. RegisterKeyHook returns a function
+// that removes the hook again.
+func (t *Terminal) RegisterKeyHook(key int, hook func(state LineState) Action) (remove func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	h := &keyHook{hook}
+	if t.keyHooks == nil {
+		t.keyHooks = make(map[int][]*keyHook)
+	}
+	t.keyHooks[key] = append(t.keyHooks[key], h)
+	return func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		hooks := slices.DeleteFunc(slices.Clone(t.keyHooks[key]), func(other *keyHook) bool {
+			return other == h
+		})
+		if len(hooks) == 0 {
+			delete(t.keyHooks, key)
+		} else {
+			t.keyHooks[key] = hooks
+		}
+	}
+}
+
+// runKeyHooks calls the hooks registered for key and reports whether one of
+// them consumed it. t.lock is released while they run.
+func (t *Terminal) runKeyHooks(key int) Action {
+	hooks := t.keyHooks[key]
+	t.flush()
+	for _, h := range hooks {
+		state := t.lineState()
+		t.lock.Unlock()
+		action := h.f(state)
+		t.lock.Lock()
+		if action == Consume {
+			return Consume
+		}
+	}
+	return Continue
+}
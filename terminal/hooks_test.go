@@ -0,0 +1,48 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+func TestKeyHooks(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ab?c\x02\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	ss.BindKey('\x02', KeyLeft)
+	var seen []LineState
+	ss.RegisterKeyHook('?', func(state LineState) Action {
+		seen = append(seen, state)
+		return Continue
+	})
+	removeHelp := ss.RegisterKeyHook('?', func(state LineState) Action {
+		ss.InsertText("!")
+		return Consume
+	})
+	var lefts int
+	ss.RegisterKeyHook(KeyLeft, func(state LineState) Action {
+		lefts++
+		return Continue
+	})
+	if line, err := ss.ReadLine(); err != nil || line != "ab!c" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	if len(seen) != 1 || seen[0].Line != "ab" || seen[0].Pos != 2 {
+		t.Errorf("first hook saw %+v", seen)
+	}
+	if lefts != 1 {
+		t.Errorf("hook for KeyLeft called %d times, expected once for the bound key", lefts)
+	}
+
+	removeHelp()
+	c.toSend = []byte("a?\r")
+	if line, err := ss.ReadLine(); err != nil || line != "a?" {
+		t.Errorf("got %q, %v after removing the hook", line, err)
+	}
+	if len(seen) != 2 {
+		t.Errorf("remaining hook called %d times, expected twice", len(seen))
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "time"
+
+// OnIdle sets a function that is called when no key has been pressed for d
+// while a line is being read, e.g. to show a hint with Write, refresh an
+// access token or warn that the session is about to be closed. It's called
+// once each time the user stops typing for that long, without the terminal
+// locked. A nil f or a d of 0 removes the function.
+func (t *Terminal) OnIdle(d time.Duration, f func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if d <= 0 {
+		f = nil
+	}
+	t.onIdle, t.idleDuration = f, d
+	if t.reading && t.events == nil {
+		t.armIdle()
+	}
+}
+
+// armIdle starts waiting for the user to become idle, replacing any earlier
+// wait.
+func (t *Terminal) armIdle() {
+	t.idleGen++
+	f := t.onIdle
+	if f == nil {
+		return
+	}
+	gen := t.idleGen
+	time.AfterFunc(t.idleDuration, func() {
+		t.lock.Lock()
+		idle := t.idleGen == gen
+		t.lock.Unlock()
+		if idle {
+			f()
+		}
+	})
+}
+
+// disarmIdle stops waiting for the user to become idle.
+func (t *Terminal) disarmIdle() {
+	t.idleGen++
+}
@@ -0,0 +1,47 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestOnIdle(t *testing.T) {
+	r, w := io.Pipe()
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, io.Discard}, "> ", true)
+	idle := make(chan bool, 10)
+	ss.OnIdle(10*time.Millisecond, func() {
+		ss.SetPrompt("still there? ")
+		idle <- true
+	})
+
+	done := make(chan string)
+	go func() {
+		line, _ := ss.ReadLine()
+		done <- line
+	}()
+	<-idle
+	select {
+	case <-idle:
+		t.Fatal("called again without a key press in between")
+	case <-time.After(50 * time.Millisecond):
+	}
+	w.Write([]byte("ab"))
+	<-idle
+	w.Write([]byte("\r"))
+	if line := <-done; line != "ab" {
+		t.Errorf("got %q", line)
+	}
+	select {
+	case <-idle:
+		t.Error("called after the line was read")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,397 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BindKey makes key do what action does in the line editor, e.g.
+// BindKey('\x02', KeyLeft) makes Ctrl-B move the cursor left. The
+// AutoCompleteCallback sees action rather than key. BindKey(key, key) undoes
+// the binding.
+func (t *Terminal) BindKey(key, action int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.bindKey(key, action)
+}
+
+func (t *Terminal) bindKey(key, action int) {
+	if key == action {
+		delete(t.bindings, key)
+		return
+	}
+	if t.bindings == nil {
+		t.bindings = make(map[int]int)
+	}
+	t.bindings[key] = action
+}
+
+// inputrcFunctions maps the names of readline's functions to the keys that
+// do the same in the line editor.
+var inputrcFunctions = map[string]int{
+	"accept-line":          KeyEnter,
+	"backward-char":        KeyLeft,
+	"backward-delete-char": KeyBackspace,
+	"backward-word":        KeyAltLeft,
+	"complete":             '\t',
+	"forward-char":         KeyRight,
+	"forward-word":         KeyAltRight,
+	"next-history":         KeyDown,
+	"previous-history":     KeyUp,
+}
+
+// maxInputrcDepth limits the nesting of $include directives.
+const maxInputrcDepth = 10
+
+// LoadUserInputrc applies the user's readline init file, named by $INPUTRC
+// or ~/.inputrc, as LoadInputrc does. It isn't an error for the file not to
+// exist.
+func (t *Terminal) LoadUserInputrc(app string) error {
+	name := os.Getenv("INPUTRC")
+	if name == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		name = filepath.Join(home, ".inputrc")
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	return t.LoadInputrc(f, app)
+}
+
+// LoadInputrc applies the subset of a readline init file, such as
+// ~/.inputrc, that the line editor supports, so it respects the keys users
+// have configured for readline. app is the program's name, for $if
+// directives.
+//
+// Key bindings to readline functions that the line editor has, such as
+// backward-char or previous-history, are made with BindKey, as long as the
+// key is one that DecodeKey recognizes. The bell-style variable sets
+// SetBellOnError and SetVisualBell, and all variables can be looked up with
+// InputrcVariable. The directives $if, $else, $endif and $include are
+// followed. Anything else, including macros and the vi editing mode, is
+// ignored, as readline ignores lines it doesn't understand.
+func (t *Terminal) LoadInputrc(r io.Reader, app string) error {
+	p := inputrcParser{
+		t:    t,
+		app:  app,
+		term: os.Getenv("TERM"),
+	}
+	return p.parse(r, 0)
+}
+
+// InputrcVariable returns the value of a variable set by LoadInputrc, such
+// as "completion-ignore-case", and whether it was set.
+func (t *Terminal) InputrcVariable(name string) (value string, ok bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	value, ok = t.inputrcVars[strings.ToLower(name)]
+	return
+}
+
+type inputrcParser struct {
+	t         *Terminal
+	app, term string
+	// skip holds, for each $if being parsed, whether its lines are
+	// skipped.
+	skip []bool
+}
+
+func (p *inputrcParser) skipping() bool {
+	for _, s := range p.skip {
+		if s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *inputrcParser) parse(r io.Reader, depth int) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '$' {
+			if err := p.directive(line[1:], depth); err != nil {
+				return err
+			}
+			continue
+		}
+		if p.skipping() {
+			continue
+		}
+		if fields := strings.Fields(line); fields[0] == "set" {
+			if len(fields) >= 3 {
+				p.set(fields[1], fields[2])
+			}
+			continue
+		}
+		p.bind(line)
+	}
+	return s.Err()
+}
+
+func (p *inputrcParser) directive(line string, depth int) error {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "if":
+		p.skip = append(p.skip, !p.test(arg))
+	case "else":
+		if n := len(p.skip); n > 0 {
+			p.skip[n-1] = !p.skip[n-1]
+		}
+	case "endif":
+		if n := len(p.skip); n > 0 {
+			p.skip = p.skip[:n-1]
+		}
+	case "include":
+		if p.skipping() || depth >= maxInputrcDepth {
+			return nil
+		}
+		if rest, ok := strings.CutPrefix(arg, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil
+			}
+			arg = filepath.Join(home, rest)
+		}
+		f, err := os.Open(arg)
+		if err != nil {
+			// readline carries on without the file.
+			return nil
+		}
+		defer f.Close()
+		return p.parse(f, depth+1)
+	}
+	return nil
+}
+
+// test evaluates the condition of an $if directive.
+func (p *inputrcParser) test(cond string) bool {
+	if mode, ok := strings.CutPrefix(cond, "mode="); ok {
+		return mode == "emacs"
+	}
+	if term, ok := strings.CutPrefix(cond, "term="); ok {
+		full, _, _ := strings.Cut(p.term, "-")
+		return term == p.term || term == full
+	}
+	if strings.ContainsAny(cond, "=<>") {
+		// Comparisons of variables or the readline version.
+		return false
+	}
+	return p.app != "" && strings.EqualFold(cond, p.app)
+}
+
+func (p *inputrcParser) set(name, value string) {
+	t := p.t
+	name = strings.ToLower(name)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.inputrcVars == nil {
+		t.inputrcVars = make(map[string]string)
+	}
+	t.inputrcVars[name] = value
+	if name == "bell-style" {
+		switch strings.ToLower(value) {
+		case "none":
+			t.bellOnError = false
+		case "audible":
+			t.bellOnError, t.visualBell = true, false
+		case "visible":
+			t.bellOnError, t.visualBell = true, true
+		}
+	}
+}
+
+// bind parses a key binding: a key name or a quoted key sequence, a colon
+// and the name of a function.
+func (p *inputrcParser) bind(line string) {
+	var seq []byte
+	var rest string
+	if line[0] == '"' {
+		end := 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(line) {
+			return
+		}
+		seq = unescapeKeySeq(line[1:end])
+		rest = line[end+1:]
+	} else {
+		name, r, ok := strings.Cut(line, ":")
+		if !ok {
+			return
+		}
+		seq = keyName(strings.TrimSpace(name))
+		rest = ":" + r
+	}
+
+	rest, ok := strings.CutPrefix(strings.TrimSpace(rest), ":")
+	fields := strings.Fields(rest)
+	if !ok || len(seq) == 0 || len(fields) == 0 {
+		return
+	}
+	key, n := DecodeKey(seq)
+	if n != len(seq) || key == KeyUnknown || key == KeyRune {
+		return
+	}
+	action, ok := inputrcFunctions[strings.ToLower(fields[0])]
+	if strings.EqualFold(fields[0], "self-insert") {
+		action, ok = key, true
+	}
+	if !ok {
+		return
+	}
+	p.t.BindKey(key, action)
+}
+
+// keyName returns the bytes of a key named as in "Control-u" or "Rubout".
+func keyName(name string) []byte {
+	var ctrl, meta bool
+prefixes:
+	for {
+		lower := strings.ToLower(name)
+		switch {
+		case strings.HasPrefix(lower, "control-"):
+			ctrl, name = true, name[len("control-"):]
+		case strings.HasPrefix(lower, "c-"):
+			ctrl, name = true, name[len("c-"):]
+		case strings.HasPrefix(lower, "meta-"):
+			meta, name = true, name[len("meta-"):]
+		case strings.HasPrefix(lower, "m-"):
+			meta, name = true, name[len("m-"):]
+		default:
+			break prefixes
+		}
+	}
+	var c byte
+	switch strings.ToLower(name) {
+	case "rubout", "del":
+		c = KeyBackspace
+	case "escape", "esc":
+		c = KeyEscape
+	case "lfd", "newline":
+		c = '\n'
+	case "return", "ret":
+		c = KeyEnter
+	case "space", "spc":
+		c = ' '
+	case "tab":
+		c = '\t'
+	default:
+		if len(name) != 1 {
+			return nil
+		}
+		c = name[0]
+	}
+	if ctrl {
+		c = control(c)
+	}
+	if meta {
+		return []byte{KeyEscape, c}
+	}
+	return []byte{c}
+}
+
+// control returns the control character typed with Ctrl and c.
+func control(c byte) byte {
+	if c == '?' {
+		return KeyBackspace
+	}
+	return c & 0x1f
+}
+
+// unescapeKeySeq decodes the backslash escapes of a quoted key sequence.
+func unescapeKeySeq(s string) []byte {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b = append(b, s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'C', 'M':
+			if i+2 < len(s) && s[i+1] == '-' {
+				next := s[i+2]
+				i += 2
+				if next == '\\' && i+1 < len(s) && s[i+1] == 'e' {
+					next = KeyEscape
+					i++
+				}
+				if c == 'C' {
+					b = append(b, control(next))
+				} else {
+					b = append(b, KeyEscape, next)
+				}
+			} else {
+				b = append(b, c)
+			}
+		case 'e':
+			b = append(b, KeyEscape)
+		case 'a':
+			b = append(b, '\a')
+		case 'b':
+			b = append(b, '\b')
+		case 'd':
+			b = append(b, KeyBackspace)
+		case 'f':
+			b = append(b, '\f')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'v':
+			b = append(b, '\v')
+		case 'x':
+			j := i + 1
+			for j < len(s) && j < i+3 && strings.IndexByte("0123456789abcdefABCDEF", s[j]) >= 0 {
+				j++
+			}
+			if v, err := strconv.ParseUint(s[i+1:j], 16, 8); err == nil {
+				b = append(b, byte(v))
+				i = j - 1
+			} else {
+				b = append(b, c)
+			}
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i:j], 8, 8)
+			b = append(b, byte(v))
+			i = j - 1
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testInputrc = `# comment
+set bell-style visible
+set completion-ignore-case on
+"\C-b": backward-char
+Control-f: forward-char
+C-h: backward-delete-char
+"\C-x": "a macro"
+Control-t: transpose-chars
+$if mode=vi
+"\C-a": backward-char
+$else
+$if other
+"\C-e": backward-char
+$endif
+$endif
+$if test
+"\C-p": previous-history
+$endif
+`
+
+func TestLoadInputrc(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ac\x02b\x06d\x08\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	if err := ss.LoadInputrc(strings.NewReader(testInputrc), "test"); err != nil {
+		t.Fatal(err)
+	}
+	line, err := ss.ReadLine()
+	if err != nil || line != "abc" {
+		t.Errorf("got %q, %v, expected the bindings to edit the line to \"abc\"", line, err)
+	}
+
+	if v, ok := ss.InputrcVariable("completion-ignore-case"); !ok || v != "on" {
+		t.Errorf("got %q, %v for completion-ignore-case", v, ok)
+	}
+	if !ss.bellOnError || !ss.visualBell {
+		t.Error("bell-style visible wasn't applied")
+	}
+	want := map[int]int{2: KeyLeft, 6: KeyRight, 8: KeyBackspace, 16: KeyUp}
+	if len(ss.bindings) != len(want) {
+		t.Errorf("got bindings %v, expected %v", ss.bindings, want)
+	}
+	for k, v := range want {
+		if ss.bindings[k] != v {
+			t.Errorf("key %d is bound to %d, expected %d", k, ss.bindings[k], v)
+		}
+	}
+}
+
+func TestLoadUserInputrc(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "inputrc")
+	inc := filepath.Join(dir, "included")
+	os.WriteFile(name, []byte("$include "+inc+"\n"), 0o666)
+	os.WriteFile(inc, []byte("\"\\e[A\": next-history\n"), 0o666)
+	t.Setenv("INPUTRC", name)
+
+	ss := NewTerminal(&MockTerminal{}, "> ", true)
+	if err := ss.LoadUserInputrc(""); err != nil {
+		t.Fatal(err)
+	}
+	if ss.bindings[KeyUp] != KeyDown {
+		t.Errorf("got bindings %v, expected the included binding", ss.bindings)
+	}
+
+	t.Setenv("INPUTRC", filepath.Join(dir, "missing"))
+	if err := ss.LoadUserInputrc(""); err != nil {
+		t.Errorf("got %v for a missing file, expected nil", err)
+	}
+}
+
+func TestUnescapeKeySeq(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []byte
+	}{
+		{`\C-a`, []byte{1}},
+		{`\C-?`, []byte{127}},
+		{`\M-x`, []byte{27, 'x'}},
+		{`\e[A`, []byte{27, '[', 'A'}},
+		{`\t\r\n\d`, []byte{'\t', '\r', '\n', 127}},
+		{`\033\x1b`, []byte{27, 27}},
+		{`\"\\`, []byte{'"', '\\'}},
+	}
+	for _, test := range tests {
+		if got := unescapeKeySeq(test.in); !bytes.Equal(got, test.want) {
+			t.Errorf("unescapeKeySeq(%q) = %q, expected %q", test.in, got, test.want)
+		}
+	}
+}
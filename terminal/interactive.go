@@ -0,0 +1,169 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/pty"
+)
+
+var errReadingLine = errors.New("terminal: RunInteractive called while reading input")
+
+// RunInteractive runs cmd, such as $EDITOR or a pager, with the terminal
+// handed over to it, and waits for it to end. If the prompt and line are on
+// the screen, they're cleared, and painted again once cmd has ended, starting
+// at the cursor, which programs usually leave at the start of a row.
+//
+// On a local terminal from NewWithStdInOut, the terminal's original settings
+// are restored while cmd runs, and standard input, output and error that
+// aren't set are connected to it. Otherwise cmd runs on a pseudo-terminal of
+// the terminal's size, and its input and output are passed through.
+//
+// RunInteractive must not be called while a line is being read or Events is
+// in use, as the input is then read elsewhere.
+func (t *Terminal) RunInteractive(cmd *exec.Cmd) error {
+	t.lock.Lock()
+	if t.reading || t.events != nil {
+		t.lock.Unlock()
+		return errReadingLine
+	}
+	// The prompt, line and footer are cleared while cmd has the screen.
+	editing := t.editing()
+	t.queue(hideCursor)
+	if editing || len(t.footer) > 0 {
+		t.clearLines()
+	}
+	t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+	t.queue(showCursor)
+	if err := t.flush(); err != nil {
+		t.lock.Unlock()
+		return err
+	}
+	t.lock.Unlock()
+
+	var err error
+	if t.cooked != nil {
+		err = t.runLocal(cmd)
+	} else {
+		err = t.runOnPty(cmd)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.queue(hideCursor)
+	if editing {
+		t.repaint()
+	} else if len(t.footer) > 0 {
+		t.drawFooter(false)
+	}
+	t.queue(showCursor)
+	if ferr := t.flush(); ferr != nil && err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// runLocal runs cmd on the local terminal in its original settings.
+func (t *Terminal) runLocal(cmd *exec.Cmd) error {
+	if cmd.Stdin == nil {
+		cmd.Stdin = os.Stdin
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	if err := Restore(t.ttyFd, t.cooked); err != nil {
+		return err
+	}
+	err := cmd.Run()
+	if _, rerr := MakeRaw(t.ttyFd); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// runOnPty runs cmd on a pseudo-terminal, copying the terminal's input to it
+// and its output to the terminal until it ends.
+func (t *Terminal) runOnPty(cmd *exec.Cmd) error {
+	t.lock.Lock()
+	width, height := t.termWidth, t.termHeight
+	t.lock.Unlock()
+
+	f, err := pty.Start(cmd)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pty.SetSize(f, width, height)
+
+	output := make(chan struct{})
+	go func() {
+		defer close(output)
+		buf := make([]byte, 4096)
+		for {
+			n, err := f.Read(buf)
+			t.lock.Lock()
+			t.queue(buf[:n])
+			t.flush()
+			t.lock.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// The input is read in the background, and the read that's waiting
+	// when cmd ends is handed to the line editor, as for a query.
+	var ended, inputDone bool
+	ch := make(chan readResult, 1)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := t.c.Read(buf)
+
+			t.lock.Lock()
+			if ended {
+				if t.reading {
+					ch <- readResult{buf[:n], err}
+				} else {
+					t.inFlight = nil
+					t.addInput(buf[:n])
+					if err != nil {
+						t.readErr = err
+					}
+				}
+				t.lock.Unlock()
+				return
+			}
+			t.recordInput(buf[:n])
+			if err != nil {
+				t.readErr = err
+				inputDone = true
+			}
+			t.lock.Unlock()
+
+			f.Write(buf[:n])
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	<-output
+
+	t.lock.Lock()
+	ended = true
+	if !inputDone {
+		t.inFlight = ch
+	}
+	t.lock.Unlock()
+	return err
+}
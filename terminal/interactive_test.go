@@ -0,0 +1,58 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRunInteractive(t *testing.T) {
+	r, w := io.Pipe()
+	c := &MockTerminal{}
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, c}, "> ", true)
+	ss.SetSize(50, 10)
+
+	go w.Write([]byte("hi\r"))
+	err := ss.RunInteractive(exec.Command("sh", "-c", `stty size; read x; echo "got $x"`))
+	if err != nil {
+		if _, serr := exec.LookPath("sh"); serr != nil || strings.Contains(err.Error(), "not supported") {
+			t.Skipf("can't run a program on a pty: %v", err)
+		}
+		t.Fatal(err)
+	}
+	out := string(c.received)
+	if !strings.Contains(out, "10 50") {
+		t.Errorf("got %q, expected the program to see the terminal's size", out)
+	}
+	if !strings.Contains(out, "got hi") {
+		t.Errorf("got %q, expected the program's answer", out)
+	}
+
+	// What's typed after the program ended is for the line editor.
+	go w.Write([]byte("next\r"))
+	line, err := ss.ReadLine()
+	if err != nil || line != "next" {
+		t.Errorf("got %q, %v, expected the line typed afterwards", line, err)
+	}
+}
+
+func TestRunInteractiveWhileReading(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, &MockTerminal{}}, "> ", true)
+	ss.Events()
+	if err := ss.RunInteractive(exec.Command("true")); err != errReadingLine {
+		t.Errorf("got %v, expected errReadingLine", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+// An Interrupt is what happens when the user presses Ctrl-C while a line is
+// being read.
+type Interrupt int
+
+const (
+	// InterruptReturn ends reading with ErrInterrupted. This is the
+	// default.
+	InterruptReturn Interrupt = iota
+	// InterruptClear abandons the line, like shells do, and shows the
+	// prompt again on a new row with an empty line.
+	InterruptClear
+	// InterruptIgnore leaves the line as it is, e.g. after the handler
+	// passed the interrupt on to a child process with Process.Signal.
+	InterruptIgnore
+)
+
+// OnInterrupt sets a function that decides what happens each time the user
+// presses Ctrl-C while a line is being read. It's passed the state of the
+// line and called without the terminal locked. A nil f restores the default,
+// InterruptReturn.
+func (t *Terminal) OnInterrupt(f func(state LineState) Interrupt) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.onInterrupt = f
+}
+
+// interrupt returns what to do about Ctrl-C. t.lock is released while the
+// function set by OnInterrupt runs.
+func (t *Terminal) interrupt() Interrupt {
+	f := t.onInterrupt
+	if f == nil {
+		return InterruptReturn
+	}
+	state := t.lineState()
+	t.flush()
+	t.lock.Unlock()
+	defer t.lock.Lock()
+	return f(state)
+}
+
+// abandonLine marks the line as interrupted with ^C and starts a new one below
+// it.
+func (t *Terminal) abandonLine() {
+	t.moveCursorToPos(len(t.line))
+	if len(t.footer) > 0 {
+		t.clearToEndOfScreen()
+	}
+	t.queue([]byte("^C\r\n"))
+	if t.secret {
+		t.wipe()
+	}
+	t.line = t.line[:0]
+	t.pos = 0
+	t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+	t.rightPromptShown = false
+	t.historyIdx = len(t.history)
+	t.repaint()
+}
@@ -0,0 +1,39 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOnInterrupt(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("abc\x03de\x03f\x03g\r")}
+	ss := NewTerminal(c, "> ", true)
+	var seen []string
+	actions := []Interrupt{InterruptClear, InterruptIgnore, InterruptReturn}
+	ss.OnInterrupt(func(state LineState) Interrupt {
+		seen = append(seen, state.Line)
+		action := actions[0]
+		actions = actions[1:]
+		return action
+	})
+	if line, err := ss.ReadLine(); err != ErrInterrupted {
+		t.Fatalf("got %q, %v, expected ErrInterrupted", line, err)
+	}
+	if want := []string{"abc", "de", "def"}; len(seen) != 3 || seen[0] != want[0] || seen[1] != want[1] || seen[2] != want[2] {
+		t.Errorf("handler saw %q, expected %q", seen, want)
+	}
+	if want := "> abc^C\r\n> de"; !bytes.Contains(c.received, []byte(want)) {
+		t.Errorf("got %q, expected the line abandoned with %q", c.received, want)
+	}
+
+	// Without a handler, Ctrl-C ends reading.
+	ss.OnInterrupt(nil)
+	c.toSend = []byte("x\x03")
+	if _, err := ss.ReadLine(); err != ErrInterrupted {
+		t.Errorf("got %v, expected ErrInterrupted", err)
+	}
+}
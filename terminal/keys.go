@@ -0,0 +1,125 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "unicode/utf8"
+
+// DecodeKey decodes the key press at the start of b. It returns the key and
+// the length of its sequence, or -1 and 0 if b is empty or only holds the
+// beginning of a sequence. Characters beyond ASCII are returned as KeyRune
+// once all of their UTF-8 encoding is there, so compose and dead keys work
+// even if it's split across reads. Other bytes than escape sequences are
+// returned as they are. Escape sequences that aren't recognized are delimited
+// as described in ECMA-48, covering control sequences as well as OSC, DCS and
+// other control strings, and returned whole as KeyUnknown.
+func DecodeKey(b []byte) (key, n int) {
+	if len(b) == 0 {
+		return -1, 0
+	}
+
+	if b[0] >= utf8.RuneSelf {
+		// A character beyond ASCII may be split across reads.
+		if !utf8.FullRune(b) {
+			return -1, 0
+		}
+		if r, size := utf8.DecodeRune(b); r != utf8.RuneError || size > 1 {
+			return KeyRune, size
+		}
+	}
+	if b[0] != KeyEscape {
+		return int(b[0]), 1
+	}
+
+	if len(b) >= 2 && b[1] == KeyEnter {
+		return KeyAltEnter, 2
+	}
+
+	if len(b) >= 3 && b[1] == '[' {
+		switch b[2] {
+		case 'A':
+			return KeyUp, 3
+		case 'B':
+			return KeyDown, 3
+		case 'C':
+			return KeyRight, 3
+		case 'D':
+			return KeyLeft, 3
+		}
+	}
+
+	if len(b) >= 6 && b[1] == '[' && b[2] == '1' && b[3] == ';' && b[4] == '3' {
+		switch b[5] {
+		case 'C':
+			return KeyAltRight, 6
+		case 'D':
+			return KeyAltLeft, 6
+		}
+	}
+
+	// If we get here then we have a key that we don't recognise, or a
+	// partial sequence.
+	if n := escapeLength(b); n > 0 {
+		return KeyUnknown, n
+	}
+	return -1, 0
+}
+
+// KeyDecoder turns input read from a terminal into key presses. Input may be
+// written to it in pieces of any size: the beginning of a sequence split
+// across reads is kept until the rest of it arrives.
+type KeyDecoder struct {
+	buf []byte
+	// off is the offset of the input not yet decoded in buf.
+	off int
+}
+
+// Write adds input to be decoded. It never returns an error.
+func (d *KeyDecoder) Write(b []byte) (n int, err error) {
+	if d.off > 0 {
+		d.buf = d.buf[:copy(d.buf, d.buf[d.off:])]
+		d.off = 0
+	}
+	d.buf = append(d.buf, b...)
+	return len(b), nil
+}
+
+// Next returns the next key and the bytes it was decoded from, which remain
+// valid until the next call to Write. ok is false when all input has been
+// decoded, or what's left is an incomplete sequence.
+func (d *KeyDecoder) Next() (key int, seq []byte, ok bool) {
+	key, n := DecodeKey(d.buf[d.off:])
+	if key < 0 {
+		return -1, nil, false
+	}
+	seq = d.buf[d.off : d.off+n]
+	d.off += n
+	return key, seq, true
+}
+
+// Pending returns the input that hasn't been decoded yet.
+func (d *KeyDecoder) Pending() []byte {
+	return d.buf[d.off:]
+}
+
+// Flush decodes an incomplete sequence left over by Next, for when no more
+// input followed within a short time. A lone escape is the Escape key and
+// anything else is returned as KeyUnknown. ok is false if there is no such
+// sequence.
+func (d *KeyDecoder) Flush() (key int, seq []byte, ok bool) {
+	seq = d.buf[d.off:]
+	if len(seq) == 0 {
+		return -1, nil, false
+	}
+	d.buf, d.off = d.buf[:0], 0
+	if len(seq) == 1 && seq[0] == KeyEscape {
+		return KeyEscape, seq, true
+	}
+	return KeyUnknown, seq, true
+}
+
+// Reset discards any input that hasn't been decoded.
+func (d *KeyDecoder) Reset() {
+	d.buf, d.off = d.buf[:0], 0
+}
@@ -0,0 +1,71 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+var decodeKeyTests = []struct {
+	in  string
+	key int
+	n   int
+}{
+	{"", -1, 0},
+	{"a", 'a', 1},
+	{"\x1b[A", KeyUp, 3},
+	{"\x1b[1;3Dx", KeyAltLeft, 6},
+	{"\x1b\r", KeyAltEnter, 2},
+	{"\x1b[", -1, 0},
+	{"\x1b[15~", KeyUnknown, 5},
+	{"\x1b]11;rgb:0/0/0\x1b\\a", KeyUnknown, 16},
+	{"\x1bP1$r0m\x1b\\", KeyUnknown, 9},
+	{"\x1bPabc", -1, 0},
+	{"é!", KeyRune, 2},
+	{"\xe2\x82", -1, 0},
+	{"\xe2\x82\xac", KeyRune, 3},
+	{"\xff", 0xff, 1},
+}
+
+func TestDecodeKey(t *testing.T) {
+	for i, test := range decodeKeyTests {
+		key, n := DecodeKey([]byte(test.in))
+		if key != test.key || n != test.n {
+			t.Errorf("test %d: got %d, %d, expected %d, %d", i, key, n, test.key, test.n)
+		}
+	}
+}
+
+func TestKeyDecoder(t *testing.T) {
+	var d KeyDecoder
+	var keys []int
+	for _, c := range []byte("a\x1b[B\x1b]0;x\x07b\x1b") {
+		d.Write([]byte{c})
+		for {
+			key, _, ok := d.Next()
+			if !ok {
+				break
+			}
+			keys = append(keys, key)
+		}
+	}
+	want := []int{'a', KeyDown, KeyUnknown, 'b'}
+	if len(keys) != len(want) {
+		t.Fatalf("got %v, expected %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("key %d: got %d, expected %d", i, keys[i], want[i])
+		}
+	}
+
+	if p := string(d.Pending()); p != "\x1b" {
+		t.Errorf("got pending %q, expected an escape", p)
+	}
+	if key, seq, ok := d.Flush(); key != KeyEscape || string(seq) != "\x1b" || !ok {
+		t.Errorf("got %d, %q, %v from Flush, expected the Escape key", key, seq, ok)
+	}
+	if _, _, ok := d.Flush(); ok {
+		t.Errorf("second Flush returned a key")
+	}
+}
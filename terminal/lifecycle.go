@@ -0,0 +1,48 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+// OnBeforeRead sets a function that ReadLine and ReadLineWithDefault call
+// before the prompt is drawn, e.g. to update a prompt showing the time or the
+// state of a git checkout with SetPrompt, or to start a timer. It's called
+// without the terminal locked. A nil f removes the function.
+func (t *Terminal) OnBeforeRead(f func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.onBeforeRead = f
+}
+
+// OnAfterRead sets a function that ReadLine and ReadLineWithDefault call with
+// each line the user enters, before returning it, e.g. to stop a timer or to
+// save the line to a history service. It isn't called when reading fails or
+// is interrupted, nor for passwords. It's called without the terminal
+// locked. A nil f removes the function.
+func (t *Terminal) OnAfterRead(f func(line string)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.onAfterRead = f
+}
+
+// beforeRead calls the function set by OnBeforeRead. t.lock is released while
+// it runs.
+func (t *Terminal) beforeRead() {
+	if f := t.onBeforeRead; f != nil {
+		t.lock.Unlock()
+		defer t.lock.Lock()
+		f()
+	}
+}
+
+// afterRead calls the function set by OnAfterRead if a line was read. t.lock
+// is released while it runs.
+func (t *Terminal) afterRead(line string, err error) {
+	if f := t.onAfterRead; f != nil && err == nil {
+		t.lock.Unlock()
+		defer t.lock.Lock()
+		f(line)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReadHooks(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("one\rtwo\r")}
+	ss := NewTerminal(c, "> ", true)
+	var events []string
+	n := 0
+	ss.OnBeforeRead(func() {
+		n++
+		ss.SetPrompt(string(rune('0'+n)) + "> ")
+		events = append(events, "before")
+	})
+	ss.OnAfterRead(func(line string) {
+		events = append(events, "after "+line)
+	})
+	for _, want := range []string{"one", "two"} {
+		if line, err := ss.ReadLine(); err != nil || line != want {
+			t.Fatalf("got %q, %v, expected %q", line, err, want)
+		}
+	}
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Fatalf("got %v, expected EOF", err)
+	}
+	if want := "1> one\r\n2> two\r\n3> "; !bytes.HasPrefix(c.received, []byte(want)) {
+		t.Errorf("got %q, expected prompts updated before each line", c.received)
+	}
+	want := []string{"before", "after one", "before", "after two", "before"}
+	if len(events) != len(want) {
+		t.Fatalf("got %q, expected %q", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("got %q, expected %q", events, want)
+			break
+		}
+	}
+}
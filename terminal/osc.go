@@ -0,0 +1,220 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// appendOSC appends an operating system command made up of params to b. It's
+// terminated with ST. Control characters are dropped from the parameters so
+// that they can't end the sequence early.
+func appendOSC(b []byte, params ...string) []byte {
+	b = append(b, KeyEscape, ']')
+	for i, param := range params {
+		if i > 0 {
+			b = append(b, ';')
+		}
+		for j := 0; j < len(param); j++ {
+			if c := param[j]; c >= 0x20 && c != 0x7f {
+				b = append(b, c)
+			}
+		}
+	}
+	return append(b, KeyEscape, '\\')
+}
+
+// sendOSC writes an operating system command to the terminal.
+func (t *Terminal) sendOSC(params ...string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.outBuf = appendOSC(t.outBuf, params...)
+	return t.flush()
+}
+
+// SetTitle sets the title of the terminal window or tab, e.g. to show the
+// command that is running.
+func (t *Terminal) SetTitle(title string) error {
+	return t.sendOSC("0", title)
+}
+
+var (
+	pushTitle = []byte{KeyEscape, '[', '2', '2', ';', '0', 't'}
+	popTitle  = []byte{KeyEscape, '[', '2', '3', ';', '0', 't'}
+)
+
+// PushTitle saves the current title on the terminal's title stack, so that it
+// can be restored with PopTitle after changing it with SetTitle. Terminals
+// without a title stack ignore it.
+func (t *Terminal) PushTitle() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(pushTitle)
+	return t.flush()
+}
+
+// PopTitle restores the title saved by the last call to PushTitle.
+func (t *Terminal) PopTitle() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(popTitle)
+	return t.flush()
+}
+
+// CopyToClipboard puts data on the clipboard of the computer the terminal is
+// running on, which may not be the one this program runs on, e.g. in an SSH
+// session. Terminals may ignore it or limit the size of data.
+func (t *Terminal) CopyToClipboard(data []byte) error {
+	return t.sendOSC("52", "c", base64.StdEncoding.EncodeToString(data))
+}
+
+// RequestClipboard asks the terminal for the contents of the clipboard. If it
+// answers, which many terminals only do if the user allowed it, the text is
+// inserted into the line being read at the cursor, as if it had been pasted.
+func (t *Terminal) RequestClipboard() error {
+	return t.sendOSC("52", "c", "?")
+}
+
+// handleReply acts upon a reply from the terminal at the start of b, either
+// to a pending query or an operating system command, and returns its length.
+// If b doesn't start with a complete one, it returns 0.
+func (t *Terminal) handleReply(b []byte) int {
+	if len(b) < 2 || b[0] != KeyEscape {
+		return 0
+	}
+	n := escapeLength(b)
+	if n < 0 {
+		return 0
+	}
+	if t.answer(b[:n]) {
+		return n
+	}
+	if b[1] != ']' {
+		return 0
+	}
+
+	payload := b[2 : n-1]
+	if b[n-1] == '\\' {
+		payload = b[2 : n-2]
+	}
+	if data, ok := bytes.CutPrefix(payload, []byte("52;")); ok {
+		if i := bytes.IndexByte(data, ';'); i >= 0 {
+			t.paste(data[i+1:])
+		}
+	}
+	return n
+}
+
+// paste inserts base64 encoded clipboard contents at the cursor.
+func (t *Terminal) paste(encoded []byte) {
+	text := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, err := base64.StdEncoding.Decode(text, encoded)
+	if err != nil {
+		return
+	}
+	text = bytes.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text[:n])
+	t.insert(text)
+}
+
+// Notify shows a desktop notification, e.g. when a long-running command has
+// finished. It uses OSC 777, which is understood by WezTerm, kitty, foot and
+// urxvt among others, or OSC 9 in iTerm2, which has no separate title.
+func (t *Terminal) Notify(title, body string) error {
+	if t.notifyOSC9 {
+		if title != "" {
+			body = title + ": " + body
+		}
+		return t.sendOSC("9", body)
+	}
+	// Semicolons would end the title early.
+	return t.sendOSC("777", "notify", strings.ReplaceAll(title, ";", ","), body)
+}
+
+// SetProgress reports the progress of a long operation, in percent, to the
+// terminal, which shows it in the tab or the taskbar. It uses the sequence
+// introduced by ConEmu and supported by Windows Terminal among others.
+func (t *Terminal) SetProgress(percent int) error {
+	percent = max(0, min(percent, 100))
+	return t.sendOSC("9", "4", "1", strconv.Itoa(percent))
+}
+
+// ClearProgress removes the progress shown by SetProgress.
+func (t *Terminal) ClearProgress() error {
+	return t.sendOSC("9", "4", "0")
+}
+
+// BackgroundColor asks the terminal for its background color with OSC 11 and
+// waits at most timeout for the answer. Terminals that don't support the
+// query don't answer at all, in which case ErrNoReply is returned.
+func (t *Terminal) BackgroundColor(timeout time.Duration) (Color, error) {
+	reply, err := t.query(appendOSC(nil, "11", "?"), func(seq []byte) bool {
+		return bytes.HasPrefix(seq, []byte("\x1b]11;"))
+	}, timeout)
+	if err != nil {
+		return Color{}, err
+	}
+	return parseColorReply(reply)
+}
+
+// errBadColor is returned for color replies that can't be parsed.
+var errBadColor = errors.New("terminal: malformed color in reply")
+
+// parseColorReply parses the color in an OSC color reply, in the XParseColor
+// format rgb:RRRR/GGGG/BBBB with one to four hex digits per channel.
+func parseColorReply(reply []byte) (Color, error) {
+	reply = bytes.TrimSuffix(bytes.TrimSuffix(reply, []byte{7}), []byte("\x1b\\"))
+	_, spec, _ := bytes.Cut(reply, []byte(";"))
+	spec, ok := bytes.CutPrefix(spec, []byte("rgb:"))
+	if !ok {
+		return Color{}, errBadColor
+	}
+	var rgb [3]uint8
+	channels := bytes.Split(spec, []byte("/"))
+	if len(channels) != 3 {
+		return Color{}, errBadColor
+	}
+	for i, c := range channels {
+		if len(c) == 0 || len(c) > 4 {
+			return Color{}, errBadColor
+		}
+		v, err := strconv.ParseUint(string(c), 16, 16)
+		if err != nil {
+			return Color{}, errBadColor
+		}
+		// Scale to 8 bits.
+		maxValue := uint64(1)<<(4*len(c)) - 1
+		rgb[i] = uint8((v*255 + maxValue/2) / maxValue)
+	}
+	return RGB(rgb[0], rgb[1], rgb[2]), nil
+}
+
+// backgroundQueryTimeout is how long IsDarkBackground waits for an answer.
+const backgroundQueryTimeout = 200 * time.Millisecond
+
+// IsDarkBackground reports whether the terminal's background is dark, so
+// that a suitable color theme can be picked. If the terminal doesn't tell its
+// background color, it's assumed to be dark, as is most common.
+func (t *Terminal) IsDarkBackground() bool {
+	c, err := t.BackgroundColor(backgroundQueryTimeout)
+	if err != nil {
+		return true
+	}
+	r, g, b := c.rgb()
+	// Relative luminance as defined by ITU-R BT.709.
+	return 2126*int(r)+7152*int(g)+722*int(b) < 10000*128
+}
@@ -0,0 +1,140 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSetTitle(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.PushTitle()
+	ss.SetTitle("vim\x07 main.go")
+	ss.PopTitle()
+	if want := "\x1b[22;0t\x1b]0;vim main.go\x1b\\\x1b[23;0t"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestClipboard(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.CopyToClipboard([]byte("ls -l"))
+	ss.RequestClipboard()
+	if want := "\x1b]52;c;bHMgLWw=\x1b\\\x1b]52;c;?\x1b\\"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	// The reply is inserted as if it had been pasted.
+	c.toSend = []byte("a\x1b]52;c;bHMgLWwK\x07b\r")
+	line, err := ss.ReadLine()
+	if want := "als -lb"; line != want || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, want)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.notifyOSC9 = false
+	ss.Notify("make; test", "done")
+	ss.notifyOSC9 = true
+	ss.Notify("make", "done")
+	if want := "\x1b]777;notify;make, test;done\x1b\\\x1b]9;make: done\x1b\\"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetProgress(42)
+	ss.SetProgress(150)
+	ss.ClearProgress()
+	if want := "\x1b]9;4;1;42\x1b\\\x1b]9;4;1;100\x1b\\\x1b]9;4;0\x1b\\"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestBackgroundColor(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\x1b]11;rgb:ffff/ffff/ffff\x1b\\cd\r")}
+	ss := NewTerminal(c, "> ", true)
+	color, err := ss.BackgroundColor(time.Second)
+	if color != RGB(255, 255, 255) || err != nil {
+		t.Errorf("got %+v, %v, expected white", color, err)
+	}
+	if want := "\x1b]11;?\x1b\\"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	// Keys typed before the reply aren't lost.
+	if line, err := ss.ReadLine(); line != "abcd" || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, "abcd")
+	}
+}
+
+func TestBackgroundColorNoReply(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("x")}
+	ss := NewTerminal(c, "> ", true)
+	if _, err := ss.BackgroundColor(10 * time.Millisecond); err != ErrNoReply {
+		t.Errorf("got error %v, expected %v", err, ErrNoReply)
+	}
+}
+
+func TestBackgroundColorWhileReading(t *testing.T) {
+	r, w := io.Pipe()
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, io.Discard}, "> ", true)
+
+	lines := make(chan string)
+	go func() {
+		line, _ := ss.ReadLine()
+		lines <- line
+	}()
+	go func() {
+		for {
+			ss.lock.Lock()
+			pending := ss.pendingQuery != nil
+			ss.lock.Unlock()
+			if pending {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		w.Write([]byte("a\x1b]11;rgb:1010/1010/1010\x07b\r"))
+	}()
+
+	color, err := ss.BackgroundColor(5 * time.Second)
+	if color != RGB(16, 16, 16) || err != nil {
+		t.Errorf("got %+v, %v, expected RGB(16, 16, 16)", color, err)
+	}
+	if line := <-lines; line != "ab" {
+		t.Errorf("got line %q, expected %q", line, "ab")
+	}
+}
+
+var colorReplyTests = []struct {
+	in  string
+	out Color
+	ok  bool
+}{
+	{"\x1b]11;rgb:ffff/0000/8080\x1b\\", RGB(255, 0, 128), true},
+	{"\x1b]11;rgb:f/0/80\x07", RGB(255, 0, 128), true},
+	{"\x1b]11;rgb:ff/00\x07", Color{}, false},
+	{"\x1b]11;#ffffff\x07", Color{}, false},
+}
+
+func TestParseColorReply(t *testing.T) {
+	for i, test := range colorReplyTests {
+		out, err := parseColorReply([]byte(test.in))
+		if out != test.out || (err == nil) != test.ok {
+			t.Errorf("test %d: got %+v, %v, expected %+v", i, out, err, test.out)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "fmt"
+
+// SetTranslateNewlines sets whether Write turns line feeds not preceded by a
+// carriage return into "\r\n". A terminal in raw mode moves the cursor down
+// but not back to the first column on a line feed, so output written for a
+// cooked terminal comes out stair-stepped.
+func (t *Terminal) SetTranslateNewlines(translate bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.onlcr = translate
+}
+
+// Printf formats according to a format specifier and writes the result to the
+// terminal, like Write. Line feeds are turned into "\r\n", as the terminal
+// doesn't do so in raw mode. Arguments that are StyledSpans are rendered with
+// the terminal's color profile first.
+func (t *Terminal) Printf(format string, args ...interface{}) (n int, err error) {
+	return t.Write(crlf([]byte(fmt.Sprintf(format, t.renderArgs(args)...))))
+}
+
+// Println is like Printf, but formats its arguments like fmt.Println.
+func (t *Terminal) Println(args ...interface{}) (n int, err error) {
+	return t.Write(crlf([]byte(fmt.Sprintln(t.renderArgs(args)...))))
+}
+
+// renderArgs returns args with any StyledSpans replaced by their rendered
+// text.
+func (t *Terminal) renderArgs(args []interface{}) []interface{} {
+	t.lock.Lock()
+	p := t.colorProfile
+	t.lock.Unlock()
+
+	var out []interface{}
+	for i, arg := range args {
+		var text string
+		switch arg := arg.(type) {
+		case StyledSpan:
+			text = arg.Style.Render(p, arg.Text)
+		case *StyledSpan:
+			text = arg.Style.Render(p, arg.Text)
+		default:
+			continue
+		}
+		if out == nil {
+			out = append([]interface{}(nil), args...)
+		}
+		out[i] = text
+	}
+	if out == nil {
+		return args
+	}
+	return out
+}
+
+// crlf returns b with every line feed not already preceded by a carriage
+// return turned into "\r\n".
+func crlf(b []byte) []byte {
+	var out []byte
+	last := 0
+	for i, c := range b {
+		if c != '\n' || i > 0 && b[i-1] == '\r' {
+			continue
+		}
+		if out == nil {
+			out = make([]byte, 0, len(b)+8)
+		}
+		out = append(out, b[last:i]...)
+		out = append(out, '\r', '\n')
+		last = i + 1
+	}
+	if out == nil {
+		return b
+	}
+	return append(out, b[last:]...)
+}
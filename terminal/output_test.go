@@ -0,0 +1,55 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+var crlfTests = []struct {
+	in, out string
+}{
+	{"", ""},
+	{"abc", "abc"},
+	{"a\nb\n", "a\r\nb\r\n"},
+	{"a\r\nb", "a\r\nb"},
+	{"\n\n", "\r\n\r\n"},
+}
+
+func TestCRLF(t *testing.T) {
+	for i, test := range crlfTests {
+		if out := string(crlf([]byte(test.in))); out != test.out {
+			t.Errorf("test %d: got %q, expected %q", i, out, test.out)
+		}
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetColorProfile(ANSI)
+	ss.Printf("%d %s\n", 42, StyledSpan{"ok", Style{Bold: true}})
+	if got, want := string(c.received), "42 \x1b[1mok\x1b[0m\r\n"; got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+
+	c.received = nil
+	ss.Println("a", "b")
+	if got, want := string(c.received), "a b\r\n"; got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
+
+func TestTranslateNewlines(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.Write([]byte("a\nb\n"))
+	ss.SetTranslateNewlines(true)
+	n, err := ss.Write([]byte("c\nd\r\n"))
+	if n != 5 || err != nil {
+		t.Errorf("got %d, %v, expected 5, nil", n, err)
+	}
+	if got, want := string(c.received), "a\nb\nc\r\nd\r\n"; got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrPassthrough is returned by ReadLine and the other methods that read input
+// for the line editor in passthrough mode.
+var ErrPassthrough = errors.New("terminal: line editing is stopped for passthrough")
+
+// ErrEscapeKey is returned by ReadLine and Read when the user presses the key
+// set by SetEscapeKey.
+var ErrEscapeKey = errors.New("terminal: escape key pressed")
+
+// Read reads input from the terminal as it is, bypassing the line editor, so
+// that a Terminal can be used wherever an io.ReadWriter is expected, e.g. to
+// copy the input to a child program's pseudo-terminal with io.Copy. Input that
+// has been received but not processed yet comes first. Replies to queries,
+// like CursorPosition, are still taken out. Read returns ErrConcurrentRead
+// while a line is being read or Events is in use. It's typically used in
+// passthrough mode, see EnterPassthrough.
+func (t *Terminal) Read(b []byte) (n int, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.reading {
+		return 0, ErrConcurrentRead
+	}
+	// Unlike a line, raw input is read in passthrough mode, and output
+	// is written as it is meanwhile, as there's no prompt to move out of
+	// the way.
+	t.reading, t.readingRaw = true, true
+	defer func() {
+		t.readingRaw = false
+		t.doneReading()
+	}()
+
+	for len(t.remainder) == 0 {
+		if err := t.fill(); err != nil {
+			return 0, err
+		}
+		if t.pendingQuery != nil {
+			t.takeReply()
+		}
+	}
+	in := t.remainder
+	if i := bytes.IndexByte(in, t.escapeKey); t.escapeKey != 0 && i >= 0 {
+		if i == 0 {
+			t.saveRemainder(in[1:])
+			return 0, ErrEscapeKey
+		}
+		// The input before the escape key is returned first.
+		in = in[:i]
+	}
+	n = copy(b, in)
+	t.saveRemainder(t.remainder[n:])
+	return n, nil
+}
+
+// SetEscapeKey sets a key that switches between line editing and passthrough
+// mode, like Ctrl-] does in telnet. It must be a control character; 0 turns
+// the escape key off, which is the default. When it's pressed, ReadLine and
+// Read return ErrEscapeKey, upon which the application calls EnterPassthrough
+// or ExitPassthrough and goes on forwarding input to its child session with
+// Read or reading lines with ReadLine. A line being edited is left as it is,
+// and editing it continues once line editing resumes:
+//
+//	t.SetEscapeKey(0x1d) // Ctrl-]
+//	for {
+//		line, err := t.ReadLine()
+//		if err == terminal.ErrEscapeKey {
+//			t.EnterPassthrough()
+//			io.Copy(child, t) // until Read returns ErrEscapeKey
+//			t.ExitPassthrough()
+//			continue
+//		}
+//		...
+//	}
+func (t *Terminal) SetEscapeKey(key byte) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.escapeKey = key
+}
+
+// EnterPassthrough stops line editing, so that the application can shuttle
+// raw bytes between the terminal and, e.g., an embedded child program that
+// owns the session for a while, using Read and Write. The prompt, the line
+// being edited and the footer are cleared from the screen, and Write passes
+// output through as it is rather than moving them out of the way or
+// translating line feeds. ExitPassthrough resumes line editing where it left
+// off. It returns ErrConcurrentRead if a line is being read.
+func (t *Terminal) EnterPassthrough() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.passthrough {
+		return nil
+	}
+	if t.reading {
+		return ErrConcurrentRead
+	}
+	t.passthrough = true
+	t.passthroughEditing = t.editing()
+	t.queue(hideCursor)
+	if t.passthroughEditing || len(t.footer) > 0 {
+		t.clearLines()
+	}
+	t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// ExitPassthrough ends passthrough mode. The prompt and line that were being
+// edited, if any, and the footer are painted again, starting at the cursor,
+// which the child program is expected to have left at the start of a row.
+func (t *Terminal) ExitPassthrough() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.passthrough {
+		return nil
+	}
+	t.passthrough = false
+	t.queue(hideCursor)
+	if t.passthroughEditing {
+		t.repaint()
+	} else if len(t.footer) > 0 {
+		t.drawFooter(false)
+	}
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// InPassthrough reports whether the terminal is in passthrough mode.
+func (t *Terminal) InPassthrough() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.passthrough
+}
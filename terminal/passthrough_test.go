@@ -0,0 +1,98 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ls\r\x1b[Avi\x7f\x03")}
+	ss := NewTerminal(c, "> ", true)
+	if line, err := ss.ReadLine(); err != nil || line != "ls" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	c.received = nil
+
+	// What ReadLine didn't process and what follows is passed through
+	// untouched.
+	var in bytes.Buffer
+	if _, err := io.Copy(&in, ss); err != nil {
+		t.Fatal(err)
+	}
+	if got := in.String(); got != "\x1b[Avi\x7f\x03" {
+		t.Errorf("got %q", got)
+	}
+	if len(c.received) > 0 {
+		t.Errorf("got %q written while reading", c.received)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetTranslateNewlines(true)
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Fatalf("got %v, expected EOF", err)
+	}
+
+	c.received = nil
+	if err := ss.EnterPassthrough(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(c.received, []byte("\r\x1b[K")) {
+		t.Errorf("got %q, expected the line cleared", c.received)
+	}
+	c.received = nil
+	ss.Write([]byte("child\n"))
+	if got := string(c.received); got != "child\n" {
+		t.Errorf("got %q written in passthrough mode", got)
+	}
+	if _, err := ss.ReadLine(); err != ErrPassthrough {
+		t.Errorf("got %v, expected ErrPassthrough", err)
+	}
+	c.toSend = []byte("\x1b[A")
+	buf := make([]byte, 10)
+	if n, err := ss.Read(buf); err != nil || string(buf[:n]) != "\x1b[A" {
+		t.Errorf("got %q, %v", buf[:n], err)
+	}
+
+	c.received = nil
+	if err := ss.ExitPassthrough(); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(c.received); !bytes.Contains(c.received, []byte("> ab")) {
+		t.Errorf("got %q, expected the line painted again", got)
+	}
+	c.toSend = []byte("c\r")
+	if line, err := ss.ReadLine(); err != nil || line != "abc" {
+		t.Errorf("got %q, %v, expected editing to continue", line, err)
+	}
+}
+
+func TestEscapeKey(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\x1dls\r\x1dc\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetEscapeKey(0x1d)
+	if _, err := ss.ReadLine(); err != ErrEscapeKey {
+		t.Fatalf("got %v, expected ErrEscapeKey", err)
+	}
+
+	ss.EnterPassthrough()
+	var in bytes.Buffer
+	if _, err := io.Copy(&in, ss); err != ErrEscapeKey {
+		t.Fatalf("got %v, expected ErrEscapeKey", err)
+	}
+	if got := in.String(); got != "ls\r" {
+		t.Errorf("got %q passed through", got)
+	}
+	ss.ExitPassthrough()
+
+	if line, err := ss.ReadLine(); err != nil || line != "abc" {
+		t.Errorf("got %q, %v", line, err)
+	}
+}
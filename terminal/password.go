@@ -0,0 +1,124 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultPasswordMask is echoed for each character typed by
+// ReadPasswordMasked unless SetPasswordMask is called.
+var defaultPasswordMask = []byte{'*'}
+
+// ErrPasswordMismatch is returned by ReadNewPassword if the user fails to enter
+// the same password twice.
+var ErrPasswordMismatch = errors.New("terminal: passwords do not match")
+
+// newPasswordAttempts is the number of times ReadNewPassword asks for a
+// password before giving up.
+const newPasswordAttempts = 3
+
+// ReadNewPassword reads a new password, e.g. when setting up credentials. It
+// prompts for the password with prompt and then again with confirmPrompt, and
+// starts over if the two don't match or the password is rejected by the
+// PasswordPolicy callback. After three failed attempts it returns
+// ErrPasswordMismatch, or the error from PasswordPolicy.
+func (t *Terminal) ReadNewPassword(prompt, confirmPrompt string) (string, error) {
+	var err error
+	for attempt := 0; attempt < newPasswordAttempts; attempt++ {
+		var password, confirmation string
+		if password, err = t.ReadPassword(prompt); err != nil {
+			return "", err
+		}
+		if t.PasswordPolicy != nil {
+			if err = t.PasswordPolicy(password); err != nil {
+				t.Write([]byte(err.Error() + "\r\n"))
+				continue
+			}
+		}
+		if confirmation, err = t.ReadPassword(confirmPrompt); err != nil {
+			return "", err
+		}
+		if password == confirmation {
+			return password, nil
+		}
+		err = ErrPasswordMismatch
+		t.Write([]byte("Passwords do not match.\r\n"))
+	}
+	return "", err
+}
+
+// SetPasswordMask sets the rune ReadPasswordMasked echoes for each character
+// typed. It should take up a single column.
+func (t *Terminal) SetPasswordMask(mask rune) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.passwordMask = utf8.AppendRune(nil, mask)
+}
+
+// SetPasswordReveal makes ReadPasswordMasked show the character that was
+// typed last for d before masking it, as many phones do, so that typos are
+// easier to spot. A d of zero, the default, masks characters right away.
+func (t *Terminal) SetPasswordReveal(d time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.revealDuration = d
+}
+
+// reveal shows the character before the cursor in place of its mask, if
+// revealing is enabled, and arranges for it to be masked again later.
+func (t *Terminal) reveal() {
+	if t.mask == nil || t.revealDuration <= 0 || t.pos == 0 {
+		return
+	}
+	r, size := utf8.DecodeLastRune(t.line[:t.pos])
+	if runeWidth(r) != bytesWidth(t.mask) {
+		// Showing it would shift the rest of the line.
+		return
+	}
+
+	pos := t.pos - size
+	t.queue(hideCursor)
+	t.moveCursorToPos(pos)
+	t.writeText(t.line[pos:t.pos])
+	t.moveCursorToPos(t.pos)
+	t.queue(showCursor)
+	t.revealPos = pos
+
+	t.revealGen++
+	gen := t.revealGen
+	time.AfterFunc(t.revealDuration, func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		if t.revealGen == gen && t.revealPos >= 0 {
+			t.conceal()
+			t.flush()
+		}
+	})
+}
+
+// conceal masks the character shown by reveal again.
+func (t *Terminal) conceal() {
+	if t.revealPos < 0 {
+		return
+	}
+	pos := t.revealPos
+	t.revealPos = -1
+	t.revealGen++
+	if t.mask == nil || pos >= len(t.line) {
+		return
+	}
+
+	t.queue(hideCursor)
+	t.moveCursorToPos(pos)
+	t.writeText(t.mask)
+	t.moveCursorToPos(t.pos)
+	t.queue(showCursor)
+}
@@ -0,0 +1,196 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPasswordMaskRune(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("abc\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetPasswordMask('•')
+	line, err := ss.ReadPasswordMasked("Password: ")
+	if line != "abc" || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, "abc")
+	}
+	if want := "Password: •••\r\n"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestPasswordReveal(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetPasswordReveal(time.Hour)
+	line, err := ss.ReadPasswordMasked("Password: ")
+	if line != "ab" || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, "ab")
+	}
+	// The b is shown until Enter is pressed.
+	if want := "Password: **\x1b[?25l\x1b[Db\x1b[?25h\x1b[?25l\x1b[D*\x1b[?25h\r\n"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestPasswordRevealPerKey(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\r"), bytesPerRead: 1}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetPasswordReveal(time.Hour)
+	line, err := ss.ReadPasswordMasked("Password: ")
+	if line != "ab" || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, "ab")
+	}
+	// Each character is shown until the next one is typed.
+	want := "Password: *\x1b[?25l\x1b[Da\x1b[?25h" +
+		"\x1b[?25l\x1b[D*\x1b[?25h*\x1b[?25l\x1b[Db\x1b[?25h" +
+		"\x1b[?25l\x1b[D*\x1b[?25h\r\n"
+	if string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestPasswordRevealTimer(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "", true)
+	ss.SetPasswordReveal(time.Millisecond)
+
+	ss.lock.Lock()
+	ss.mask = ss.passwordMask
+	ss.insert([]byte("x"))
+	ss.flush()
+	ss.lock.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ss.lock.Lock()
+		pos := ss.revealPos
+		ss.lock.Unlock()
+		if pos < 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("character not masked again")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+	if out := string(c.received); !strings.Contains(out, "x") || !strings.HasSuffix(out, "*\x1b[?25h") {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestReadNewPassword(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("a\rb\rsecret\rsecret\r")}
+	ss := NewTerminal(c, "> ", true)
+	password, err := ss.ReadNewPassword("New: ", "Again: ")
+	if password != "secret" || err != nil {
+		t.Errorf("got %q, %v, expected %q", password, err, "secret")
+	}
+	if !strings.Contains(string(c.received), "Passwords do not match.") {
+		t.Errorf("mismatch not reported: %q", c.received)
+	}
+}
+
+func TestReadNewPasswordPolicy(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("a\rb\rc\r")}
+	ss := NewTerminal(c, "> ", true)
+	tooShort := errors.New("too short")
+	ss.PasswordPolicy = func(string) error { return tooShort }
+	if _, err := ss.ReadNewPassword("New: ", "Again: "); err != tooShort {
+		t.Errorf("got error %v, expected %v", err, tooShort)
+	}
+	if n := strings.Count(string(c.received), "too short"); n != 3 {
+		t.Errorf("policy error shown %d times, expected 3: %q", n, c.received)
+	}
+}
+
+func TestReadNewPasswordMismatch(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("a\rb\ra\rb\ra\rb\r")}
+	ss := NewTerminal(c, "> ", true)
+	if _, err := ss.ReadNewPassword("New: ", "Again: "); err != ErrPasswordMismatch {
+		t.Errorf("got error %v, expected %v", err, ErrPasswordMismatch)
+	}
+}
+
+func TestReadPasswordBytes(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("hunter2\rnext")}
+	ss := NewTerminal(c, "> ", true)
+	password, err := ss.ReadPasswordBytes("Password: ")
+	if string(password) != "hunter2" || err != nil {
+		t.Errorf("got %q, %v, expected %q", password, err, "hunter2")
+	}
+	for name, buf := range map[string][]byte{
+		"inBuf":       ss.inBuf,
+		"line":        ss.line[:cap(ss.line)],
+		"lineScratch": ss.lineScratch[:cap(ss.lineScratch)],
+		"outBuf":      ss.outBuf[:cap(ss.outBuf)],
+	} {
+		if bytes.Contains(buf, []byte("hunter")) {
+			t.Errorf("password left in %s", name)
+		}
+	}
+	if string(ss.remainder) != "next" {
+		t.Errorf("unread input %q lost", ss.remainder)
+	}
+}
+
+func TestPasswordMultiLine(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("hunter2\rnext")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetMultiLine(true)
+	if pw, err := ss.ReadPassword("Password: "); pw != "hunter2" || err != nil {
+		t.Errorf("got %q, %v, expected Enter to end the password", pw, err)
+	}
+}
+
+func TestPasswordInterruptWipe(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.secret = true
+	ss.line = make([]byte, 0, maxLineLength)
+	old := ss.line[:cap(ss.line)]
+	ss.insert([]byte("hunter2"))
+	ss.handleKey(KeyCtrlC)
+	if !ss.interrupted {
+		t.Fatal("Ctrl-C didn't interrupt")
+	}
+	if i := slices.IndexFunc(old, func(b byte) bool { return b != 0 }); i >= 0 {
+		t.Errorf("got %q, expected the old line to be wiped", old)
+	}
+	if bytes.Contains(ss.line[:cap(ss.line)], []byte("hunter")) {
+		t.Errorf("password left in the line")
+	}
+}
+
+func TestPasswordGrowWipe(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.secret = true
+	ss.line = make([]byte, 0, 4)
+	old := ss.line[:cap(ss.line)]
+	for _, key := range []byte("hunter2") {
+		ss.handleKey(int(key))
+	}
+	if string(ss.line) != "hunter2" {
+		t.Fatalf("got line %q", ss.line)
+	}
+	if i := slices.IndexFunc(old, func(b byte) bool { return b != 0 }); i >= 0 {
+		t.Errorf("got %q, expected the old line to be wiped", old)
+	}
+}
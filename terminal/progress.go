@@ -0,0 +1,276 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// footerLine is a row of the footer, which is shown below the line being
+// edited, or at the cursor while no line is being read, and moves down as
+// output is written.
+type footerLine struct {
+	text string
+}
+
+// addFooter adds a row to the bottom of the footer.
+func (t *Terminal) addFooter(text string) *footerLine {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	l := &footerLine{text: text}
+	t.footer = append(t.footer, l)
+	t.updateFooter()
+	return l
+}
+
+// setFooter changes the text of a row of the footer.
+func (t *Terminal) setFooter(l *footerLine, text string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if l.text == text {
+		return
+	}
+	l.text = text
+	t.updateFooter()
+}
+
+// removeFooter removes a row from the footer. If final isn't empty, it's
+// written above the prompt as a line of output.
+func (t *Terminal) removeFooter(l *footerLine, final string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for i, other := range t.footer {
+		if other == l {
+			t.footer = append(t.footer[:i], t.footer[i+1:]...)
+			break
+		}
+	}
+	if final == "" || t.passthrough {
+		// In passthrough mode, the footer is drawn again once it
+		// ends, and the final text would be mixed into the output.
+		t.updateFooter()
+		return
+	}
+
+	editing := t.editing()
+	t.queue(hideCursor)
+	t.clearLines()
+	t.queue([]byte(final))
+	t.queue([]byte("\r\n"))
+	if editing {
+		t.repaint()
+	} else {
+		t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+		t.drawFooter(false)
+	}
+	t.queue(showCursor)
+	t.flush()
+}
+
+// updateFooter repaints the footer.
+func (t *Terminal) updateFooter() {
+	if t.passthrough {
+		return
+	}
+	t.queue(hideCursor)
+	t.drawFooter(t.editing())
+	t.queue(showCursor)
+	t.flush()
+}
+
+// drawFooter paints the footer below the line being edited, if editing is
+// true, or at the cursor, which must be at the start of a row, and leaves the
+// cursor where it was. Anything below the footer is cleared.
+func (t *Terminal) drawFooter(editing bool) {
+	if editing {
+		t.moveCursorToPos(len(t.line))
+	}
+	t.maxLine = t.cursorY
+	for i, l := range t.footer {
+		if editing || i > 0 {
+			t.writeText(newline)
+		}
+		// The last column is left empty so that the terminal
+		// doesn't wrap.
+		t.writeText([]byte(truncate(l.text, t.termWidth-1)))
+		t.clearLineToRight()
+	}
+	t.clearToEndOfScreen()
+
+	if editing {
+		t.moveCursorToPos(t.pos)
+		return
+	}
+	t.move(t.cursorY, 0, 0, 0)
+	t.moveToColumn(t.cursorX, 0)
+	t.cursorX, t.cursorY = 0, 0
+}
+
+// spinnerFrames are the frames of the Spinner animation.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is the time between frames of the Spinner animation.
+const spinnerInterval = 100 * time.Millisecond
+
+// Spinner shows an animation and a message on a row of its own, below the
+// line being edited, while a background task runs.
+type Spinner struct {
+	t    *Terminal
+	line *footerLine
+	stop chan struct{}
+
+	mu      sync.Mutex
+	frame   int
+	message string
+	stopped bool
+}
+
+// NewSpinner starts showing a spinner with the given message. Stop removes
+// it.
+func (t *Terminal) NewSpinner(message string) *Spinner {
+	s := &Spinner{t: t, message: message, stop: make(chan struct{})}
+	s.line = t.addFooter(s.text())
+	go s.animate()
+	return s
+}
+
+func (s *Spinner) text() string {
+	return spinnerFrames[s.frame] + " " + s.message
+}
+
+func (s *Spinner) animate() {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+		s.mu.Lock()
+		s.frame = (s.frame + 1) % len(spinnerFrames)
+		text := s.text()
+		s.mu.Unlock()
+		s.t.setFooter(s.line, text)
+	}
+}
+
+// SetMessage changes the message shown next to the spinner.
+func (s *Spinner) SetMessage(message string) {
+	s.mu.Lock()
+	s.message = message
+	text := s.text()
+	s.mu.Unlock()
+	s.t.setFooter(s.line, text)
+}
+
+// Stop removes the spinner. If final isn't empty, it's written as a line of
+// output, e.g. to report the result of the task.
+func (s *Spinner) Stop(final string) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	s.t.removeFooter(s.line, final)
+}
+
+// ProgressBar shows the progress of a background task on a row of its own,
+// below the line being edited.
+type ProgressBar struct {
+	t    *Terminal
+	line *footerLine
+
+	mu             sync.Mutex
+	message        string
+	current, total int64
+}
+
+// NewProgressBar starts showing a progress bar with the given message for a
+// task made up of total units of work, e.g. bytes. Done removes it.
+func (t *Terminal) NewProgressBar(message string, total int64) *ProgressBar {
+	p := &ProgressBar{t: t, message: message, total: total}
+	p.line = t.addFooter(p.text())
+	return p
+}
+
+// progressBarMinWidth is the least number of columns used for the bar.
+const progressBarMinWidth = 10
+
+func (p *ProgressBar) text() string {
+	percent := 100
+	if p.total > 0 {
+		percent = max(0, min(int(p.current*100/p.total), 100))
+	}
+
+	p.t.lock.Lock()
+	width := p.t.termWidth
+	p.t.lock.Unlock()
+	// The message, the brackets around the bar, the percentage and the
+	// last column, which is left empty.
+	barWidth := max(progressBarMinWidth, width-stringWidth(p.message)-9)
+	filled := barWidth * percent / 100
+
+	var b strings.Builder
+	if p.message != "" {
+		b.WriteString(p.message)
+		b.WriteByte(' ')
+	}
+	b.WriteByte('[')
+	b.WriteString(strings.Repeat("=", filled))
+	if filled < barWidth {
+		b.WriteByte('>')
+		b.WriteString(strings.Repeat(" ", barWidth-filled-1))
+	}
+	b.WriteString("] ")
+	s := strconv.Itoa(percent)
+	b.WriteString(strings.Repeat(" ", 3-len(s)))
+	b.WriteString(s)
+	b.WriteByte('%')
+	return b.String()
+}
+
+// Set sets the number of units of work done so far.
+func (p *ProgressBar) Set(current int64) {
+	p.mu.Lock()
+	p.current = current
+	text := p.text()
+	p.mu.Unlock()
+	p.t.setFooter(p.line, text)
+}
+
+// Add adds n to the number of units of work done so far.
+func (p *ProgressBar) Add(n int64) {
+	p.mu.Lock()
+	p.current += n
+	text := p.text()
+	p.mu.Unlock()
+	p.t.setFooter(p.line, text)
+}
+
+// SetMessage changes the message shown next to the progress bar.
+func (p *ProgressBar) SetMessage(message string) {
+	p.mu.Lock()
+	p.message = message
+	text := p.text()
+	p.mu.Unlock()
+	p.t.setFooter(p.line, text)
+}
+
+// Done removes the progress bar. If final isn't empty, it's written as a line
+// of output.
+func (p *ProgressBar) Done(final string) {
+	p.t.removeFooter(p.line, final)
+}
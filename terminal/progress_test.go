@@ -0,0 +1,89 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProgressBar(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(30, 24)
+	ss.ReadLine()
+
+	c.received = nil
+	p := ss.NewProgressBar("copy", 10)
+	p.Set(5)
+	want := "\x1b[?25l\r\ncopy [>                ]   0%\x1b[K\x1b[J\x1b[A\x1b[5G\x1b[?25h" +
+		"\x1b[?25l\r\ncopy [========>        ]  50%\x1b[K\x1b[J\x1b[A\x1b[5G\x1b[?25h"
+	if string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	// After Enter, the progress bar moves below the line.
+	c.received = nil
+	c.toSend = []byte("c\r")
+	ss.ReadLine()
+	if want := "c\x1b[J\r\ncopy [========>        ]  50%\x1b[K\x1b[J\r"; string(c.received) != want {
+		t.Errorf("got %q after Enter, expected %q", c.received, want)
+	}
+
+	// Output is written above it.
+	c.received = nil
+	ss.Write([]byte("more\r\n"))
+	if want := "\x1b[?25l\x1b[Kmore\r\ncopy [========>        ]  50%\x1b[K\x1b[J\r\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q after Write, expected %q", c.received, want)
+	}
+
+	// The prompt goes above it.
+	c.received = nil
+	c.toSend = []byte("x")
+	ss.ReadLine()
+	if want := "\x1b[?25l\x1b[K> \r\ncopy [========>        ]  50%\x1b[K\x1b[J\x1b[A\x1b[3G\x1b[?25hx"; string(c.received) != want {
+		t.Errorf("got %q from ReadLine, expected %q", c.received, want)
+	}
+
+	c.received = nil
+	p.Done("copied")
+	if want := "\x1b[?25l\x1b[B\r\x1b[K\x1b[A\x1b[Kcopied\r\n> x\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q from Done, expected %q", c.received, want)
+	}
+}
+
+func TestProgressBarStatusLine(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(30, 10)
+	ss.SetStatusLine("connected")
+	ss.ReadLine()
+
+	c.received = nil
+	p := ss.NewProgressBar("copy", 10)
+	p.Set(5)
+	expectStatusLine(t, c.received, "connected")
+}
+
+func TestSpinner(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	s := ss.NewSpinner("working")
+	s.SetMessage("still working")
+	s.Stop("done")
+	s.Stop("twice")
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+	out := string(c.received)
+	for _, want := range []string{"⠋ working", "still working", "done\r\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q missing from %q", want, out)
+		}
+	}
+	if strings.Contains(out, "twice") || len(ss.footer) != 0 {
+		t.Errorf("spinner not removed: %q", out)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// SetOutputPump makes the terminal's output go through a goroutine of its own
+// that writes it to the underlying connection, rather than being written
+// while the terminal is locked by whichever call produced it. A slow
+// connection, like an SSH client that isn't reading, then holds up neither
+// key presses nor Write calls, until frames writes are waiting; beyond that
+// they wait for the connection to catch up. An error writing is returned by
+// the next call that writes. A frames of 0 stops the pump, once everything
+// it holds has been written, which is the default.
+func (t *Terminal) SetOutputPump(frames int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.flush()
+	if p := t.pump; p != nil {
+		p.stop()
+		t.pump = nil
+	}
+	if frames > 0 {
+		t.pump = startOutputPump(t.c, frames)
+	}
+}
+
+// FlushOutput waits until all output has been written to the underlying
+// connection, which it only has to do if SetOutputPump is in use, and
+// returns an error if writing failed.
+func (t *Terminal) FlushOutput() error {
+	t.lock.Lock()
+	err := t.flush()
+	p := t.pump
+	var written chan struct{}
+	if p != nil && err == nil {
+		written = make(chan struct{})
+		p.frames <- outputFrame{written: written}
+	}
+	t.lock.Unlock()
+
+	if written == nil {
+		return err
+	}
+	<-written
+	return p.error()
+}
+
+// output writes data to the terminal, through the pump if there is one.
+func (t *Terminal) output(data []byte) (n int, err error) {
+	if t.pump == nil {
+		return t.c.Write(data)
+	}
+	if err := t.pump.send(bytes.Clone(data), t.secret); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// outputPump writes frames of output in a goroutine.
+type outputPump struct {
+	frames chan outputFrame
+	done   chan struct{}
+
+	// errLock protects err, the first error writing.
+	errLock sync.Mutex
+	err     error
+}
+
+// An outputFrame is output to be written. secret frames may hold part of a
+// password and are cleared once they're written. If written is non-nil, it's
+// closed when everything before it has been written.
+type outputFrame struct {
+	data    []byte
+	secret  bool
+	written chan struct{}
+}
+
+// startOutputPump starts writing frames to w, buffering up to n of them.
+func startOutputPump(w io.Writer, n int) *outputPump {
+	p := &outputPump{
+		frames: make(chan outputFrame, n),
+		done:   make(chan struct{}),
+	}
+	go p.run(w)
+	return p
+}
+
+// run writes the frames as they arrive, until the pump is stopped. Once
+// writing fails, further frames are dropped.
+func (p *outputPump) run(w io.Writer) {
+	defer close(p.done)
+	for f := range p.frames {
+		if len(f.data) > 0 && p.error() == nil {
+			if _, err := w.Write(f.data); err != nil {
+				p.errLock.Lock()
+				p.err = err
+				p.errLock.Unlock()
+			}
+		}
+		if f.secret {
+			clear(f.data)
+		}
+		if f.written != nil {
+			close(f.written)
+		}
+	}
+}
+
+// send queues data for writing, waiting while the buffer is full, and
+// returns an error if an earlier write failed.
+func (p *outputPump) send(data []byte, secret bool) error {
+	if err := p.error(); err != nil {
+		return err
+	}
+	p.frames <- outputFrame{data: data, secret: secret}
+	return nil
+}
+
+// error returns the error writing, if any.
+func (p *outputPump) error() error {
+	p.errLock.Lock()
+	defer p.errLock.Unlock()
+
+	return p.err
+}
+
+// stop waits for the queued frames to be written and stops the pump.
+func (p *outputPump) stop() {
+	close(p.frames)
+	<-p.done
+}
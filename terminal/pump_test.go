@@ -0,0 +1,75 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// stalledWriter is a connection whose writes wait until it's released.
+type stalledWriter struct {
+	io.Reader
+	release chan struct{}
+	lock    sync.Mutex
+	written bytes.Buffer
+	err     error
+}
+
+func (w *stalledWriter) Write(b []byte) (int, error) {
+	<-w.release
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.written.Write(b)
+}
+
+func TestOutputPump(t *testing.T) {
+	w := &stalledWriter{Reader: bytes.NewReader(nil), release: make(chan struct{})}
+	ss := NewTerminal(w, "> ", true)
+	ss.SetOutputPump(4)
+	// The first write is taken by the pump, and the next ones are
+	// buffered, while the connection is stalled.
+	for _, s := range []string{"one\n", "two\n", "three\n"} {
+		if n, err := ss.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	close(w.release)
+	if err := ss.FlushOutput(); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.written.String(); got != "one\ntwo\nthree\n" {
+		t.Errorf("got %q", got)
+	}
+
+	errBroken := errors.New("broken pipe")
+	w.lock.Lock()
+	w.err = errBroken
+	w.lock.Unlock()
+	ss.Write([]byte("four\n"))
+	if err := ss.FlushOutput(); err != errBroken {
+		t.Errorf("got %v, expected the error writing", err)
+	}
+	if _, err := ss.Write([]byte("five\n")); err != errBroken {
+		t.Errorf("got %v from Write after the error", err)
+	}
+
+	// Without the pump, output is written directly again.
+	ss.SetOutputPump(0)
+	w.lock.Lock()
+	w.err = nil
+	w.lock.Unlock()
+	ss.Write([]byte("six\n"))
+	if got := w.written.String(); got != "one\ntwo\nthree\nsix\n" {
+		t.Errorf("got %q", got)
+	}
+}
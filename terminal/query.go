@@ -0,0 +1,140 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoReply is returned by queries the terminal didn't answer in time.
+var ErrNoReply = errors.New("terminal: no reply to query")
+
+// A query waits for the terminal's reply to a request.
+type query struct {
+	// match reports whether the escape sequence seq is the reply.
+	match func(seq []byte) bool
+	reply chan []byte
+}
+
+// readResult is the outcome of a read started by a query.
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// query writes request to the terminal and returns the escape sequence
+// satisfying match that the terminal sends in reply. If a line is being read
+// at the same time, the reply is picked out of the input by readLine.
+// Otherwise input is read here and anything but the reply is kept for the next
+// call to ReadLine.
+func (t *Terminal) query(request []byte, match func(seq []byte) bool, timeout time.Duration) ([]byte, error) {
+	t.queryLock.Lock()
+	defer t.queryLock.Unlock()
+
+	t.lock.Lock()
+	q := &query{match: match, reply: make(chan []byte, 1)}
+	t.pendingQuery = q
+	t.queue(request)
+	if err := t.flush(); err != nil {
+		t.pendingQuery = nil
+		t.lock.Unlock()
+		return nil, err
+	}
+	if !t.reading && t.inFlight == nil {
+		t.startRead()
+	}
+	t.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-q.reply:
+		return reply, nil
+	case <-timer.C:
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.pendingQuery == q {
+		t.pendingQuery = nil
+		return nil, ErrNoReply
+	}
+	// The reply arrived just in time.
+	return <-q.reply, nil
+}
+
+// answer hands seq to the pending query if it's the reply the query waits
+// for.
+func (t *Terminal) answer(seq []byte) bool {
+	q := t.pendingQuery
+	if q == nil || !q.match(seq) {
+		return false
+	}
+	t.pendingQuery = nil
+	q.reply <- append([]byte(nil), seq...)
+	return true
+}
+
+// startRead reads from the terminal in the background on behalf of a query.
+// If a line is being read by the time data arrives, it's passed on to
+// readLine through t.inFlight. Otherwise it's added to t.remainder, and the
+// reading continues while the query is still waiting.
+func (t *Terminal) startRead() {
+	ch := make(chan readResult, 1)
+	t.inFlight = ch
+	go func() {
+		buf := make([]byte, 256)
+		n, err := t.c.Read(buf)
+
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		if t.reading {
+			ch <- readResult{buf[:n], err}
+			return
+		}
+		t.inFlight = nil
+		t.addInput(buf[:n])
+		if err != nil {
+			t.readErr = err
+			return
+		}
+		t.takeReply()
+		if t.pendingQuery != nil {
+			t.startRead()
+		}
+	}()
+}
+
+// addInput appends data read from the terminal to t.remainder.
+func (t *Terminal) addInput(data []byte) {
+	t.recordInput(data)
+	for len(t.inBuf)-len(t.remainder) < len(data) && len(t.inBuf) < maxInBuf {
+		t.growInBuf()
+	}
+	n := copy(t.inBuf[len(t.remainder):], data)
+	t.remainder = t.inBuf[:len(t.remainder)+n]
+}
+
+// takeReply removes the reply to the pending query from t.remainder, if it's
+// there, and hands it to the query.
+func (t *Terminal) takeReply() {
+	b := t.remainder
+	for i := 0; i < len(b); i++ {
+		if b[i] != KeyEscape {
+			continue
+		}
+		n := escapeLength(b[i:])
+		if n < 0 {
+			return
+		}
+		if t.answer(b[i : i+n]) {
+			t.remainder = append(b[:i], b[i+n:]...)
+			return
+		}
+		i += n - 1
+	}
+}
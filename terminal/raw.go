@@ -0,0 +1,16 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "errors"
+
+// ErrNotSupported is returned by MakeRaw and GetSize on systems where they
+// aren't implemented.
+var ErrNotSupported = errors.New("terminal: not supported on this system")
+
+// State holds the settings of a terminal, to be restored by Restore.
+type State struct {
+	state
+}
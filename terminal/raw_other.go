@@ -0,0 +1,30 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package terminal
+
+type state struct{}
+
+// IsTerminal reports whether fd refers to a terminal. It's always false on
+// systems other than Linux.
+func IsTerminal(fd int) bool {
+	return false
+}
+
+// MakeRaw puts the terminal fd into raw mode. It is only supported on Linux.
+func MakeRaw(fd int) (*State, error) {
+	return nil, ErrNotSupported
+}
+
+// Restore restores the terminal fd to a state returned by MakeRaw.
+func Restore(fd int, state *State) error {
+	return ErrNotSupported
+}
+
+// GetSize returns the size of the terminal fd. It is only supported on Linux.
+func GetSize(fd int) (width, height int, err error) {
+	return 0, 0, ErrNotSupported
+}
@@ -0,0 +1,52 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/pty"
+)
+
+func TestMakeRaw(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("can't open a pseudo-terminal: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+	fd := int(tty.Fd())
+
+	if !IsTerminal(fd) {
+		t.Fatal("IsTerminal is false for a pseudo-terminal")
+	}
+	pty.SetSize(ptmx, 100, 30)
+	if w, h, err := GetSize(fd); err != nil || w != 100 || h != 30 {
+		t.Errorf("got size %dx%d, %v, expected 100x30", w, h, err)
+	}
+
+	state, err := MakeRaw(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without echo, only the output is read back.
+	ptmx.Write([]byte("x"))
+	tty.Write([]byte("a\n"))
+	b := make([]byte, 10)
+	if n, _ := ptmx.Read(b); string(b[:n]) != "a\n" {
+		t.Errorf("got %q in raw mode, expected a\\n", b[:n])
+	}
+	if n, _ := tty.Read(b); string(b[:n]) != "x" {
+		t.Errorf("read %q, expected the key without waiting for a newline", b[:n])
+	}
+
+	if err := Restore(fd, state); err != nil {
+		t.Fatal(err)
+	}
+	tty.Write([]byte("b\n"))
+	if n, _ := ptmx.Read(b); string(b[:n]) != "b\r\n" {
+		t.Errorf("got %q after Restore, expected b\\r\\n", b[:n])
+	}
+}
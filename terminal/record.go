@@ -0,0 +1,144 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Recorder writes a session in asciinema's asciicast v2 format, which
+// standard players can replay: a JSON header line followed by one JSON array
+// per event, giving its time in seconds since the start, its type and data.
+type Recorder struct {
+	lock  sync.Mutex
+	w     io.Writer
+	start time.Time
+	err   error
+	// partial holds the start of a UTF-8 sequence at the end of the last
+	// output or input, which is completed by the next.
+	partial [2][]byte
+}
+
+type asciicastHeader struct {
+	Version   int   `json:"version"`
+	Width     int   `json:"width"`
+	Height    int   `json:"height"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+// NewRecorder writes the header for a session on a terminal of the given size
+// to w and returns a Recorder for its events.
+func NewRecorder(w io.Writer, width, height int) (*Recorder, error) {
+	r := &Recorder{w: w, start: time.Now()}
+	header, _ := json.Marshal(asciicastHeader{2, width, height, r.start.Unix()})
+	if _, err := w.Write(append(header, '\n')); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Output returns a writer that records everything written to it as output
+// events.
+func (r *Recorder) Output() io.Writer {
+	return recorderStream{r, 0}
+}
+
+// Input returns a writer that records everything written to it as input
+// events.
+func (r *Recorder) Input() io.Writer {
+	return recorderStream{r, 1}
+}
+
+// Resize records that the terminal changed size.
+func (r *Recorder) Resize(width, height int) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.event("r", strconv.Itoa(width)+"x"+strconv.Itoa(height))
+}
+
+// Err returns the first error writing an event, after which nothing more is
+// recorded.
+func (r *Recorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.err
+}
+
+// event writes an event line. r.lock must be held.
+func (r *Recorder) event(code, data string) error {
+	if r.err != nil {
+		return r.err
+	}
+	line := []byte{'['}
+	elapsed := time.Since(r.start).Seconds()
+	line = strconv.AppendFloat(line, elapsed, 'f', 6, 64)
+	line = append(line, ", \""...)
+	line = append(line, code...)
+	line = append(line, "\", "...)
+	text, _ := json.Marshal(data)
+	line = append(line, text...)
+	line = append(line, "]\n"...)
+	_, r.err = r.w.Write(line)
+	return r.err
+}
+
+type recorderStream struct {
+	r      *Recorder
+	stream int
+}
+
+var streamCodes = [2]string{"o", "i"}
+
+func (s recorderStream) Write(data []byte) (n int, err error) {
+	r := s.r
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	b := append(r.partial[s.stream], data...)
+	// Events hold text, so a UTF-8 sequence that isn't complete yet waits
+	// for the rest of it.
+	end := len(b)
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				end = len(b) - i
+			}
+			break
+		}
+	}
+	r.partial[s.stream] = append([]byte(nil), b[end:]...)
+	if end > 0 {
+		if err := r.event(streamCodes[s.stream], string(b[:end])); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// Record starts recording the session to w in asciicast v2 format, including
+// the input if input is true. It uses the transcript, see SetTranscript, which
+// also stops the recording. Changes of size through SetSize are recorded too.
+func (t *Terminal) Record(w io.Writer, input bool) (*Recorder, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	r, err := NewRecorder(w, t.termWidth, t.termHeight)
+	if err != nil {
+		return nil, err
+	}
+	t.transcriptOut, t.transcriptIn = r.Output(), nil
+	if input {
+		t.transcriptIn = r.Input()
+	}
+	t.recorder = r
+	return r, nil
+}
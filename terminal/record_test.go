@@ -0,0 +1,86 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// readCast returns the header and the events, without their times, of an
+// asciicast.
+func readCast(t *testing.T, cast string) (header map[string]interface{}, events [][2]string) {
+	lines := strings.Split(strings.TrimSuffix(cast, "\n"), "\n")
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatalf("bad header %q: %v", lines[0], err)
+	}
+	for _, line := range lines[1:] {
+		var ev []interface{}
+		if err := json.Unmarshal([]byte(line), &ev); err != nil || len(ev) != 3 {
+			t.Fatalf("bad event %q: %v", line, err)
+		}
+		if _, ok := ev[0].(float64); !ok {
+			t.Fatalf("bad time in %q", line)
+		}
+		events = append(events, [2]string{ev[1].(string), ev[2].(string)})
+	}
+	return header, events
+}
+
+func TestRecorder(t *testing.T) {
+	var cast bytes.Buffer
+	r, err := NewRecorder(&cast, 80, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Output().Write([]byte("a\xe6\x97"))
+	r.Output().Write([]byte("\xa5b"))
+	r.Input().Write([]byte("\r"))
+	r.Resize(100, 30)
+
+	header, events := readCast(t, cast.String())
+	if header["version"] != 2.0 || header["width"] != 80.0 || header["height"] != 24.0 {
+		t.Errorf("bad header %v", header)
+	}
+	want := [][2]string{{"o", "a"}, {"o", "日b"}, {"i", "\r"}, {"r", "100x30"}}
+	if len(events) != len(want) {
+		t.Fatalf("got %q, expected %q", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: got %q, expected %q", i, events[i], want[i])
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("hi\r")}
+	ss := NewTerminal(c, "> ", true)
+	var cast bytes.Buffer
+	if _, err := ss.Record(&cast, true); err != nil {
+		t.Fatal(err)
+	}
+	ss.ReadLine()
+	ss.SetSize(100, 30)
+
+	_, events := readCast(t, cast.String())
+	var out, in string
+	resized := false
+	for _, ev := range events {
+		switch ev[0] {
+		case "o":
+			out += ev[1]
+		case "i":
+			in += ev[1]
+		case "r":
+			resized = ev[1] == "100x30"
+		}
+	}
+	if out != string(c.received) || in != "hi\r" || !resized {
+		t.Errorf("got output %q, input %q, resized %v", out, in, resized)
+	}
+}
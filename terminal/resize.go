@@ -0,0 +1,28 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+// OnResize sets a function that is called with the new size of the terminal
+// whenever it changes: through SetSize, and for a Terminal returned by
+// NewWithStdInOut, when the window is resized. Status bars, menus and
+// full-screen widgets can redraw themselves from it. It's called after the
+// line being edited has been repainted, without the terminal locked, so it
+// may use the Terminal. A nil f removes the function.
+func (t *Terminal) OnResize(f func(width, height int)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.onResize = f
+}
+
+// watchResize makes the terminal follow the size of the local terminal fd,
+// until the returned function is called.
+func (t *Terminal) watchResize(fd int) (stop func()) {
+	return watchWinch(func() {
+		if width, height, err := GetSize(fd); err == nil {
+			t.SetSize(width, height)
+		}
+	})
+}
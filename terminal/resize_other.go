@@ -0,0 +1,12 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package terminal
+
+// watchWinch does nothing, as there is no SIGWINCH on this system.
+func watchWinch(f func()) (stop func()) {
+	return func() {}
+}
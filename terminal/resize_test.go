@@ -0,0 +1,26 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+func TestOnResize(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	var sizes [][2]int
+	ss.OnResize(func(width, height int) {
+		// The terminal isn't locked.
+		ss.SetPrompt("$ ")
+		sizes = append(sizes, [2]int{width, height})
+	})
+	ss.SetSize(100, 30)
+	ss.SetSize(100, 30)
+	ss.SetSize(120, 30)
+	ss.OnResize(nil)
+	ss.SetSize(80, 24)
+	if want := [][2]int{{100, 30}, {120, 30}}; len(sizes) != len(want) || sizes[0] != want[0] || sizes[1] != want[1] {
+		t.Errorf("got sizes %v, expected %v", sizes, want)
+	}
+}
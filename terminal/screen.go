@@ -0,0 +1,231 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// A Cell is a single character on the screen and its appearance. A Rune of 0
+// is displayed as a space, except after a wide rune, which covers the cell
+// following it.
+type Cell struct {
+	Rune  rune
+	Style Style
+}
+
+// Screen is a grid of cells covering the whole terminal, for full-screen
+// applications. Cells are changed in memory with SetCell and Fill, and Flush
+// updates the terminal with as little output as possible.
+type Screen struct {
+	t             *Terminal
+	width, height int
+	// cells holds the contents of the screen and shown what is displayed
+	// by the terminal. shown is nil if that's unknown.
+	cells, shown []Cell
+	// cursorX and cursorY give the cursor position after Flush, or -1 if
+	// the cursor is hidden.
+	cursorX, cursorY int
+}
+
+var (
+	enterAltScreen = []byte{KeyEscape, '[', '?', '1', '0', '4', '9', 'h'}
+	exitAltScreen  = []byte{KeyEscape, '[', '?', '1', '0', '4', '9', 'l'}
+)
+
+// NewScreen switches the terminal to the alternate screen, which leaves the
+// contents of the normal screen untouched, and returns a blank Screen with
+// the size set by SetSize. Close switches back.
+func (t *Terminal) NewScreen() (*Screen, error) {
+	t.lock.Lock()
+	width, height := t.termWidth, t.termHeight
+	t.queue(enterAltScreen)
+	err := t.flush()
+	t.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Screen{t: t, cursorX: -1, cursorY: -1}
+	s.Resize(width, height)
+	return s, nil
+}
+
+// Close switches the terminal back to the normal screen.
+func (s *Screen) Close() error {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	s.t.queue(exitAltScreen)
+	s.t.queue(showCursor)
+	return s.t.flush()
+}
+
+// Size returns the number of columns and rows of the screen.
+func (s *Screen) Size() (width, height int) {
+	return s.width, s.height
+}
+
+// Resize changes the size of the screen, e.g. after the terminal was resized,
+// and clears it. The next Flush repaints the whole screen.
+func (s *Screen) Resize(width, height int) {
+	s.width, s.height = width, height
+	s.cells = make([]Cell, width*height)
+	s.shown = nil
+}
+
+// Invalidate makes the next Flush repaint the whole screen, e.g. after
+// something else has written to the terminal.
+func (s *Screen) Invalidate() {
+	s.shown = nil
+}
+
+// Cell returns the cell at column x and row y.
+func (s *Screen) Cell(x, y int) Cell {
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return Cell{}
+	}
+	return s.cells[y*s.width+x]
+}
+
+// SetCell sets the cell at column x and row y, counting from 0 at the top
+// left corner. A wide rune also takes up the cell to its right. Cells outside
+// of the screen are ignored.
+func (s *Screen) SetCell(x, y int, r rune, style Style) {
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return
+	}
+	i := y*s.width + x
+	if x > 0 && runeWidth(s.cells[i-1].Rune) == 2 {
+		// This cell was covered by a wide rune, which is cut in half.
+		s.cells[i-1].Rune = ' '
+	}
+	if runeWidth(r) == 2 {
+		if x+1 == s.width {
+			// It doesn't fit.
+			r = ' '
+		} else {
+			s.cells[i+1] = Cell{Style: style}
+		}
+	}
+	s.cells[i] = Cell{Rune: r, Style: style}
+}
+
+// SetString sets the cells starting at column x and row y to the runes of
+// text, all in the same style, and returns the column after the last one.
+func (s *Screen) SetString(x, y int, text string, style Style) int {
+	for _, r := range text {
+		w := runeWidth(r)
+		if w == 0 {
+			continue
+		}
+		s.SetCell(x, y, r, style)
+		x += w
+	}
+	return x
+}
+
+// Fill sets every cell of the screen to r in the given style. A wide rune
+// fills every other cell, as with SetCell.
+func (s *Screen) Fill(r rune, style Style) {
+	step := max(runeWidth(r), 1)
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x += step {
+			s.SetCell(x, y, r, style)
+		}
+	}
+}
+
+// SetCursor makes Flush leave the cursor at column x and row y. If either is
+// negative, the cursor is hidden.
+func (s *Screen) SetCursor(x, y int) {
+	if x < 0 || y < 0 {
+		x, y = -1, -1
+	}
+	s.cursorX, s.cursorY = x, y
+}
+
+var (
+	homeCursor = []byte{KeyEscape, '[', 'H'}
+	eraseAll   = []byte{KeyEscape, '[', '2', 'J'}
+)
+
+// Flush updates the terminal to show the screen. Only cells that changed
+// since the last Flush are written.
+func (s *Screen) Flush() error {
+	t := s.t
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(hideCursor)
+	if s.shown == nil {
+		t.queue(homeCursor)
+		t.queue(eraseAll)
+		s.shown = make([]Cell, len(s.cells))
+		for i := range s.shown {
+			s.shown[i] = Cell{Rune: ' '}
+		}
+	}
+
+	// x and y track the terminal's cursor; x is -1 if it's unknown.
+	x, y := -1, -1
+	// style is the style of the last cell written and sgr the sequence
+	// that was sent for it.
+	var style Style
+	var sgr []byte
+	for row := 0; row < s.height; row++ {
+		for col := 0; col < s.width; col++ {
+			i := row*s.width + col
+			c := s.cells[i]
+			// A wide rune can't be in the last column, as SetCell
+			// doesn't put it there, but don't trust that.
+			wide := runeWidth(c.Rune) == 2 && col+1 < s.width
+			if s.shown[i] == c && (!wide || s.shown[i+1] == s.cells[i+1]) {
+				continue
+			}
+
+			if x != col || y != row {
+				t.queueMoveTo(col, row)
+				x, y = col, row
+			}
+			if c.Style != style {
+				if seq := c.Style.sgr(t.colorProfile); !bytes.Equal(seq, sgr) {
+					if sgr != nil {
+						t.queue(vt100EscapeCodes.Reset)
+					}
+					t.queue(seq)
+					sgr = seq
+				}
+				style = c.Style
+			}
+			r := c.Rune
+			if r == 0 || runeWidth(r) == 0 || runeWidth(r) == 2 && !wide {
+				r = ' '
+			}
+			t.outBuf = utf8.AppendRune(t.outBuf, r)
+			s.shown[i] = c
+			x++
+			if wide {
+				s.shown[i+1] = s.cells[i+1]
+				x++
+				col++
+			}
+			if x >= s.width {
+				// Whether the cursor wrapped depends on the
+				// terminal.
+				x = -1
+			}
+		}
+	}
+	if sgr != nil {
+		t.queue(vt100EscapeCodes.Reset)
+	}
+	if s.cursorX >= 0 {
+		t.queueMoveTo(s.cursorX, s.cursorY)
+		t.queue(showCursor)
+	}
+	return t.flush()
+}
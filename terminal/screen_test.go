@@ -0,0 +1,81 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScreenFlush(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetColorProfile(ANSI)
+	ss.SetSize(4, 2)
+	s, err := ss.NewScreen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Fill(' ', Style{})
+	s.SetString(0, 0, "ab", Style{})
+	s.SetCell(1, 1, '世', Style{Bold: true})
+	s.Flush()
+	want := "\x1b[?1049h\x1b[?25l\x1b[H\x1b[2J\x1b[1;1Hab\x1b[2;2H\x1b[1m世\x1b[0m"
+	if string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	// Only the changed cell is written.
+	c.received = nil
+	s.SetCell(1, 0, 'x', Style{})
+	s.SetCursor(0, 1)
+	s.Flush()
+	if want := "\x1b[?25l\x1b[1;2Hx\x1b[2;1H\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	c.received = nil
+	s.Flush()
+	if want := "\x1b[?25l\x1b[2;1H\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q for an unchanged screen, expected %q", c.received, want)
+	}
+}
+
+func TestScreenWideRune(t *testing.T) {
+	ss := NewTerminal(&MockTerminal{}, "> ", true)
+	ss.SetSize(3, 1)
+	s, _ := ss.NewScreen()
+	s.SetCell(2, 0, '世', Style{})
+	if r := s.Cell(2, 0).Rune; r != ' ' {
+		t.Errorf("wide rune in the last column stored as %q", r)
+	}
+	s.SetCell(0, 0, '世', Style{})
+	s.SetCell(1, 0, 'x', Style{})
+	if r := s.Cell(0, 0).Rune; r != ' ' {
+		t.Errorf("wide rune cut in half stored as %q", r)
+	}
+}
+
+func TestScreenFillWideRune(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(3, 2)
+	s, _ := ss.NewScreen()
+	s.Fill('日', Style{})
+	for y := 0; y < 2; y++ {
+		if r := s.Cell(0, y).Rune; r != '日' {
+			t.Errorf("got %q at 0,%d, expected the wide rune", r, y)
+		}
+		if r := s.Cell(2, y).Rune; r != ' ' {
+			t.Errorf("got %q in the last column of row %d, expected a blank", r, y)
+		}
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x1b[1;1H日\x1b[2;1H日"; !strings.Contains(string(c.received), want) {
+		t.Errorf("got %q, expected it to contain %q", c.received, want)
+	}
+}
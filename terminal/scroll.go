@@ -0,0 +1,142 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// SetHorizontalScroll sets whether the line is kept on the row the prompt ends
+// on, scrolling sideways to follow the cursor, rather than wrapping onto the
+// rows below. A < or > at either end of the row shows that part of the line
+// is out of view. Terminal multiplexers and serial consoles often lose track
+// of the cursor when lines wrap, which this avoids. Multi-line buffers still
+// wrap, and the right prompt isn't shown while scrolling.
+func (t *Terminal) SetHorizontalScroll(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.hscroll = on
+	t.scrollCol = 0
+}
+
+// scrolling reports whether the line is scrolled rather than wrapped.
+func (t *Terminal) scrolling() bool {
+	return t.hscroll && !t.multiLine
+}
+
+// scrollWidth returns the number of columns the line is scrolled in when
+// the prompt ends at column px. The last column of the terminal is left
+// alone, as writing to it would make some terminals wrap.
+func (t *Terminal) scrollWidth(px int) int {
+	return max(t.termWidth-1-px, 1)
+}
+
+// unwrapped lays b, which is part of the line, out on a row without an end,
+// starting at column x, and returns the column after it. If f is non-nil, it
+// is called with how each character is shown and the column it starts at.
+func (t *Terminal) unwrapped(b []byte, x int, f func(shown []byte, x, width int)) int {
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		var shown []byte
+		switch {
+		case t.mask != nil:
+			shown = t.mask
+		case r == '\t':
+			shown = bytes.Repeat([]byte{' '}, tabStop-x%tabStop)
+		default:
+			shown = t.display(b[:size], 0)
+		}
+		width := bytesWidth(shown)
+		if f != nil {
+			f(shown, x, width)
+		}
+		x += width
+		b = b[size:]
+	}
+	return x
+}
+
+// scroll adjusts the part of the line in view so that the cursor is in it when
+// it's at pos, and reports whether it changed. When the cursor would leave
+// the view, the line is scrolled to bring it to the middle, or as far as the
+// end of the line allows.
+func (t *Terminal) scroll(pos int) bool {
+	px, _ := t.promptEnd()
+	w := t.scrollWidth(px)
+	c := t.unwrapped(t.line[:pos], px, nil) - px
+	total := t.unwrapped(t.line[pos:], px+c, nil) - px
+
+	old := t.scrollCol
+	if lo, hi := t.scrollBounds(total, w); c < lo || c > hi {
+		t.scrollCol = max(c-w/2, 0)
+	}
+	// Don't leave columns empty after the end of the line.
+	t.scrollCol = min(t.scrollCol, max(total-(w-1), 0))
+	return t.scrollCol != old
+}
+
+// scrollBounds returns the first and last column of the line that the cursor
+// can be shown at, which excludes those covered by the < and > indicators.
+func (t *Terminal) scrollBounds(total, w int) (lo, hi int) {
+	lo, hi = t.scrollCol, t.scrollCol+w-1
+	if t.scrollCol > 0 {
+		lo++
+	}
+	if total > t.scrollCol+w {
+		hi--
+	}
+	return lo, hi
+}
+
+// drawScrolled writes the part of the line in view after the prompt.
+func (t *Terminal) drawScrolled() {
+	t.scrollDirty = false
+	px, py := t.promptEnd()
+	w := t.scrollWidth(px)
+	total := t.unwrapped(t.line, px, nil) - px
+	// Characters are shown if they fit between the indicators.
+	lo, hi := t.scrollBounds(total, w)
+	end := hi + 1
+
+	t.move(max(0, t.cursorY-py), max(0, py-t.cursorY), 0, 0)
+	t.moveToColumn(t.cursorX, px)
+	t.cursorY = py
+	at := t.scrollCol
+	if t.scrollCol > 0 {
+		t.queue([]byte{'<'})
+		at++
+	}
+	shown := false
+	t.unwrapped(t.line, px, func(b []byte, x, width int) {
+		x -= px
+		if width == 0 {
+			// Combining marks go with the character before them.
+			if shown {
+				t.queue(b)
+			}
+			return
+		}
+		shown = x >= lo && x+width <= end
+		if !shown {
+			return
+		}
+		for ; at < x; at++ {
+			t.queue([]byte{' '})
+		}
+		t.queue(b)
+		at += width
+	})
+	if total > t.scrollCol+w {
+		for ; at < end; at++ {
+			t.queue([]byte{' '})
+		}
+		t.queue([]byte{'>'})
+		at++
+	}
+	t.cursorX = px + at - t.scrollCol
+	t.clearLineToRight()
+}
@@ -0,0 +1,45 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHorizontalScroll(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(20, 5)
+	ss.SetHorizontalScroll(true)
+	line := "abcdefghijklmnopqrstuvwxyz"
+	for _, test := range []struct {
+		pos, x int
+		shown  string
+	}{
+		{len(line), 18, "<lmnopqrstuvwxyz"},
+		{24, 16, "<lmnopqrstuvwxyz"},
+		{4, 6, "abcdefghijklmnop>"},
+		{0, 2, "abcdefghijklmnop>"},
+		{20, 12, "<lmnopqrstuvwxyz"},
+		{12, 4, "<lmnopqrstuvwxyz"},
+		{10, 10, "<defghijklmnopqr>"},
+	} {
+		c.received = nil
+		ss.SetLine(line, test.pos)
+		if ss.cursorX != test.x || ss.cursorY != 0 || ss.maxLine != 0 {
+			t.Errorf("cursor at %d,%d at position %d, expected %d,0", ss.cursorX, ss.cursorY, test.pos, test.x)
+		}
+		if !bytes.Contains(c.received, []byte(test.shown)) {
+			t.Errorf("%q shown at position %d, expected %q", c.received, test.pos, test.shown)
+		}
+	}
+
+	c.received = nil
+	ss.SetLine("abc", 3)
+	if !bytes.Contains(c.received, []byte("abc")) || ss.cursorX != 5 {
+		t.Errorf("%q written with the cursor at %d after shortening the line", c.received, ss.cursorX)
+	}
+}
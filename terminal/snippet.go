@@ -0,0 +1,144 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// SetSnippet defines a named snippet of text, to be inserted at the cursor
+// by a key bound with BindSnippet. A snippet without placeholders is a
+// plain macro. Placeholders mark the fields to be filled in, as in
+//
+//	ssh ${user}@${host:localhost} -p ${port:22}
+//
+// The cursor is put at the end of the first field, after its default text,
+// if it has one, and Tab moves it to the next one, until the last field has
+// been reached or the line is entered. An empty text removes the snippet.
+func (t *Terminal) SetSnippet(name, text string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if text == "" {
+		delete(t.snippets, name)
+		return
+	}
+	if t.snippets == nil {
+		t.snippets = make(map[string]string)
+	}
+	t.snippets[name] = text
+}
+
+// BindSnippet makes key insert the snippet called name. An empty name undoes
+// the binding.
+func (t *Terminal) BindSnippet(key int, name string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if name == "" {
+		delete(t.snippetKeys, key)
+		return
+	}
+	if t.snippetKeys == nil {
+		t.snippetKeys = make(map[int]string)
+	}
+	t.snippetKeys[key] = name
+}
+
+// SaveSnippets writes the snippets, as a JSON object mapping their names to
+// their text, for LoadSnippets to read them in another session.
+func (t *Terminal) SaveSnippets(w io.Writer) error {
+	t.lock.Lock()
+	snippets := make(map[string]string, len(t.snippets))
+	for name, text := range t.snippets {
+		snippets[name] = text
+	}
+	t.lock.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(snippets)
+}
+
+// LoadSnippets reads snippets written by SaveSnippets and defines them, as
+// SetSnippet does.
+func (t *Terminal) LoadSnippets(r io.Reader) error {
+	var snippets map[string]string
+	if err := json.NewDecoder(r).Decode(&snippets); err != nil {
+		return err
+	}
+	for name, text := range snippets {
+		t.SetSnippet(name, text)
+	}
+	return nil
+}
+
+// parseSnippet returns the text of a snippet with its placeholders replaced
+// by their default text, and the offsets of the ends of its fields.
+func parseSnippet(snippet string) (text string, fields []int) {
+	var b strings.Builder
+	for {
+		i := strings.Index(snippet, "${")
+		end := strings.IndexByte(snippet[max(i, 0):], '}')
+		if i < 0 || end < 0 {
+			b.WriteString(snippet)
+			return b.String(), fields
+		}
+		end += i
+		b.WriteString(snippet[:i])
+		if _, def, ok := strings.Cut(snippet[i+2:end], ":"); ok {
+			b.WriteString(def)
+		}
+		fields = append(fields, b.Len())
+		snippet = snippet[end+1:]
+	}
+}
+
+// insertSnippet inserts the snippet called name at the cursor and moves the
+// cursor to its first field. t.lock must be held.
+func (t *Terminal) insertSnippet(name string) {
+	snippet, ok := t.snippets[name]
+	if !ok {
+		t.invalidKey()
+		return
+	}
+	text, fields := parseSnippet(snippet)
+	start := t.pos
+	t.insert([]byte(text))
+	t.snippetFields = nil
+	if len(fields) == 0 {
+		return
+	}
+	for i := range fields {
+		fields[i] += start
+	}
+	t.snippetFields = fields
+	t.snippetLen = len(t.line)
+	t.nextSnippetField()
+}
+
+// nextSnippetField moves the cursor to the next field of the snippet being
+// filled in. Fields are assumed to be edited in order, so the ones that
+// follow are moved along by the text typed since the last one.
+func (t *Terminal) nextSnippetField() {
+	pos := t.snippetFields[0] + len(t.line) - t.snippetLen
+	t.snippetFields = t.snippetFields[1:]
+	for i := range t.snippetFields {
+		t.snippetFields[i] += len(t.line) - t.snippetLen
+	}
+	if len(t.snippetFields) == 0 {
+		t.snippetFields = nil
+	}
+	t.snippetLen = len(t.line)
+	if pos < 0 || pos > len(t.line) {
+		t.snippetFields = nil
+		t.invalidKey()
+		return
+	}
+	t.pos = pos
+	t.moveCursorToPos(t.pos)
+}
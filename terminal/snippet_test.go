@@ -0,0 +1,54 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParseSnippet(t *testing.T) {
+	text, fields := parseSnippet("ssh ${user}@${host:localhost} $HOME ${x")
+	if text != "ssh @localhost $HOME ${x" || !reflect.DeepEqual(fields, []int{4, 14}) {
+		t.Errorf("got %q, %v", text, fields)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("x \x18root\t\x7f\x7f\x7f\x7f\x7f\x7f\x7f\x7f\x7fhost\t\r\x18\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSnippet("ssh", "ssh ${user}@${host:localhost} -p ${port:22}")
+	ss.BindSnippet(0x18, "ssh")
+	if line, err := ss.ReadLine(); err != nil || line != "x ssh root@host -p 22" {
+		t.Errorf("got %q, %v", line, err)
+	}
+	// Enter ends filling in the fields.
+	if line, err := ss.ReadLine(); err != nil || line != "ssh @localhost -p 22" {
+		t.Errorf("got %q, %v", line, err)
+	}
+}
+
+func TestSaveSnippets(t *testing.T) {
+	ss := NewTerminal(&MockTerminal{}, "> ", true)
+	ss.SetSnippet("a", "echo ${x}")
+	ss.SetSnippet("b", "ls")
+	ss.SetSnippet("b", "")
+	var buf bytes.Buffer
+	if err := ss.SaveSnippets(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	other := NewTerminal(&MockTerminal{}, "> ", true)
+	if err := other.LoadSnippets(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"a": "echo ${x}"}; !reflect.DeepEqual(other.snippets, want) {
+		t.Errorf("loaded %v, expected %v", other.snippets, want)
+	}
+}
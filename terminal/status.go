@@ -0,0 +1,96 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"strconv"
+)
+
+// scrollIfAtBottom makes sure that the cursor isn't on the bottom row, by
+// scrolling the screen up a row if it is.
+var scrollIfAtBottom = []byte{'\n', KeyEscape, '[', 'A'}
+
+// SetStatusLine shows text on the bottom row of the terminal, e.g. connection
+// details, the editing mode or key hints. The rest of the screen scrolls
+// above it, so that it stays in place while lines are read and output is
+// written. Text may contain escape sequences for styling; it's truncated to
+// the width of the terminal. An empty text removes the status line.
+//
+// The status line uses the scroll region, so SetScrollRegion shouldn't be
+// used at the same time.
+func (t *Terminal) SetStatusLine(text string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	shown := t.statusLine != ""
+	t.statusLine = text
+	switch {
+	case text == "" && shown:
+		t.queue(saveCursor)
+		t.queue(resetScrollRegion)
+		t.queueMoveTo(0, t.termHeight-1)
+		t.queue(eraseLine)
+		t.queue(restoreCursor)
+	case text != "" && !shown:
+		t.queue(scrollIfAtBottom)
+		t.reserveStatusLine()
+		t.drawStatusLine()
+	case text != "":
+		t.drawStatusLine()
+	}
+	return t.flush()
+}
+
+// reserveStatusLine excludes the bottom row from the scroll region.
+func (t *Terminal) reserveStatusLine() {
+	t.queue(saveCursor)
+	t.outBuf = append(t.outBuf, KeyEscape, '[', '1', ';')
+	t.outBuf = strconv.AppendInt(t.outBuf, int64(t.termHeight-1), 10)
+	t.outBuf = append(t.outBuf, 'r')
+	t.queue(restoreCursor)
+}
+
+var eraseLine = []byte{KeyEscape, '[', '2', 'K'}
+
+// drawStatusLine paints the status line, if there is one, leaving the cursor
+// where it is.
+func (t *Terminal) drawStatusLine() {
+	if t.statusLine == "" {
+		return
+	}
+	t.queue(saveCursor)
+	t.queueMoveTo(0, t.termHeight-1)
+	t.queue(eraseLine)
+	// The last column is left empty so that the terminal doesn't wrap.
+	t.queue([]byte(truncate(t.statusLine, t.termWidth-1)))
+	t.queue(restoreCursor)
+}
+
+// statusLineResized moves the status line to the bottom of the terminal
+// after its height changed from oldHeight.
+func (t *Terminal) statusLineResized(oldHeight int) {
+	if t.statusLine == "" {
+		return
+	}
+	if oldHeight < t.termHeight {
+		// The old status line is now part of the scroll region.
+		t.queue(saveCursor)
+		t.queueMoveTo(0, oldHeight-1)
+		t.queue(eraseLine)
+		t.queue(restoreCursor)
+	}
+	t.reserveStatusLine()
+	t.drawStatusLine()
+}
+
+// queueMoveTo queues a sequence moving the cursor to column x and row y of
+// the screen. The cursor bookkeeping isn't updated.
+func (t *Terminal) queueMoveTo(x, y int) {
+	t.outBuf = append(t.outBuf, KeyEscape, '[')
+	t.outBuf = strconv.AppendInt(t.outBuf, int64(y+1), 10)
+	t.outBuf = append(t.outBuf, ';')
+	t.outBuf = strconv.AppendInt(t.outBuf, int64(x+1), 10)
+	t.outBuf = append(t.outBuf, 'H')
+}
@@ -0,0 +1,68 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStatusLine(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(10, 5)
+	ss.SetStatusLine("connected to example.com")
+	want := "\n\x1b[A\x1b7\x1b[1;4r\x1b8\x1b7\x1b[5;1H\x1b[2Kconnected\x1b8"
+	if string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	c.received = nil
+	ss.SetSize(10, 6)
+	want = "\x1b7\x1b[5;1H\x1b[2K\x1b8\x1b7\x1b[1;5r\x1b8\x1b7\x1b[6;1H\x1b[2Kconnected\x1b8"
+	if string(c.received) != want {
+		t.Errorf("got %q after resize, expected %q", c.received, want)
+	}
+
+	c.received = nil
+	ss.SetStatusLine("")
+	if want := "\x1b7\x1b[r\x1b[6;1H\x1b[2K\x1b8"; string(c.received) != want {
+		t.Errorf("got %q after removal, expected %q", c.received, want)
+	}
+}
+
+// expectStatusLine reports an error unless the status line was drawn again
+// after the last time the screen below the cursor was cleared.
+func expectStatusLine(t *testing.T, out []byte, status string) {
+	t.Helper()
+	i := bytes.LastIndex(out, []byte("\x1b[J"))
+	if i < 0 {
+		t.Fatalf("got %q, expected the screen to be cleared", out)
+	}
+	if !bytes.Contains(out[i:], []byte("\x1b[2K"+status)) {
+		t.Errorf("got %q, expected the status line to be drawn again", out)
+	}
+}
+
+func TestStatusLineKept(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(10, 5)
+	ss.SetStatusLine("connected")
+	ss.SetMultiLine(true)
+	ss.SetLine("a\nb", 2)
+
+	// Deleting the line break leaves a row to clear.
+	c.received = nil
+	ss.lock.Lock()
+	ss.handleKey(KeyBackspace)
+	ss.flush()
+	ss.lock.Unlock()
+	expectStatusLine(t, c.received, "connected")
+
+	c.received = nil
+	ss.ClearToEndOfScreen()
+	expectStatusLine(t, c.received, "connected")
+}
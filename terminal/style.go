@@ -0,0 +1,332 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorProfile describes the range of colors a terminal is able to display.
+type ColorProfile int
+
+const (
+	// NoColor terminals get no styling escape sequences at all.
+	NoColor ColorProfile = iota
+	// ANSI terminals support the 16 basic colors.
+	ANSI
+	// ANSI256 terminals support the xterm 256-color palette.
+	ANSI256
+	// TrueColor terminals support 24-bit RGB colors.
+	TrueColor
+)
+
+// DetectColorProfile guesses the color capabilities of the terminal attached
+// to the current process from the TERM and COLORTERM environment variables.
+// The NO_COLOR, CLICOLOR and CLICOLOR_FORCE conventions are honored.
+func DetectColorProfile() ColorProfile {
+	return applyColorEnv(detectColorProfile(os.Getenv), os.Getenv)
+}
+
+// applyColorEnv adjusts p according to the user's color preferences. A
+// non-empty NO_COLOR, or CLICOLOR=0, disables color; a CLICOLOR_FORCE other
+// than 0 enables basic colors even if the terminal doesn't seem to support
+// them. NO_COLOR takes precedence over CLICOLOR_FORCE.
+func applyColorEnv(p ColorProfile, getenv func(string) string) ColorProfile {
+	if getenv("NO_COLOR") != "" {
+		return NoColor
+	}
+	if force := getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		if p == NoColor {
+			return ANSI
+		}
+		return p
+	}
+	if getenv("CLICOLOR") == "0" {
+		return NoColor
+	}
+	return p
+}
+
+func detectColorProfile(getenv func(string) string) ColorProfile {
+	term := strings.ToLower(getenv("TERM"))
+	if term == "" || term == "dumb" {
+		return NoColor
+	}
+
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+
+	switch {
+	case strings.HasSuffix(term, "-direct") || strings.Contains(term, "truecolor"):
+		return TrueColor
+	case strings.Contains(term, "256color"):
+		return ANSI256
+	}
+	return ANSI
+}
+
+type colorKind uint8
+
+const (
+	colorDefault colorKind = iota
+	colorANSI
+	colorANSI256
+	colorRGB
+)
+
+// Color is a terminal color. The zero value is the terminal's default color.
+type Color struct {
+	kind  colorKind
+	value uint32
+}
+
+// ANSIColor returns one of the 16 basic colors. Values 0-7 are the normal
+// colors (black, red, green, yellow, blue, magenta, cyan, white) and 8-15
+// their bright variants.
+func ANSIColor(n uint8) Color {
+	return Color{kind: colorANSI, value: uint32(n & 15)}
+}
+
+// Color256 returns a color from the xterm 256-color palette.
+func Color256(n uint8) Color {
+	return Color{kind: colorANSI256, value: uint32(n)}
+}
+
+// RGB returns a 24-bit color.
+func RGB(r, g, b uint8) Color {
+	return Color{kind: colorRGB, value: uint32(r)<<16 | uint32(g)<<8 | uint32(b)}
+}
+
+// IsDefault reports whether c is the terminal's default color.
+func (c Color) IsDefault() bool {
+	return c.kind == colorDefault
+}
+
+// ansi16 holds the RGB values xterm uses for the 16 basic colors.
+var ansi16 = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the channel intensities of the 6x6x6 color cube in the
+// 256-color palette.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// rgb returns the red, green and blue components of c.
+func (c Color) rgb() (r, g, b uint8) {
+	switch c.kind {
+	case colorANSI:
+		p := ansi16[c.value]
+		return p[0], p[1], p[2]
+	case colorANSI256:
+		n := c.value
+		switch {
+		case n < 16:
+			p := ansi16[n]
+			return p[0], p[1], p[2]
+		case n < 232:
+			n -= 16
+			return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+		default:
+			v := uint8(8 + 10*(n-232))
+			return v, v, v
+		}
+	}
+	return uint8(c.value >> 16), uint8(c.value >> 8), uint8(c.value)
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr := int(r1) - int(r2)
+	dg := int(g1) - int(g2)
+	db := int(b1) - int(b2)
+	return dr*dr + dg*dg + db*db
+}
+
+// cubeIndex returns the index of the color cube level closest to v.
+func cubeIndex(v uint8) int {
+	best := 0
+	for i, l := range cubeLevels {
+		if colorDistance(v, 0, 0, l, 0, 0) < colorDistance(v, 0, 0, cubeLevels[best], 0, 0) {
+			best = i
+		}
+	}
+	return best
+}
+
+// to256 returns the palette entry closest to c, which must be an RGB color.
+func (c Color) to256() Color {
+	r, g, b := c.rgb()
+	ri, gi, bi := cubeIndex(r), cubeIndex(g), cubeIndex(b)
+	cube := Color256(uint8(16 + 36*ri + 6*gi + bi))
+
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIdx := 0
+	if avg > 8 {
+		grayIdx = min((avg-8+5)/10, 23)
+	}
+	gray := Color256(uint8(232 + grayIdx))
+
+	cr, cg, cb := cube.rgb()
+	gr, gg, gb := gray.rgb()
+	if colorDistance(r, g, b, gr, gg, gb) < colorDistance(r, g, b, cr, cg, cb) {
+		return gray
+	}
+	return cube
+}
+
+// to16 returns the basic color closest to c.
+func (c Color) to16() Color {
+	if c.kind == colorANSI256 && c.value < 16 {
+		return ANSIColor(uint8(c.value))
+	}
+	r, g, b := c.rgb()
+	best, bestDist := 0, -1
+	for i, p := range ansi16 {
+		if d := colorDistance(r, g, b, p[0], p[1], p[2]); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return ANSIColor(uint8(best))
+}
+
+// Convert returns the closest approximation of c that a terminal with the
+// given profile can display. Colors are degraded from 24-bit to the 256-color
+// palette, then to the 16 basic colors, and finally to the default color.
+func (c Color) Convert(p ColorProfile) Color {
+	if c.kind == colorDefault {
+		return c
+	}
+	switch p {
+	case NoColor:
+		return Color{}
+	case ANSI:
+		if c.kind != colorANSI {
+			return c.to16()
+		}
+	case ANSI256:
+		if c.kind == colorRGB {
+			return c.to256()
+		}
+	}
+	return c
+}
+
+// appendParams appends the SGR parameters selecting c as the foreground (or
+// background) color to b.
+func (c Color) appendParams(b []byte, background bool) []byte {
+	switch c.kind {
+	case colorANSI:
+		base := 30
+		if background {
+			base = 40
+		}
+		if c.value >= 8 {
+			base += 60 - 8
+		}
+		return strconv.AppendInt(b, int64(base)+int64(c.value), 10)
+	case colorANSI256:
+		if background {
+			b = append(b, "48;5;"...)
+		} else {
+			b = append(b, "38;5;"...)
+		}
+		return strconv.AppendInt(b, int64(c.value), 10)
+	case colorRGB:
+		if background {
+			b = append(b, "48;2;"...)
+		} else {
+			b = append(b, "38;2;"...)
+		}
+		r, g, bl := c.rgb()
+		b = strconv.AppendInt(b, int64(r), 10)
+		b = append(b, ';')
+		b = strconv.AppendInt(b, int64(g), 10)
+		b = append(b, ';')
+		return strconv.AppendInt(b, int64(bl), 10)
+	}
+	return b
+}
+
+// Style describes the appearance of a piece of text. The zero value is the
+// terminal's default appearance.
+type Style struct {
+	Foreground, Background Color
+
+	Bold, Faint, Italic, Underline, Reverse bool
+}
+
+// sgr returns the escape sequence that switches the terminal to style s, or
+// nil if s is the default style on a terminal with profile p.
+func (s Style) sgr(p ColorProfile) []byte {
+	if p == NoColor {
+		return nil
+	}
+	var params []byte
+	add := func(on bool, param string) {
+		if !on {
+			return
+		}
+		if len(params) > 0 {
+			params = append(params, ';')
+		}
+		params = append(params, param...)
+	}
+	add(s.Bold, "1")
+	add(s.Faint, "2")
+	add(s.Italic, "3")
+	add(s.Underline, "4")
+	add(s.Reverse, "7")
+	for i, c := range [2]Color{s.Foreground, s.Background} {
+		c = c.Convert(p)
+		if c.IsDefault() {
+			continue
+		}
+		if len(params) > 0 {
+			params = append(params, ';')
+		}
+		params = c.appendParams(params, i == 1)
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	seq := []byte{KeyEscape, '['}
+	seq = append(seq, params...)
+	return append(seq, 'm')
+}
+
+// Render returns text wrapped in the escape sequences needed to display it in
+// style s on a terminal with profile p. Colors the terminal can't display are
+// replaced by their closest approximation, and on NoColor terminals text is
+// returned unchanged.
+func (s Style) Render(p ColorProfile, text string) string {
+	seq := s.sgr(p)
+	if seq == nil {
+		return text
+	}
+	return string(seq) + text + string(vt100EscapeCodes.Reset)
+}
+
+// StyledSpan is a piece of text displayed in a particular style.
+type StyledSpan struct {
+	Text  string
+	Style Style
+}
+
+// renderSpans returns the concatenation of spans, each rendered for a terminal
+// with profile p.
+func renderSpans(p ColorProfile, spans []StyledSpan) string {
+	var b strings.Builder
+	for _, span := range spans {
+		b.WriteString(span.Style.Render(p, span.Text))
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+	"testing"
+)
+
+var colorConvertTests = []struct {
+	in      Color
+	profile ColorProfile
+	out     Color
+}{
+	{RGB(255, 0, 0), TrueColor, RGB(255, 0, 0)},
+	{RGB(255, 0, 0), ANSI256, Color256(196)},
+	{RGB(128, 128, 128), ANSI256, Color256(244)},
+	{RGB(255, 0, 0), ANSI, ANSIColor(9)},
+	{RGB(10, 10, 10), ANSI, ANSIColor(0)},
+	{Color256(21), ANSI, ANSIColor(4)},
+	{Color256(9), ANSI, ANSIColor(9)},
+	{ANSIColor(3), ANSI256, ANSIColor(3)},
+	{RGB(255, 0, 0), NoColor, Color{}},
+	{Color{}, ANSI, Color{}},
+}
+
+func TestColorConvert(t *testing.T) {
+	for i, test := range colorConvertTests {
+		if out := test.in.Convert(test.profile); out != test.out {
+			t.Errorf("test %d: got %+v, expected %+v", i, out, test.out)
+		}
+	}
+}
+
+var detectColorProfileTests = []struct {
+	term, colorterm string
+	profile         ColorProfile
+}{
+	{"", "", NoColor},
+	{"dumb", "truecolor", NoColor},
+	{"linux", "", ANSI},
+	{"xterm", "", ANSI},
+	{"xterm-256color", "", ANSI256},
+	{"tmux-256color", "", ANSI256},
+	{"xterm-256color", "truecolor", TrueColor},
+	{"xterm-direct", "", TrueColor},
+}
+
+func TestDetectColorProfile(t *testing.T) {
+	for i, test := range detectColorProfileTests {
+		env := map[string]string{"TERM": test.term, "COLORTERM": test.colorterm}
+		getenv := func(key string) string { return env[key] }
+		if p := detectColorProfile(getenv); p != test.profile {
+			t.Errorf("test %d: got %d, expected %d", i, p, test.profile)
+		}
+	}
+}
+
+func TestStyleRender(t *testing.T) {
+	s := Style{Foreground: RGB(255, 0, 0), Bold: true}
+	tests := []struct {
+		profile ColorProfile
+		out     string
+	}{
+		{TrueColor, "\x1b[1;38;2;255;0;0mhi\x1b[0m"},
+		{ANSI256, "\x1b[1;38;5;196mhi\x1b[0m"},
+		{ANSI, "\x1b[1;91mhi\x1b[0m"},
+		{NoColor, "hi"},
+	}
+	for _, test := range tests {
+		if out := s.Render(test.profile, "hi"); out != test.out {
+			t.Errorf("profile %d: got %q, expected %q", test.profile, out, test.out)
+		}
+	}
+
+	if out := (Style{}).Render(TrueColor, "hi"); out != "hi" {
+		t.Errorf("default style rendered as %q", out)
+	}
+}
+
+var colorEnvTests = []struct {
+	env     map[string]string
+	in, out ColorProfile
+}{
+	{map[string]string{}, ANSI256, ANSI256},
+	{map[string]string{"NO_COLOR": "1"}, TrueColor, NoColor},
+	{map[string]string{"NO_COLOR": ""}, TrueColor, TrueColor},
+	{map[string]string{"CLICOLOR": "0"}, ANSI, NoColor},
+	{map[string]string{"CLICOLOR": "1"}, ANSI, ANSI},
+	{map[string]string{"CLICOLOR_FORCE": "1"}, NoColor, ANSI},
+	{map[string]string{"CLICOLOR_FORCE": "1", "CLICOLOR": "0"}, ANSI256, ANSI256},
+	{map[string]string{"CLICOLOR_FORCE": "0"}, NoColor, NoColor},
+	{map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, ANSI, NoColor},
+}
+
+func TestApplyColorEnv(t *testing.T) {
+	for i, test := range colorEnvTests {
+		getenv := func(key string) string { return test.env[key] }
+		if p := applyColorEnv(test.in, getenv); p != test.out {
+			t.Errorf("test %d: got %d, expected %d", i, p, test.out)
+		}
+	}
+}
+
+func TestNoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	term := NewTerminal(&MockTerminal{}, "> ", true)
+	if p := term.ColorProfile(); p != NoColor {
+		t.Errorf("got profile %d with NO_COLOR set", p)
+	}
+	if len(term.Escape.Red) != 0 {
+		t.Errorf("escape codes not empty with NO_COLOR set")
+	}
+
+	term.SetColorProfile(ANSI)
+	if len(term.Escape.Red) == 0 {
+		t.Errorf("escape codes empty after overriding the color profile")
+	}
+}
+
+func TestSetPromptStyled(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("x")}
+	ss := NewTerminal(c, "", true)
+	ss.SetColorProfile(ANSI)
+	ss.SetPromptStyled(
+		StyledSpan{Text: "user", Style: Style{Foreground: RGB(0, 255, 0)}},
+		StyledSpan{Text: "> "},
+	)
+	if want := "\x1b[92muser\x1b[0m> "; ss.prompt != want {
+		t.Errorf("got prompt %q, expected %q", ss.prompt, want)
+	}
+
+	ss.SetColorProfile(NoColor)
+	if want := "user> "; ss.prompt != want {
+		t.Errorf("got prompt %q after disabling color, expected %q", ss.prompt, want)
+	}
+
+	ss.SetColorProfile(TrueColor)
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Fatalf("got error %v, expected EOF", err)
+	}
+	if ss.cursorX != 7 {
+		t.Errorf("cursor at %d, expected 7", ss.cursorX)
+	}
+
+	ss.SetPrompt("$ ")
+	ss.SetColorProfile(ANSI)
+	if ss.prompt != "$ " {
+		t.Errorf("plain prompt replaced by %q", ss.prompt)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+// Suspend stops the program, as Ctrl-Z does for a program on a local terminal
+// in cooked mode, and returns when it's continued, e.g. by the shell's fg
+// command. The terminal's original settings are restored in the meantime, and
+// the prompt and line are painted again afterwards. Pressing Ctrl-Z while a
+// line is read calls Suspend.
+//
+// Suspend is only supported on local terminals from NewWithStdInOut, on Unix
+// systems, and returns ErrNotSupported otherwise.
+func (t *Terminal) Suspend() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.suspend()
+}
+
+// suspend implements Suspend. t.lock must be held, and is held while the
+// program is stopped.
+func (t *Terminal) suspend() error {
+	if t.cooked == nil || !canStop {
+		return ErrNotSupported
+	}
+
+	// The line stays on the screen, with the shell's output below it.
+	editing := t.editing()
+	if editing {
+		t.moveCursorToPos(len(t.line))
+	}
+	if len(t.footer) > 0 {
+		t.clearToEndOfScreen()
+	}
+	if editing {
+		t.queue([]byte("\r\n"))
+	}
+	t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+	if err := t.flush(); err != nil {
+		return err
+	}
+	if err := Restore(t.ttyFd, t.cooked); err != nil {
+		return err
+	}
+
+	stop()
+
+	// The program was continued, and the shell may have changed the
+	// terminal's settings.
+	if _, err := MakeRaw(t.ttyFd); err != nil {
+		return err
+	}
+	t.queue(hideCursor)
+	if editing {
+		t.repaint()
+	}
+	if len(t.footer) > 0 {
+		t.drawFooter(editing)
+	}
+	t.queue(showCursor)
+	return t.flush()
+}
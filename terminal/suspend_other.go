@@ -0,0 +1,13 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package terminal
+
+// canStop reports whether stop is implemented.
+const canStop = false
+
+// stop isn't supported on this system.
+func stop() {}
@@ -0,0 +1,23 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+func TestSuspendNotLocal(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("a\x1ab\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	if err := ss.Suspend(); err != ErrNotSupported {
+		t.Errorf("got %v, expected ErrNotSupported", err)
+	}
+	// Ctrl-Z doesn't stop the test, and isn't inserted.
+	line, err := ss.ReadLine()
+	if err != nil || line != "ab" {
+		t.Errorf("got %q, %v, expected \"ab\"", line, err)
+	}
+}
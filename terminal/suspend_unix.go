@@ -0,0 +1,24 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package terminal
+
+import (
+	"os/signal"
+	"syscall"
+)
+
+// canStop reports whether stop is implemented.
+const canStop = true
+
+// stop stops the program's process group with SIGTSTP and returns once it's
+// continued. If the program asked to be notified of SIGTSTP, the signal is
+// reset to its default action in the meantime, and it's up to the program to
+// ask again.
+func stop() {
+	signal.Reset(syscall.SIGTSTP)
+	syscall.Kill(0, syscall.SIGTSTP)
+}
@@ -0,0 +1,61 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "bytes"
+
+// TabAction is what pressing Tab does in the line editor.
+type TabAction int
+
+const (
+	// TabComplete passes Tab to the AutoCompleteCallback, as any other
+	// key. This is the default.
+	TabComplete TabAction = iota
+	// TabInsertTab inserts a tab character.
+	TabInsertTab
+	// TabInsertSpaces inserts spaces.
+	TabInsertSpaces
+)
+
+// defaultTabSpaces is the number of spaces TabInsertSpaces inserts unless
+// specified otherwise.
+const defaultTabSpaces = 4
+
+// SetTabAction sets what Tab does: atLineStart when only spaces and tabs
+// precede the cursor on its row, and action elsewhere. A REPL for code may
+// indent with TabInsertSpaces at the start of a line and complete with
+// TabComplete after some text. spaces is the number of spaces inserted by
+// TabInsertSpaces; 0 means 4.
+func (t *Terminal) SetTabAction(action, atLineStart TabAction, spaces int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if spaces <= 0 {
+		spaces = defaultTabSpaces
+	}
+	t.tabAction, t.tabActionAtLineStart, t.tabSpaces = action, atLineStart, spaces
+}
+
+// tabKey handles Tab unless it's to be completed, and reports whether it did.
+// t.lock must be held.
+func (t *Terminal) tabKey() bool {
+	if t.secret || t.mask != nil {
+		return false
+	}
+	action := t.tabAction
+	rowStart := bytes.LastIndexByte(t.line[:t.pos], '\n') + 1
+	if len(bytes.Trim(t.line[rowStart:t.pos], " \t")) == 0 {
+		action = t.tabActionAtLineStart
+	}
+	switch action {
+	case TabInsertTab:
+		t.insert([]byte{'\t'})
+	case TabInsertSpaces:
+		t.insert(bytes.Repeat([]byte{' '}, t.tabSpaces))
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "testing"
+
+func TestTabAction(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("\t\tif\tx\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	var completed int
+	ss.AutoCompleteCallback = func(line []byte, pos, key int) ([]byte, int) {
+		if key == '\t' {
+			completed++
+		}
+		return nil, 0
+	}
+	ss.SetTabAction(TabComplete, TabInsertSpaces, 2)
+	if line, err := ss.ReadLine(); err != nil || line != "    ifx" {
+		t.Errorf("got %q, %v", line, err)
+	}
+	if completed != 1 {
+		t.Errorf("Tab was completed %d times, expected once after the text", completed)
+	}
+
+	c.toSend = []byte("a\tb\r")
+	ss.SetTabAction(TabInsertTab, TabComplete, 0)
+	if line, err := ss.ReadLine(); err != nil || line != "a\tb" {
+		t.Errorf("got %q, %v, expected a tab", line, err)
+	}
+}
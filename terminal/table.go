@@ -0,0 +1,187 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"slices"
+	"strings"
+)
+
+// Alignment says on which side of a table column its cells are aligned.
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignRight
+)
+
+// columnGap is the number of spaces between table columns.
+const columnGap = 2
+
+// Table lays out rows of cells in aligned columns. Cells may contain escape
+// sequences, e.g. from Style.Render, which take up no space.
+type Table struct {
+	// Header, if not empty, is shown above the rows in HeaderStyle.
+	Header      []string
+	HeaderStyle Style
+
+	Rows [][]string
+
+	// Align gives the alignment of each column. Columns not listed are
+	// aligned to the left.
+	Align []Alignment
+
+	// Wrap makes cells that don't fit into their column continue on
+	// further lines. Otherwise they are cut short and end in "…".
+	Wrap bool
+}
+
+// Render returns the table laid out to fit into width columns, with each line
+// ending in "\r\n". When the table is too wide, the widest columns are narrowed
+// first. Text is styled for a terminal with profile p.
+func (tab *Table) Render(p ColorProfile, width int) string {
+	n := len(tab.Header)
+	for _, row := range tab.Rows {
+		n = max(n, len(row))
+	}
+	if n == 0 {
+		return ""
+	}
+
+	widths := make([]int, n)
+	measure := func(row []string) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], stringWidth(cell))
+		}
+	}
+	measure(tab.Header)
+	for _, row := range tab.Rows {
+		measure(row)
+	}
+	natural := slices.Clone(widths)
+	fitColumns(widths, width-columnGap*(n-1))
+	if tab.Wrap {
+		// A column narrower than a wide rune can't hold it.
+		for i := range widths {
+			widths[i] = max(widths[i], min(natural[i], 2))
+		}
+	}
+
+	var b strings.Builder
+	if len(tab.Header) > 0 {
+		tab.writeRow(&b, p, tab.Header, widths, tab.HeaderStyle)
+	}
+	for _, row := range tab.Rows {
+		tab.writeRow(&b, p, row, widths, Style{})
+	}
+	return b.String()
+}
+
+// fitColumns narrows columns so that their widths add up to no more than
+// avail. Columns narrower than an even share of the space keep their width,
+// the rest share what's left.
+func fitColumns(widths []int, avail int) {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	if total <= avail {
+		return
+	}
+
+	fixed := make([]bool, len(widths))
+	left := len(widths)
+	for changed := true; changed && left > 0; {
+		changed = false
+		share := max(avail/left, 1)
+		for i, w := range widths {
+			if !fixed[i] && w <= share {
+				fixed[i] = true
+				avail -= w
+				left--
+				changed = true
+			}
+		}
+	}
+	if left == 0 {
+		return
+	}
+	share, extra := avail/left, avail%left
+	if share < 1 {
+		share, extra = 1, 0
+	}
+	for i := range widths {
+		if fixed[i] {
+			continue
+		}
+		widths[i] = share
+		if extra > 0 {
+			widths[i]++
+			extra--
+		}
+	}
+}
+
+func (tab *Table) writeRow(b *strings.Builder, p ColorProfile, row []string, widths []int, style Style) {
+	cells := make([][]string, len(widths))
+	height := 1
+	for i, w := range widths {
+		var cell string
+		if i < len(row) {
+			cell = row[i]
+		}
+		if tab.Wrap {
+			cells[i] = strings.Split(Wrap(cell, w), "\n")
+		} else {
+			cells[i] = []string{ellipsize(cell, w)}
+		}
+		height = max(height, len(cells[i]))
+	}
+
+	var line strings.Builder
+	for l := 0; l < height; l++ {
+		line.Reset()
+		for i, w := range widths {
+			var cell string
+			if l < len(cells[i]) {
+				cell = cells[i][l]
+			}
+			if i > 0 {
+				line.WriteString(strings.Repeat(" ", columnGap))
+			}
+			pad := strings.Repeat(" ", max(w-stringWidth(cell), 0))
+			if i < len(tab.Align) && tab.Align[i] == AlignRight {
+				line.WriteString(pad)
+				line.WriteString(cell)
+				continue
+			}
+			line.WriteString(cell)
+			if i < len(widths)-1 {
+				line.WriteString(pad)
+			}
+		}
+		b.WriteString(style.Render(p, strings.TrimRight(line.String(), " ")))
+		b.WriteString("\r\n")
+	}
+}
+
+// ellipsize returns s cut short to width columns, ending in "…" if anything
+// was left out.
+func ellipsize(s string, width int) string {
+	if width <= 1 || stringWidth(s) <= width {
+		return truncate(s, width)
+	}
+	return truncate(s, width-1) + "…"
+}
+
+// WriteTable writes tab to the terminal, laid out to fit its width.
+func (t *Terminal) WriteTable(tab *Table) error {
+	t.lock.Lock()
+	width, p := t.termWidth-1, t.colorProfile
+	t.lock.Unlock()
+
+	_, err := t.Write([]byte(tab.Render(p, width)))
+	return err
+}
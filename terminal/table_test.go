@@ -0,0 +1,87 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"reflect"
+	"testing"
+)
+
+var fitColumnsTests = []struct {
+	widths []int
+	avail  int
+	want   []int
+}{
+	{[]int{3, 5}, 10, []int{3, 5}},
+	{[]int{3, 20}, 13, []int{3, 10}},
+	{[]int{20, 3, 20}, 23, []int{10, 3, 10}},
+	{[]int{20, 20}, 11, []int{6, 5}},
+	{[]int{5, 5, 5}, 2, []int{1, 1, 1}},
+}
+
+func TestFitColumns(t *testing.T) {
+	for i, test := range fitColumnsTests {
+		widths := append([]int(nil), test.widths...)
+		fitColumns(widths, test.avail)
+		if !reflect.DeepEqual(widths, test.want) {
+			t.Errorf("test %d: got %v, expected %v", i, widths, test.want)
+		}
+	}
+}
+
+func TestTableRender(t *testing.T) {
+	tab := &Table{
+		Header: []string{"NAME", "SIZE"},
+		Rows: [][]string{
+			{"a.txt", "12"},
+			{"photo.jpg", "2048"},
+		},
+		Align: []Alignment{AlignLeft, AlignRight},
+	}
+	want := "NAME       SIZE\r\n" +
+		"a.txt        12\r\n" +
+		"photo.jpg  2048\r\n"
+	if got := tab.Render(NoColor, 80); got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+
+	tab.HeaderStyle = Style{Bold: true}
+	want = "\x1b[1mNAME       SIZE\x1b[0m\r\n"
+	if got := tab.Render(ANSI, 80); got[:len(want)] != want {
+		t.Errorf("got %q, expected it to start with %q", got, want)
+	}
+}
+
+func TestTableNarrow(t *testing.T) {
+	tab := &Table{Rows: [][]string{{"id", "a long description"}}}
+	want := "id  a long…\r\n"
+	if got := tab.Render(NoColor, 11); got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+
+	tab.Wrap = true
+	want = "id  a long\r\n    descrip\r\n    tion\r\n"
+	if got := tab.Render(NoColor, 11); got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
+
+func TestTableWideRunes(t *testing.T) {
+	// The first column can't be narrower than 日.
+	tab := &Table{Rows: [][]string{{"日本", "x"}}, Wrap: true}
+	want := "日  x\r\n本\r\n"
+	if got := tab.Render(ANSI, 3); got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.WriteTable(&Table{Rows: [][]string{{"a", "b"}}})
+	if got, want := string(c.received), "a  b\r\n"; got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
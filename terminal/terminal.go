@@ -5,10 +5,15 @@
 package terminal
 
 import (
-	"fmt"
+	"bytes"
+	"errors"
 	"io"
 	"os"
+	"strconv"
 	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 func max(i, j int) int {
@@ -65,20 +70,57 @@ type Terminal struct {
 	// Otherwise it returns a replacement line and the new cursor position.
 	AutoCompleteCallback func(line []byte, pos, key int) (newLine []byte, newPos int)
 
+	// AcceptCallback, if non-null, is called with the full input when
+	// Enter is pressed. If it reports that the input is incomplete, e.g.
+	// because of unbalanced braces, a newline is inserted and editing
+	// continues after the continuation prompt instead of returning the
+	// line. Alt-Enter always returns the line.
+	AcceptCallback func(line string) (complete bool)
+
+	// PasswordPolicy, if non-nil, is called by ReadNewPassword with the
+	// password the user entered. A non-nil error rejects it; the error's
+	// text is shown to the user, who is asked for another password.
+	PasswordPolicy func(password string) error
+
 	// Escape contains a pointer to the escape codes for this terminal.
 	// It's always a valid pointer, although the escape codes themselves
 	// may be empty if the terminal doesn't support them.
 	Escape *EscapeCodes
 
+	// colorProfile determines how styled text is rendered by Render.
+	colorProfile ColorProfile
+
 	// lock protects the terminal and the state in this object from
 	// concurrent processing of a key press and a Write() call.
 	lock sync.Mutex
 
 	c      io.ReadWriter
 	prompt string
+	// promptSpans, if non-nil, holds the styled prompt that prompt was
+	// rendered from.
+	promptSpans []StyledSpan
+	// rightPrompt is displayed flush right on the input row while the line
+	// leaves room for it. rightPromptShown is true while it's on screen.
+	rightPrompt      string
+	rightPromptWidth int
+	rightPromptShown bool
+	// continuationPrompt replaces prompt while the application has marked
+	// the input as incomplete.
+	continuationPrompt string
+	incomplete         bool
+	// multiLine is true if Enter inserts a newline into the buffer rather
+	// than submitting it.
+	multiLine bool
+	// promptMinInput, if non-zero, is the number of columns that the
+	// prompt is truncated to leave for the line.
+	promptMinInput int
+	// promptLayout and continuationLayout cache where the prompts end.
+	promptLayout, continuationLayout promptLayout
 
 	// line is the current line being entered.
 	line []byte
+	// lineScratch is spare storage for building a replacement line.
+	lineScratch []byte
 	// history is a buffer of previously entered lines
 	history [][]byte
 	// index into the history buffer (for use in the handleKey(KeyUp) function)
@@ -87,6 +129,85 @@ type Terminal struct {
 	pos int
 	// echo is true if local echo is enabled
 	echo bool
+	// mask, if non-nil, is displayed in place of each rune of line, e.g.
+	// while reading a password.
+	mask []byte
+	// secret is true while reading a password. The password is then
+	// returned in secretLine rather than as a string, so that it can be
+	// wiped from memory.
+	secret     bool
+	secretLine []byte
+	// statusLine is shown on the bottom row, if it isn't empty.
+	statusLine string
+	// footer holds the lines of spinners and progress bars, which are
+	// shown below the line being edited.
+	footer []*footerLine
+	// visualBell makes the bell flash the screen rather than beep.
+	// bellOnError rings it when a key can't be acted upon.
+	visualBell, bellOnError bool
+	// flowControl makes Ctrl-S and Ctrl-Q pause and resume the output,
+	// which is held in outBuf while outputPaused is set.
+	flowControl, outputPaused bool
+	// bindings maps keys to the keys whose action they take, from
+	// BindKey.
+	bindings map[int]int
+	// inputrcVars holds the variables set by LoadInputrc.
+	inputrcVars map[string]string
+	// historyExpansion expands references to lines of history, which
+	// start with historyChars[0], or historyChars[1] for a quick
+	// substitution, when Enter is pressed. historyVerify leaves an
+	// expanded line to be edited.
+	historyExpansion, historyVerify bool
+	historyChars                    [2]byte
+	// abbreviations maps the abbreviations from SetAbbreviation to their
+	// expansions.
+	abbreviations map[string]string
+	// snippets maps the names of snippets to their text, and snippetKeys
+	// the keys bound to them. snippetFields holds the offsets of the
+	// fields of the snippet being filled in that are yet to be reached,
+	// for the line as it was snippetLen bytes long.
+	snippets      map[string]string
+	snippetKeys   map[int]string
+	snippetFields []int
+	snippetLen    int
+	// autoPair inserts the closing bracket or quote along with the
+	// opening one.
+	autoPair bool
+	// tabAction is what Tab does, or tabActionAtLineStart after nothing
+	// but whitespace on the cursor's row. tabSpaces is the number of
+	// spaces inserted by TabInsertSpaces.
+	tabAction, tabActionAtLineStart TabAction
+	tabSpaces                       int
+	// bidi reorders right-to-left text for display. bidiShown is set
+	// while the line on the screen holds some.
+	bidi, bidiShown bool
+	// hscroll keeps the line on a single row, scrolled so that the
+	// cursor is in view. scrollCol is the column of the line shown first,
+	// and scrollDirty is set when the line has changed since it was drawn.
+	hscroll     bool
+	scrollCol   int
+	scrollDirty bool
+	// notifyOSC9 makes Notify use OSC 9, which iTerm2 understands, rather
+	// than OSC 777.
+	notifyOSC9 bool
+	// onlcr makes Write turn line feeds into "\r\n", like the tty's ONLCR
+	// flag does outside raw mode.
+	onlcr bool
+	// transcriptOut and transcriptIn receive copies of the output and
+	// input, see SetTranscript. redacted is set once the input has been
+	// replaced by a marker while a password is read.
+	transcriptOut, transcriptIn io.Writer
+	redacted                    bool
+	// recorder is the Recorder started by Record, if any.
+	recorder *Recorder
+	// passwordMask is the mask used by ReadPasswordMasked.
+	passwordMask []byte
+	// revealDuration is how long the last character typed into a masked
+	// line is shown. revealPos is the offset of the character on screen,
+	// or -1, and revealGen identifies the timer that hides it again.
+	revealDuration time.Duration
+	revealPos      int
+	revealGen      int
 
 	// cursorX contains the current X value of the cursor where the left
 	// edge is 0. cursorY contains the row number where the first row of
@@ -100,31 +221,114 @@ type Terminal struct {
 	// outBuf contains the terminal data to be sent.
 	outBuf []byte
 	// remainder contains the remainder of any partial key sequences after
-	// a read. It aliases into inBuf.
+	// a read. It aliases into inBuf, which grows if a partial sequence
+	// fills it.
 	remainder []byte
-	inBuf     [256]byte
+	inBuf     []byte
+	// reading is true while a line is being read. readingRaw is set as
+	// well while Read reads input for the application.
+	reading, readingRaw bool
+	// passthrough is set while line editing is stopped by
+	// EnterPassthrough. passthroughEditing records whether a line was
+	// being edited.
+	passthrough, passthroughEditing bool
+	// escapeKey, if non-zero, switches between line editing and
+	// passthrough mode.
+	escapeKey byte
+	// pendingQuery is waiting for the terminal's reply to a request and
+	// inFlight delivers the result of a read it started. queryLock
+	// allows one query at a time.
+	pendingQuery *query
+	inFlight     chan readResult
+	queryLock    sync.Mutex
+	// events, if non-nil, delivers the events read by Events. eventLock
+	// guards it for post, and the closing of the stream.
+	events    *eventStream
+	eventLock sync.Mutex
+	// readErr is an error returned by a read together with data. It's
+	// returned once the data has been processed.
+	readErr error
+	// ttyFd is the local terminal the Terminal runs on, or -1, and cooked
+	// holds its settings from before NewWithStdInOut put it into raw mode.
+	ttyFd  int
+	cooked *State
+	// stopResize stops following the size of the local terminal.
+	stopResize func()
+	// onResize is called with the new size when it changes.
+	onResize func(width, height int)
+	// onBeforeRead and onAfterRead are called around reading a line.
+	onBeforeRead func()
+	onAfterRead  func(line string)
+	// onInterrupt decides what Ctrl-C does. interrupted is set when it
+	// ends reading with ErrInterrupted.
+	onInterrupt func(state LineState) Interrupt
+	interrupted bool
+	// onIdle is called when no key has been pressed for idleDuration
+	// while reading a line. idleGen identifies the current wait.
+	onIdle       func()
+	idleDuration time.Duration
+	idleGen      int
+	// drawnPrompt is the prompt as it was last drawn. promptDrawn is set
+	// while it's on the screen in front of the line being read.
+	drawnPrompt string
+	promptDrawn bool
+	// pump, if non-nil, writes the output in a goroutine of its own.
+	pump *outputPump
+	// keyHooks holds the hooks registered for each key, in order.
+	keyHooks map[int][]*keyHook
 }
 
 // NewTerminal runs a VT100 terminal on the given ReadWriter. If the ReadWriter is
 // a local terminal, that terminal must first have been put into raw mode.
 // prompt is a string that is written at the start of each input line (i.e.
-// "> ").
+// "> "). Color is disabled if the NO_COLOR or CLICOLOR conventions ask for
+// it; see SetColorProfile.
 func NewTerminal(c io.ReadWriter, prompt string, echo bool) *Terminal {
+	profile := applyColorEnv(ANSI, os.Getenv)
+	escape := &vt100EscapeCodes
+	if profile == NoColor {
+		escape = &EscapeCodes{}
+	}
 	return &Terminal{
-		Escape:     &vt100EscapeCodes,
-		c:          c,
-		prompt:     prompt,
-		history:    make([][]byte, 0, 100),
-		historyIdx: -1,
-		termWidth:  80,
-		termHeight: 24,
-		echo:       echo,
+		Escape:             escape,
+		colorProfile:       profile,
+		c:                  c,
+		prompt:             prompt,
+		continuationPrompt: "... ",
+		history:            make([][]byte, 0, 100),
+		historyIdx:         -1,
+		termWidth:          80,
+		termHeight:         24,
+		echo:               echo,
+		notifyOSC9:         os.Getenv("TERM_PROGRAM") == "iTerm.app",
+		passwordMask:       defaultPasswordMask,
+		revealPos:          -1,
+		inBuf:              make([]byte, 256),
+		ttyFd:              -1,
 	}
 }
 
+// ErrConcurrentRead is returned when input is to be read while another
+// goroutine is reading from the terminal already. Only one goroutine at
+// a time may read; others may still call Write and the methods that change the
+// line being read.
+var ErrConcurrentRead = errors.New("terminal: input is already being read")
+
+// ErrInterrupted is returned when the user presses Ctrl-C, unless the
+// function set by OnInterrupt decides otherwise.
+var ErrInterrupted = errors.New("control-c break")
+
 const (
+	// KeyCtrlSpace inserts a space without expanding an abbreviation.
+	KeyCtrlSpace = 0
 	KeyCtrlC     = 3
 	KeyCtrlD     = 4
+	// KeyCtrlQ resumes the output paused by KeyCtrlS, if flow control is on.
+	KeyCtrlQ = 17
+	// KeyCtrlS finishes editing in a TextArea.
+	KeyCtrlS = 19
+	// KeyCtrlZ suspends a program on a local terminal from NewWithStdInOut.
+	KeyCtrlZ     = 26
 	KeyEnter     = '\r'
 	KeyEscape    = 27
 	KeyBackspace = 127
@@ -135,66 +339,58 @@ const (
 	KeyDown
 	KeyAltLeft
 	KeyAltRight
+	KeyAltEnter
+	// KeyRune is a character beyond ASCII, whose UTF-8 encoding is the
+	// key's sequence.
+	KeyRune
 )
 
 // bytesToKey tries to parse a key sequence from b. If successful, it returns
 // the key and the remainder of the input. Otherwise it returns -1.
 func bytesToKey(b []byte) (int, []byte) {
-	if len(b) == 0 {
-		return -1, nil
-	}
-
-	if b[0] != KeyEscape {
-		return int(b[0]), b[1:]
+	key, n := DecodeKey(b)
+	if key < 0 {
+		return -1, b
 	}
+	return key, b[n:]
+}
 
-	if len(b) >= 3 && b[0] == KeyEscape && b[1] == '[' {
-		switch b[2] {
-		case 'A':
-			return KeyUp, b[3:]
-		case 'B':
-			return KeyDown, b[3:]
-		case 'C':
-			return KeyRight, b[3:]
-		case 'D':
-			return KeyLeft, b[3:]
-		}
-	}
+// queue appends data to the end of t.outBuf
+func (t *Terminal) queue(data []byte) {
+	t.outBuf = append(t.outBuf, data...)
+}
 
-	if len(b) >= 6 &&
-		b[0] == KeyEscape &&
-		b[1] == '[' &&
-		b[2] == '1' &&
-		b[3] == ';' &&
-		b[4] == '3' {
-		switch b[5] {
-		case 'C':
-			return KeyAltRight, b[6:]
-		case 'D':
-			return KeyAltLeft, b[6:]
-		}
+// flush writes out the data in t.outBuf.
+func (t *Terminal) flush() error {
+	if len(t.outBuf) == 0 || t.outputPaused {
+		return nil
 	}
+	t.recordOutput(t.outBuf)
+	_, err := t.output(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+	return err
+}
 
-	// If we get here then we have a key that we don't recognise, or a
-	// partial sequence. It's not clear how one should find the end of a
-	// sequence without knowing them all, but it seems that [a-zA-Z] only
-	// appears at the end of a sequence.
-	for i, c := range b[0:] {
-		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
-			return KeyUnknown, b[i+1:]
-		}
+// showPrompt writes the prompt, unless something is on the screen already.
+func (t *Terminal) showPrompt() {
+	if t.cursorX == 0 && t.cursorY == 0 {
+		t.drawPrompt()
 	}
-
-	return -1, b
 }
 
-// queue appends data to the end of t.outBuf
-func (t *Terminal) queue(data []byte) {
-	t.outBuf = append(t.outBuf, data...)
+// drawPrompt writes the prompt and records what it looked like.
+func (t *Terminal) drawPrompt() {
+	t.drawnPrompt = t.shownPrompt()
+	t.promptDrawn = true
+	t.writeText([]byte(t.drawnPrompt))
 }
 
-var eraseUnderCursor = []byte{' ', KeyEscape, '[', 'D'}
-var space = []byte{' '}
+var newline = []byte{'\n'}
+
+// hideCursor and showCursor are wrapped around repaints that take several
+// steps, so that the cursor isn't seen jumping around.
+var hideCursor = []byte{KeyEscape, '[', '?', '2', '5', 'l'}
+var showCursor = []byte{KeyEscape, '[', '?', '2', '5', 'h'}
 
 func isPrintable(key int) bool {
 	return key >= 32 && key < 127
@@ -207,9 +403,10 @@ func (t *Terminal) moveCursorToPos(pos int) {
 		return
 	}
 
-	x := len(t.prompt) + pos
-	y := x / t.termWidth
-	x = x % t.termWidth
+	if t.scrolling() && (t.scroll(pos) || t.scrollDirty) {
+		t.drawScrolled()
+	}
+	x, y := t.posToXY(pos)
 
 	up := 0
 	if y < t.cursorY {
@@ -221,50 +418,67 @@ func (t *Terminal) moveCursorToPos(pos int) {
 		down = y - t.cursorY
 	}
 
-	left := 0
-	if x < t.cursorX {
-		left = t.cursorX - x
-	}
-
-	right := 0
-	if x > t.cursorX {
-		right = x - t.cursorX
-	}
-
+	t.move(up, down, 0, 0)
+	t.moveToColumn(t.cursorX, x)
 	t.cursorX = x
 	t.cursorY = y
-	t.move(up, down, left, right)
 }
 
+// move appends data to t.outBuf which will move the cursor by the given
+// number of rows and columns. Moves of more than one cell use a single
+// parameterized sequence, so moving 70 columns takes 5 bytes rather than 210;
+// on a 9600 baud serial link that's 5ms instead of over 200ms.
 func (t *Terminal) move(up, down, left, right int) {
-	movement := make([]byte, 3*(up+down+left+right))
-	m := movement
-	for i := 0; i < up; i++ {
-		m[0] = KeyEscape
-		m[1] = '['
-		m[2] = 'A'
-		m = m[3:]
+	t.queueMove(up, 'A')
+	t.queueMove(down, 'B')
+	t.queueMove(left, 'D')
+	t.queueMove(right, 'C')
+}
+
+// moveToColumn appends data to t.outBuf which will move the cursor from
+// column from to column to on the same row. The column is addressed
+// absolutely if that takes fewer bytes than a relative movement, which is the
+// case for large jumps along the long rows of a wrapped line.
+func (t *Terminal) moveToColumn(from, to int) {
+	switch {
+	case to == from:
+		return
+	case to == 0:
+		t.outBuf = append(t.outBuf, '\r')
+		return
 	}
-	for i := 0; i < down; i++ {
-		m[0] = KeyEscape
-		m[1] = '['
-		m[2] = 'B'
-		m = m[3:]
+
+	n := to - from
+	dir := byte('C')
+	if n < 0 {
+		n, dir = -n, 'D'
 	}
-	for i := 0; i < left; i++ {
-		m[0] = KeyEscape
-		m[1] = '['
-		m[2] = 'D'
-		m = m[3:]
+	relative := 3
+	if n > 1 {
+		relative += len(strconv.Itoa(n))
 	}
-	for i := 0; i < right; i++ {
-		m[0] = KeyEscape
-		m[1] = '['
-		m[2] = 'C'
-		m = m[3:]
+	if absolute := 3 + len(strconv.Itoa(to+1)); absolute < relative {
+		t.outBuf = append(t.outBuf, KeyEscape, '[')
+		t.outBuf = strconv.AppendInt(t.outBuf, int64(to+1), 10)
+		t.outBuf = append(t.outBuf, 'G')
+		return
 	}
+	t.queueMove(n, dir)
+}
 
-	t.queue(movement)
+// queueMove appends the control sequence moving the cursor n cells in the
+// direction given by its final byte.
+func (t *Terminal) queueMove(n int, dir byte) {
+	switch {
+	case n <= 0:
+		return
+	case n == 1:
+		t.outBuf = append(t.outBuf, KeyEscape, '[', dir)
+	default:
+		t.outBuf = append(t.outBuf, KeyEscape, '[')
+		t.outBuf = strconv.AppendInt(t.outBuf, int64(n), 10)
+		t.outBuf = append(t.outBuf, dir)
+	}
 }
 
 func (t *Terminal) clearLineToRight() {
@@ -272,29 +486,94 @@ func (t *Terminal) clearLineToRight() {
 	t.queue(op)
 }
 
+func (t *Terminal) clearToEndOfScreen() {
+	op := []byte{KeyEscape, '[', 'J'}
+	t.queue(op)
+	// That clears the status line as well, which is outside of the scroll
+	// region.
+	t.drawStatusLine()
+}
+
 const maxLineLength = 4096
 
 // handleKey processes the given key and, optionally, returns a line of text
 // that the user has entered.
 func (t *Terminal) handleKey(key int) (line string, ok bool) {
+	t.conceal()
+	if action, ok := t.bindings[key]; ok {
+		key = action
+	}
+	if t.keyHooks[key] != nil && t.runKeyHooks(key) == Consume {
+		return
+	}
+	if key == KeyCtrlZ && t.cooked != nil {
+		t.suspend()
+		return
+	}
+	if t.flowControl && (key == KeyCtrlS || key == KeyCtrlQ) {
+		t.pauseOutput(key == KeyCtrlS)
+		return
+	}
+	if name, bound := t.snippetKeys[key]; bound {
+		t.insertSnippet(name)
+		return
+	}
+	if t.snippetFields != nil {
+		switch key {
+		case '\t':
+			t.nextSnippetField()
+			return
+		case KeyEnter, KeyAltEnter, KeyUp, KeyDown, KeyCtrlC, KeyCtrlD:
+			t.snippetFields = nil
+		}
+	}
+	if key == '\t' && t.tabKey() {
+		return
+	}
+	if t.autoPair && t.pairKey(key) {
+		return
+	}
+	if len(t.abbreviations) > 0 {
+		switch key {
+		case ' ', KeyEnter:
+			t.expandAbbreviation()
+		case KeyCtrlSpace:
+			key = ' '
+		}
+	}
 	switch key {
 	case KeyBackspace:
 		if t.pos == 0 {
+			t.invalidKey()
 			return
 		}
-		t.pos--
+		// A character is deleted along with its combining marks.
+		end := t.pos
+		t.pos = prevCharacter(t.line, t.pos)
 		t.moveCursorToPos(t.pos)
 
-		copy(t.line[t.pos:], t.line[1+t.pos:])
-		t.line = t.line[:len(t.line)-1]
+		width := t.textWidth(t.line[t.pos:end], t.cursorX)
+		multiLine := t.line[t.pos] == '\n' || bytes.IndexByte(t.line[t.pos:], '\n') >= 0
+		n := copy(t.line[t.pos:], t.line[end:])
+		t.line = t.line[:t.pos+n]
 		if t.echo {
 			t.writeLine(t.line[t.pos:])
 		}
-		t.queue(eraseUnderCursor)
+		if multiLine {
+			// Rows below the end of the buffer may be left over.
+			t.clearToEndOfScreen()
+		} else if !t.scrolling() {
+			// Blank the cells the line no longer covers.
+			for i := 0; i < width; i++ {
+				t.outBuf = append(t.outBuf, ' ')
+			}
+			t.queueMove(width, 'D')
+		}
 		t.moveCursorToPos(t.pos)
 	case KeyAltLeft:
 		// move left by a word.
 		if t.pos == 0 {
+			t.invalidKey()
 			return
 		}
 		t.pos--
@@ -329,79 +608,98 @@ func (t *Terminal) handleKey(key int) (line string, ok bool) {
 		t.moveCursorToPos(t.pos)
 	case KeyLeft:
 		if t.pos == 0 {
+			t.invalidKey()
 			return
 		}
-		t.pos--
+		t.pos = prevCharacter(t.line, t.pos)
 		t.moveCursorToPos(t.pos)
 	case KeyRight:
 		if t.pos == len(t.line) {
+			t.invalidKey()
 			return
 		}
-		t.pos++
+		t.pos = nextCharacter(t.line, t.pos)
 		t.moveCursorToPos(t.pos)
 	case KeyUp:
+		// In a buffer spanning several lines, move to the previous
+		// one unless the cursor is on the first.
+		if start := bytes.LastIndexByte(t.line[:t.pos], '\n') + 1; start > 0 {
+			prev := bytes.LastIndexByte(t.line[:start-1], '\n') + 1
+			t.pos = prev + min(t.pos-start, start-1-prev)
+			t.moveCursorToPos(t.pos)
+			return
+		}
 		if len(t.history) == 0 {
+			t.invalidKey()
 			return
 		}
 		t.historyIdx--
 		t.historyIdx = historyIdxValue(t.historyIdx, t.history)
 
-		h := t.history[t.historyIdx]
-		newLine := make([]byte, len(h))
-		copy(newLine, h)
-		newPos := len(newLine)
-		if t.echo {
-			t.moveCursorToPos(0)
-			t.writeLine(newLine)
-			for i := len(newLine); i < len(t.line); i++ {
-				t.writeLine(space)
-			}
-			t.moveCursorToPos(newPos)
-		}
-		t.line = newLine
-		t.pos = newPos
+		newLine := append(t.lineScratch[:0], t.history[t.historyIdx]...)
+		t.replaceLine(newLine, len(newLine))
 		return
 
 	case KeyDown:
+		// In a buffer spanning several lines, move to the next one
+		// unless the cursor is on the last.
+		if end := bytes.IndexByte(t.line[t.pos:], '\n'); end >= 0 {
+			start := bytes.LastIndexByte(t.line[:t.pos], '\n') + 1
+			next := t.pos + end + 1
+			nextEnd := bytes.IndexByte(t.line[next:], '\n')
+			if nextEnd < 0 {
+				nextEnd = len(t.line) - next
+			}
+			t.pos = next + min(t.pos-start, nextEnd)
+			t.moveCursorToPos(t.pos)
+			return
+		}
 		if len(t.history) == 0 {
+			t.invalidKey()
 			return
 		}
-		newPos := 0
-		newLine := []byte{}
+		newLine := t.lineScratch[:0]
 		t.historyIdx++
 		if t.historyIdx >= len(t.history) {
 			t.historyIdx = len(t.history)
 		} else {
 			t.historyIdx = historyIdxValue(t.historyIdx, t.history)
-			h := t.history[t.historyIdx]
-			newLine = make([]byte, len(h))
-			copy(newLine, h)
-			newPos = len(newLine)
-			//			fmt.Println("in")
-		}
-		if t.echo {
-			t.moveCursorToPos(0)
-			t.writeLine(newLine)
-			for i := len(newLine); i < len(t.line); i++ {
-				t.writeLine(space)
-			}
-			t.moveCursorToPos(newPos)
+			newLine = append(newLine, t.history[t.historyIdx]...)
 		}
-		t.line = newLine
-		t.pos = newPos
+		t.replaceLine(newLine, len(newLine))
 		return
 
 	case KeyEnter:
+		if t.historyExpansion && !t.secret && !t.multiLine && t.mask == nil && !t.expandLine() {
+			return
+		}
+		if !t.secret && (t.multiLine || !t.accept()) {
+			t.insertNewline()
+			return
+		}
+		fallthrough
+	case KeyAltEnter:
 		t.moveCursorToPos(len(t.line))
+		if len(t.footer) > 0 {
+			t.clearToEndOfScreen()
+		}
 		t.queue([]byte("\r\n"))
-		line = string(t.line)
+		if t.secret {
+			t.secretLine = append([]byte(nil), t.line...)
+		} else {
+			line = string(t.line)
+		}
 		ok = true
 		t.line = t.line[:0]
 		t.pos = 0
 		t.cursorX = 0
 		t.cursorY = 0
 		t.maxLine = 0
+		t.rightPromptShown = false
 		t.historyIdx = len(t.history) + 1
+		if len(t.footer) > 0 {
+			t.drawFooter(false)
+		}
 	case KeyCtrlD:
 		// add 'exit' to the end of the line
 		ok = true
@@ -442,28 +740,57 @@ func (t *Terminal) handleKey(key int) (line string, ok bool) {
 			t.moveCursorToPos(t.pos)
 		}
 	case KeyCtrlC:
-		// add '^C' to the end of the line
-		if len(t.line) == maxLineLength {
+		switch t.interrupt() {
+		case InterruptClear:
+			t.abandonLine()
+			return
+		case InterruptIgnore:
 			return
 		}
-		newLine := make([]byte, len(t.line), 2*(2+len(t.line)))
-		copy(newLine, t.line)
-		t.line = newLine
-		t.line = t.line[:len(t.line)+3]
-		copy(t.line[t.pos+3:], t.line[t.pos:])
-		t.line[t.pos] = byte('^')
-		t.pos++
-		t.line[t.pos] = byte('C')
-		if t.echo {
-			t.writeLine(t.line[t.pos-1:])
+		t.interrupted = true
+		if t.secret {
+			// Adding ^C to the line would copy the password.
+			t.moveCursorToPos(len(t.line))
+			if t.echo {
+				t.queue([]byte("^C"))
+			}
+		} else {
+			// add '^C' to the end of the line
+			if len(t.line) == maxLineLength {
+				return
+			}
+			newLine := make([]byte, len(t.line), 2*(2+len(t.line)))
+			copy(newLine, t.line)
+			t.line = newLine
+			t.line = t.line[:len(t.line)+2]
+			copy(t.line[t.pos+2:], t.line[t.pos:])
+			t.line[t.pos] = byte('^')
+			t.pos++
+			t.line[t.pos] = byte('C')
+			if t.echo {
+				t.writeLine(t.line[t.pos-1:])
+			}
+			t.pos++
+			t.moveCursorToPos(t.pos)
+		}
+		if len(t.footer) > 0 {
+			t.clearToEndOfScreen()
 		}
-		t.pos++
-		t.moveCursorToPos(t.pos)
 		t.queue([]byte("\r\n"))
-		t.line = make([]byte, 0)
+		if t.secret {
+			clear(t.line[:cap(t.line)])
+			t.line = t.line[:0]
+		} else {
+			t.line = make([]byte, 0)
+		}
 		t.pos = 0
 		t.cursorX = 0
 		t.cursorY = 0
+		t.maxLine = 0
+		t.rightPromptShown = false
+		if len(t.footer) > 0 {
+			t.drawFooter(false)
+		}
 
 	default:
 		if t.AutoCompleteCallback != nil {
@@ -472,28 +799,22 @@ func (t *Terminal) handleKey(key int) (line string, ok bool) {
 			t.lock.Lock()
 
 			if newLine != nil {
-				if t.echo {
-					t.moveCursorToPos(0)
-					t.writeLine(newLine)
-					for i := len(newLine); i < len(t.line); i++ {
-						t.writeLine(space)
-					}
-					t.moveCursorToPos(newPos)
-				}
-				t.line = newLine
-				t.pos = newPos
+				t.setLine(newLine, newPos)
 				return
 			}
 		}
-		if !isPrintable(key) {
-			return
-		}
-		if len(t.line) == maxLineLength {
+		if !isPrintable(key) || len(t.line) == maxLineLength {
+			// Either the key isn't bound to anything, e.g. Tab
+			// without completion, or the line is full.
+			t.invalidKey()
 			return
 		}
 		if len(t.line) == cap(t.line) {
 			newLine := make([]byte, len(t.line), 2*(1+len(t.line)))
 			copy(newLine, t.line)
+			if t.secret {
+				clear(t.line)
+			}
 			t.line = newLine
 		}
 		t.line = t.line[:len(t.line)+1]
@@ -504,197 +825,1356 @@ func (t *Terminal) handleKey(key int) (line string, ok bool) {
 		}
 		t.pos++
 		t.moveCursorToPos(t.pos)
+		t.reveal()
 	}
 	return
 }
 
-func (t *Terminal) writeLine(line []byte) {
-	for len(line) != 0 {
-		remainingOnLine := t.termWidth - t.cursorX
-		todo := len(line)
-		if todo > remainingOnLine {
-			todo = remainingOnLine
-		}
-		t.queue(line[:todo])
-		t.cursorX += todo
-		line = line[todo:]
-
-		if t.cursorX == t.termWidth {
-			t.cursorX = 0
-			t.cursorY++
-			if t.cursorY > t.maxLine {
-				t.maxLine = t.cursorY
-			}
-		}
+// accept reports whether the input should be returned when Enter is pressed.
+func (t *Terminal) accept() bool {
+	if t.AcceptCallback == nil {
+		return true
 	}
+	line := string(t.line)
+	t.lock.Unlock()
+	defer t.lock.Lock()
+	return t.AcceptCallback(line)
 }
 
-func (t *Terminal) Write(buf []byte) (n int, err error) {
-	t.lock.Lock()
-	defer t.lock.Unlock()
-
-	if t.cursorX == 0 && t.cursorY == 0 {
-		// This is the easy case: there's nothing on the screen that we
-		// have to move out of the way.
-		return t.c.Write(buf)
+// insert inserts text into the line at the cursor, leaving the cursor after
+// it, and repaints the rest of the line once.
+func (t *Terminal) insert(text []byte) {
+	if room := maxLineLength - len(t.line); len(text) > room {
+		text = text[:room]
 	}
+	if len(text) == 0 {
+		return
+	}
+	t.conceal()
+	n := len(t.line)
+	t.line = append(t.line, text...)
+	copy(t.line[t.pos+len(text):], t.line[t.pos:n])
+	copy(t.line[t.pos:], text)
+	if t.echo {
+		t.writeLine(t.line[t.pos:])
+	}
+	t.pos += len(text)
+	t.moveCursorToPos(t.pos)
+	t.reveal()
+}
 
-	// We have a prompt and possibly user input on the screen. We
-	// have to clear it first.
-	t.move(0 /* up */, 0 /* down */, t.cursorX /* left */, 0 /* right */)
-	t.cursorX = 0
-	t.clearLineToRight()
-
-	for t.cursorY > 0 {
-		t.move(1 /* up */, 0, 0, 0)
-		t.cursorY--
-		t.clearLineToRight()
+// printableRun returns the number of bytes of printable characters at the
+// start of b.
+func printableRun(b []byte) int {
+	for i := 0; i < len(b); {
+		if isPrintable(int(b[i])) {
+			i++
+			continue
+		}
+		n := printableRune(b[i:])
+		if n == 0 {
+			return i
+		}
+		i += n
 	}
+	return len(b)
+}
 
-	if _, err = t.c.Write(t.outBuf); err != nil {
-		return
+// printableRune returns the length of the UTF-8 encoding of the character
+// beyond ASCII at the start of b if it can be inserted into the line, or 0.
+func printableRune(b []byte) int {
+	if !utf8.FullRune(b) {
+		return 0
 	}
-	t.outBuf = t.outBuf[:0]
+	r, size := utf8.DecodeRune(b)
+	if size == 1 || !unicode.IsPrint(r) && !extendsCharacter(r) {
+		return 0
+	}
+	return size
+}
 
-	if n, err = t.c.Write(buf); err != nil {
+// insertNewline inserts a newline at the cursor, splitting the current line of
+// the buffer in two.
+func (t *Terminal) insertNewline() {
+	if len(t.line) == maxLineLength {
 		return
 	}
-
-	t.queue([]byte(t.prompt))
-	chars := len(t.prompt)
+	t.line = append(t.line, 0)
+	copy(t.line[t.pos+1:], t.line[t.pos:])
+	t.line[t.pos] = '\n'
 	if t.echo {
-		t.queue(t.line)
-		chars += len(t.line)
+		t.writeLine(t.line[t.pos:])
 	}
-	t.cursorX = chars % t.termWidth
-	t.cursorY = chars / t.termWidth
+	t.pos++
 	t.moveCursorToPos(t.pos)
+}
+
+// replaceLine is like setLine, but newLine must have been built in
+// t.lineScratch. The storage of the old line becomes the new scratch space, so
+// that repeatedly replacing the line doesn't allocate.
+func (t *Terminal) replaceLine(newLine []byte, pos int) {
+	old := t.line
+	t.setLine(newLine, pos)
+	t.lineScratch = old[:0]
+}
 
-	if _, err = t.c.Write(t.outBuf); err != nil {
+// setLine replaces the line being edited and moves the cursor to pos. If echo
+// is enabled, only the part of the line that differs from what is on the
+// screen is repainted.
+func (t *Terminal) setLine(newLine []byte, pos int) {
+	t.conceal()
+	if !t.echo {
+		t.line = newLine
+		t.pos = pos
 		return
 	}
-	t.outBuf = t.outBuf[:0]
-	return
-}
 
-// ReadPassword temporarily changes the prompt and reads a password, without
-// echo, from the terminal.
-func (t *Terminal) ReadPassword(prompt string) (line string, err error) {
-	t.lock.Lock()
-	defer t.lock.Unlock()
+	t.queue(hideCursor)
+	defer t.queue(showCursor)
 
-	oldPrompt := t.prompt
-	t.prompt = prompt
-	t.echo = false
+	if t.scrolling() {
+		t.line = newLine
+		t.pos = pos
+		t.scrollDirty = true
+		t.moveCursorToPos(pos)
+		return
+	}
 
-	line, err = t.readLine()
+	old := t.line
+	oldEndX, oldEndY := t.posToXY(len(old))
+	prefix, suffix := commonAffixes(old, newLine)
+
+	t.moveCursorToPos(prefix)
+	t.line = newLine
+	oldMiddle, newMiddle := old[prefix:len(old)-suffix], newLine[prefix:len(newLine)-suffix]
+	if bytes.IndexByte(oldMiddle, '\n') < 0 && bytes.IndexByte(newMiddle, '\n') < 0 &&
+		t.textWidth(oldMiddle, t.cursorX) == t.textWidth(newMiddle, t.cursorX) {
+		// The rest of the line stays where it is.
+		t.writeLine(newMiddle)
+	} else {
+		t.writeLine(newLine[prefix:])
+		if oldEndY > t.cursorY {
+			t.clearToEndOfScreen()
+		} else if oldEndY == t.cursorY && oldEndX > t.cursorX {
+			t.clearLineToRight()
+			if _, row := t.promptEnd(); row == t.cursorY {
+				t.rightPromptShown = false
+			}
+		}
+	}
+	t.pos = pos
+	t.moveCursorToPos(pos)
+}
 
-	t.prompt = oldPrompt
-	t.echo = true
+// commonAffixes returns the lengths of the longest common prefix and suffix of
+// a and b, which don't overlap and don't split any UTF-8 sequences.
+func commonAffixes(a, b []byte) (prefix, suffix int) {
+	n := min(len(a), len(b))
+	for prefix < n && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for prefix > 0 && (prefix < len(a) && !utf8.RuneStart(a[prefix]) || prefix < len(b) && !utf8.RuneStart(b[prefix])) {
+		prefix--
+	}
 
+	n -= prefix
+	for suffix < n && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && (!utf8.RuneStart(a[len(a)-suffix]) || !utf8.RuneStart(b[len(b)-suffix])) {
+		suffix--
+	}
 	return
 }
 
-// ReadLine returns a line of input from the terminal.
-func (t *Terminal) ReadLine() (line string, err error) {
-	t.lock.Lock()
-	defer t.lock.Unlock()
-
-	return t.readLine()
+// writeLine queues line, which is part of the text being edited, for output.
+// Each newline in line starts a new row with the continuation prompt; the
+// remainder of the row before it is cleared.
+func (t *Terminal) writeLine(line []byte) {
+	if t.scrolling() {
+		// The line is drawn when the cursor is moved, as that may
+		// scroll it.
+		t.scrollDirty = true
+		return
+	}
+	if t.mask != nil {
+		t.writeText(t.masked(line))
+		return
+	}
+	reordered := t.bidi && (t.bidiShown || hasRTL(t.line))
+	if reordered {
+		// Changing part of a row may change the order of all of it, so
+		// the whole line is written again.
+		t.bidiShown = hasRTL(t.line)
+		x, y := t.promptEnd()
+		t.move(max(0, t.cursorY-y), max(0, y-t.cursorY), 0, 0)
+		t.moveToColumn(t.cursorX, x)
+		t.cursorX, t.cursorY = x, y
+		line = t.line
+	}
+	for {
+		i := bytes.IndexByte(line, '\n')
+		if i < 0 {
+			if reordered {
+				line, _ = reorder(line, 0)
+			}
+			t.writeText(t.display(line, t.cursorX))
+			return
+		}
+		row := line[:i]
+		if reordered {
+			row, _ = reorder(row, 0)
+		}
+		t.writeText(t.display(row, t.cursorX))
+		t.clearLineToRight()
+		t.writeText(newline)
+		t.writeText([]byte(t.continuationPrompt))
+		line = line[i+1:]
+	}
 }
 
-func (t *Terminal) readLine() (line string, err error) {
-	// t.lock must be held at this point
+// masked returns what is displayed for b, which is part of the line, while a
+// mask is set: one mask per rune.
+func (t *Terminal) masked(b []byte) []byte {
+	return bytes.Repeat(t.mask, utf8.RuneCount(b))
+}
 
-	if t.cursorX == 0 && t.cursorY == 0 {
-		t.writeLine([]byte(t.prompt))
-		t.c.Write(t.outBuf)
-		t.outBuf = t.outBuf[:0]
+// textWidth returns the number of columns b, which is part of the line, takes
+// up on the screen when written from column x.
+func (t *Terminal) textWidth(b []byte, x int) int {
+	if t.mask != nil {
+		return bytesWidth(t.masked(b))
 	}
+	return bytesWidth(t.display(b, x))
+}
 
-	for {
-		rest := t.remainder
-		lineOk := false
-		for !lineOk {
-			var key int
-			key, rest = bytesToKey(rest)
-			if key < 0 {
-				break
-			}
-
-			line, lineOk = t.handleKey(key)
-			if key == KeyCtrlD && lineOk {
-				return "", io.EOF
-			}
-			if key == KeyCtrlC {
-				t.remainder = nil
-				return "^C", fmt.Errorf("control-c break")
-			}
-		}
-		if len(rest) > 0 {
-			n := copy(t.inBuf[:], rest)
-			t.remainder = t.inBuf[:n]
-		} else {
-			t.remainder = nil
+// writeText queues text for output, keeping track of the cursor position.
+// Escape sequences in text take up no space on the screen and newlines move
+// to the start of the next row.
+func (t *Terminal) writeText(line []byte) {
+	for len(line) != 0 {
+		if line[0] == '\n' {
+			t.queue([]byte("\r\n"))
+			t.cursorX = 0
+			t.cursorY++
+			t.maxLine = max(t.maxLine, t.cursorY)
+			line = line[1:]
+			continue
 		}
-		t.c.Write(t.outBuf)
-		t.outBuf = t.outBuf[:0]
-		if lineOk {
-			if t.echo { //&& len(line) > 0 {
-				// don't put passwords into history...
-				b := []byte(line)
-				h := make([]byte, len(b))
-				copy(h, b)
-				t.history = append(t.history, h)
+		if line[0] == KeyEscape {
+			n := escapeLength(line)
+			if n < 0 {
+				n = len(line)
 			}
-			return
+			t.queue(line[:n])
+			line = line[n:]
+			continue
 		}
 
-		// t.remainder is a slice at the beginning of t.inBuf
-		// containing a partial key sequence
-		readBuf := t.inBuf[len(t.remainder):]
-		var n int
-
-		t.lock.Unlock()
-		n, err = t.c.Read(readBuf)
-		t.lock.Lock()
-
-		if err != nil {
-			return "", err
+		r, size := utf8.DecodeRune(line)
+		width := runeWidth(r)
+		if t.cursorX+width > t.termWidth {
+			// The terminal moves wide characters that don't fit
+			// onto the next row.
+			t.cursorX = 0
+			t.cursorY++
 		}
+		t.queue(line[:size])
+		t.cursorX += width
+		line = line[size:]
 
-		t.remainder = t.inBuf[:n+len(t.remainder)]
+		if t.cursorX == t.termWidth {
+			t.cursorX = 0
+			t.cursorY++
+		}
+		if t.cursorY > t.maxLine {
+			t.maxLine = t.cursorY
+		}
 	}
 }
 
-// SetPrompt sets the prompt to be used when reading subsequent lines.
-func (t *Terminal) SetPrompt(prompt string) {
-	t.lock.Lock()
-	defer t.lock.Unlock()
-
-	t.prompt = prompt
+// promptEnd returns the position of the cursor after the prompt has been
+// written, relative to the start of its first row. Prompts may span several
+// rows, either because they wrap or because they contain newlines; editing
+// starts on the last one.
+func (t *Terminal) promptEnd() (x, y int) {
+	return t.promptLayout.end(t, t.shownPrompt())
 }
 
-func (t *Terminal) SetSize(width, height int) {
-	t.lock.Lock()
-	defer t.lock.Unlock()
+// promptLayout caches the position of the cursor after a prompt has been
+// written, which is needed for every key press.
+type promptLayout struct {
+	prompt string
+	width  int
+	x, y   int
+}
 
-	t.termWidth, t.termHeight = width, height
+// end returns the position of the cursor after prompt has been written at the
+// start of a row of t.
+func (l *promptLayout) end(t *Terminal, prompt string) (x, y int) {
+	if l.prompt != prompt || l.width != t.termWidth {
+		l.x, l.y = t.advance(0, 0, []byte(prompt))
+		l.prompt, l.width = prompt, t.termWidth
+	}
+	return l.x, l.y
 }
 
+// posToXY returns the position of the cursor on the screen, relative to the
+// start of the prompt, when it is at the given logical position in the line.
+func (t *Terminal) posToXY(pos int) (x, y int) {
+	x, y = t.promptEnd()
+	if t.scrolling() {
+		return t.unwrapped(t.line[:pos], x, nil) - t.scrollCol, y
+	}
+	line := t.line[:pos]
+	if t.mask != nil {
+		return t.advance(x, y, t.masked(line))
+	}
+	for {
+		i := bytes.IndexByte(line, '\n')
+		if i < 0 {
+			if t.bidi {
+				// What's shown before the cursor depends on the
+				// rest of the row.
+				start := pos - len(line)
+				end := bytes.IndexByte(t.line[pos:], '\n')
+				if end < 0 {
+					end = len(t.line) - pos
+				}
+				if row := t.line[start : pos+end]; hasRTL(row) {
+					visual, before := reorder(row, len(line))
+					line = visual[:before]
+				}
+			}
+			return t.advance(x, y, t.display(line, x))
+		}
+		_, y = t.advance(x, y, t.display(line[:i], x))
+		var dy int
+		x, dy = t.continuationLayout.end(t, t.continuationPrompt)
+		y += 1 + dy
+		line = line[i+1:]
+	}
+}
+
+// advance returns the position of the cursor after b has been written, in the
+// way writeText does, starting at x, y.
+func (t *Terminal) advance(x, y int, b []byte) (int, int) {
+	for len(b) > 0 {
+		switch b[0] {
+		case '\n':
+			x = 0
+			y++
+			b = b[1:]
+			continue
+		case KeyEscape:
+			n := escapeLength(b)
+			if n < 0 {
+				n = len(b)
+			}
+			b = b[n:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		width := runeWidth(r)
+		if x+width > t.termWidth {
+			x = 0
+			y++
+		}
+		x += width
+		if x == t.termWidth {
+			x = 0
+			y++
+		}
+	}
+	return x, y
+}
+
+// rightPromptColumn returns the column the right prompt starts at. The last
+// column is left empty so that the terminal doesn't wrap.
+func (t *Terminal) rightPromptColumn() int {
+	return t.termWidth - 1 - t.rightPromptWidth
+}
+
+// updateRightPrompt shows or hides the right prompt depending on whether the
+// prompt and line leave room for it, with at least one column in between.
+func (t *Terminal) updateRightPrompt() {
+	if !t.echo || t.rightPrompt == "" || t.scrolling() {
+		return
+	}
+	// Only the first line of the buffer shares the row with the prompt.
+	first := t.line
+	if i := bytes.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	px, row := t.promptEnd()
+	if t.mask != nil {
+		first = t.masked(first)
+	} else {
+		first = t.display(first, px)
+	}
+	end, endRow := t.advance(px, row, first)
+	col := t.rightPromptColumn()
+	fits := endRow == row && end < col
+	if fits == t.rightPromptShown {
+		return
+	}
+	if endRow != row {
+		// The line wraps, so it has overwritten the whole row.
+		t.rightPromptShown = false
+		return
+	}
+
+	x := col
+	if !fits {
+		// The line is about to collide with the right prompt, or has
+		// already overwritten part of it. Clear whatever is left.
+		x = end
+	}
+	t.move(max(0, t.cursorY-row), max(0, row-t.cursorY), max(0, t.cursorX-x), max(0, x-t.cursorX))
+	if fits {
+		t.queue([]byte(t.rightPrompt))
+		x += t.rightPromptWidth
+	} else {
+		t.clearLineToRight()
+	}
+	t.move(max(0, row-t.cursorY), max(0, t.cursorY-row), max(0, x-t.cursorX), max(0, t.cursorX-x))
+	t.rightPromptShown = fits
+}
+
+func (t *Terminal) Write(buf []byte) (n int, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.passthrough {
+		t.recordOutput(buf)
+		return t.output(buf)
+	}
+	if t.onlcr {
+		if _, err = t.write(crlf(buf)); err != nil {
+			return 0, err
+		}
+		return len(buf), nil
+	}
+	return t.write(buf)
+}
+
+// write implements Write. t.lock must be held.
+func (t *Terminal) write(buf []byte) (n int, err error) {
+
+	if t.cursorX == 0 && t.cursorY == 0 && len(t.footer) == 0 {
+		// This is the easy case: there's nothing on the screen that we
+		// have to move out of the way.
+		t.recordOutput(buf)
+		return t.output(buf)
+	}
+
+	// We have a prompt and possibly user input on the screen. We
+	// have to clear it first. Everything is sent in a single write so
+	// that the prompt doesn't flicker.
+	editing := t.editing()
+	t.queue(hideCursor)
+	t.clearLines()
+	t.queue(buf)
+	if editing {
+		t.repaint()
+	} else {
+		// Only the footer is on the screen. It starts on a row of
+		// its own.
+		if len(buf) > 0 && buf[len(buf)-1] != '\n' {
+			t.queue([]byte("\r\n"))
+		}
+		t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+		t.drawFooter(false)
+	}
+	t.queue(showCursor)
+
+	if err = t.flush(); err != nil {
+		return
+	}
+	return len(buf), nil
+}
+
+// clearLines clears the rows occupied by the prompt and line, starting from
+// the last one, and leaves the cursor at the start of the first.
+func (t *Terminal) clearLines() {
+	t.move(0 /* up */, t.maxLine-t.cursorY /* down */, 0 /* left */, 0 /* right */)
+	t.moveToColumn(t.cursorX, 0)
+	t.cursorY = t.maxLine
+	t.cursorX = 0
+	t.clearLineToRight()
+
+	for t.cursorY > 0 {
+		t.move(1 /* up */, 0, 0, 0)
+		t.cursorY--
+		t.clearLineToRight()
+	}
+}
+
+// repaint writes the prompt and line from scratch, starting at the beginning
+// of an empty row, and moves the cursor to its position in the line.
+func (t *Terminal) repaint() {
+	t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+	t.revealPos = -1
+	t.drawPrompt()
+	if t.echo {
+		t.writeLine(t.line)
+	}
+	t.moveCursorToPos(t.pos)
+	t.rightPromptShown = false
+	t.updateRightPrompt()
+	if len(t.footer) > 0 {
+		t.drawFooter(true)
+	}
+}
+
+// Refresh clears the prompt and line from the screen and paints them again,
+// e.g. after a highlighter's state has changed or the screen got garbled.
+func (t *Terminal) Refresh() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(hideCursor)
+	t.clearLines()
+	t.repaint()
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// InvalidatePrompt shows the current prompt in place of the one on the screen
+// while a line is being read, e.g. after SetPrompt was called from another
+// goroutine to update a clock, the state of a git checkout or a connection
+// indicator. The line being entered is left alone: if the new prompt takes
+// up as much room on its row as the old one, only the prompt is written
+// again. Nothing is written if the prompt hasn't changed, so it's cheap to
+// call often.
+func (t *Terminal) InvalidatePrompt() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.promptDrawn || !t.editing() {
+		// The new prompt is shown when the next line is read.
+		return nil
+	}
+	prompt := t.shownPrompt()
+	if prompt == t.drawnPrompt {
+		return nil
+	}
+
+	t.queue(hideCursor)
+	oldX, oldY := t.advance(0, 0, []byte(t.drawnPrompt))
+	if x, y := t.promptEnd(); x == oldX && y == 0 && oldY == 0 {
+		// The prompt fits on its row as before.
+		t.move(t.cursorY, 0, 0, 0)
+		t.moveToColumn(t.cursorX, 0)
+		t.cursorX, t.cursorY = 0, 0
+		t.drawPrompt()
+		t.moveCursorToPos(t.pos)
+	} else {
+		t.clearLines()
+		t.repaint()
+	}
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// Redraw paints the prompt and line at the current cursor position, which
+// must be at the start of an empty row, without clearing what the terminal
+// believes to be on the screen. It's useful after something else, like an
+// external program or a screen clear, has taken over the display.
+func (t *Terminal) Redraw() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.queue(hideCursor)
+	t.repaint()
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// visualBellDuration is how long the screen stays inverted by a visual bell.
+const visualBellDuration = 100 * time.Millisecond
+
+var (
+	bell         = []byte{7}
+	reverseVideo = []byte{KeyEscape, '[', '?', '5', 'h'}
+	normalVideo  = []byte{KeyEscape, '[', '?', '5', 'l'}
+)
+
+// Bell rings the terminal bell, or flashes the screen if a visual bell was
+// requested with SetVisualBell.
+func (t *Terminal) Bell() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.ring()
+	return t.flush()
+}
+
+// ring queues the bell.
+func (t *Terminal) ring() {
+	if !t.visualBell {
+		t.queue(bell)
+		return
+	}
+	t.queue(reverseVideo)
+	time.AfterFunc(visualBellDuration, func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		t.queue(normalVideo)
+		t.flush()
+	})
+}
+
+// invalidKey is called when a key press has no effect, e.g. Backspace at the
+// start of the line or Tab when there is nothing to complete.
+func (t *Terminal) invalidKey() {
+	if t.bellOnError {
+		t.ring()
+	}
+}
+
+// SetVisualBell makes Bell flash the screen, by briefly switching it to
+// reverse video, instead of making a sound.
+func (t *Terminal) SetVisualBell(visual bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.visualBell = visual
+}
+
+// SetBellOnError makes the terminal ring the bell, like readline does, when a
+// key press can't be acted upon: moving past either end of the line or the
+// history, deleting at the start of the line, keys that aren't bound to
+// anything, including a Tab the AutoCompleteCallback didn't complete, and
+// typing into a full line.
+func (t *Terminal) SetBellOnError(bell bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.bellOnError = bell
+}
+
+// ReadPassword temporarily changes the prompt and reads a password, without
+// echo, from the terminal.
+func (t *Terminal) ReadPassword(prompt string) (line string, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	password, err := t.readPassword(prompt, nil)
+	line = string(password)
+	clear(password)
+	return
+}
+
+// ReadPasswordBytes is like ReadPassword, but returns the password as a byte
+// slice, which the caller can overwrite once it's done with it. Unlike a
+// string returned by ReadPassword, no other copy of the password is kept in
+// memory.
+func (t *Terminal) ReadPasswordBytes(prompt string) (password []byte, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.readPassword(prompt, nil)
+}
+
+// ReadPasswordMasked is like ReadPassword, but echoes a mask, an asterisk
+// unless changed with SetPasswordMask, for each character typed, so that the
+// user can see that their keystrokes register. Backspace removes the last one
+// as usual.
+func (t *Terminal) ReadPasswordMasked(prompt string) (line string, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	password, err := t.readPassword(prompt, t.passwordMask)
+	line = string(password)
+	clear(password)
+	return
+}
+
+// readPassword reads a line without recording it in the history. If mask is
+// nil nothing is echoed, otherwise mask is echoed in place of each character.
+// The AcceptCallback isn't consulted. Afterwards all buffers that held the
+// password are wiped.
+func (t *Terminal) readPassword(prompt string, mask []byte) (password []byte, err error) {
+	if err := t.startReading(); err != nil {
+		return nil, err
+	}
+	defer t.doneReading()
+
+	oldPrompt, oldSpans, oldEcho := t.prompt, t.promptSpans, t.echo
+	t.prompt, t.promptSpans = prompt, nil
+	t.echo = mask != nil
+	t.mask = mask
+	// The line never has to grow, which would leave a copy behind.
+	oldLine, oldPos := t.line, t.pos
+	t.line, t.pos = make([]byte, 0, maxLineLength), 0
+	t.secret = true
+
+	_, err = t.readLine()
+	password, t.secretLine = t.secretLine, nil
+	if t.revealPos >= 0 {
+		t.conceal()
+		t.flush()
+	}
+	t.wipe()
+
+	t.line, t.pos = oldLine, oldPos
+	t.secret, t.redacted = false, false
+	t.prompt, t.promptSpans = oldPrompt, oldSpans
+	t.echo = oldEcho
+	t.mask = nil
+
+	return
+}
+
+// wipe overwrites everything but unread input in the buffers that may have
+// held a password.
+func (t *Terminal) wipe() {
+	clear(t.line[:cap(t.line)])
+	clear(t.lineScratch[:cap(t.lineScratch)])
+	clear(t.inBuf[len(t.remainder):])
+	clear(t.outBuf[:cap(t.outBuf)])
+}
+
+// ReadLine returns a line of input from the terminal. If the input has been
+// marked as incomplete with SetIncomplete, the continuation prompt is used
+// instead of the normal one. Only one goroutine may read at a time; while
+// another one does, ReadLine returns ErrConcurrentRead.
+func (t *Terminal) ReadLine() (line string, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err := t.startReading(); err != nil {
+		return "", err
+	}
+	defer t.doneReading()
+
+	t.beforeRead()
+	if !t.incomplete {
+		line, err = t.readLine()
+	} else {
+		oldPrompt, oldSpans := t.prompt, t.promptSpans
+		t.prompt, t.promptSpans = t.continuationPrompt, nil
+		line, err = t.readLine()
+		t.prompt, t.promptSpans = oldPrompt, oldSpans
+	}
+	t.afterRead(line, err)
+
+	return
+}
+
+// ReadLineWithDefault temporarily changes the prompt and reads a line of
+// input, starting with initial already entered and the cursor at its end, so
+// that the user can edit it rather than retype it.
+func (t *Terminal) ReadLineWithDefault(prompt, initial string) (line string, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err := t.startReading(); err != nil {
+		return "", err
+	}
+	defer t.doneReading()
+
+	t.beforeRead()
+	oldPrompt, oldSpans := t.prompt, t.promptSpans
+	t.prompt, t.promptSpans = prompt, nil
+
+	t.showPrompt()
+	t.setLine([]byte(initial), len(initial))
+	t.updateRightPrompt()
+	t.flush()
+
+	line, err = t.readLine()
+
+	t.prompt, t.promptSpans = oldPrompt, oldSpans
+	t.afterRead(line, err)
+
+	return
+}
+
+func (t *Terminal) readLine() (line string, err error) {
+	// t.lock must be held at this point
+
+	// Output is collected in t.outBuf and written at most once for each
+	// read from t.c, so that a key press results in a single write
+	// (e.g. one SSH channel packet) rather than many small ones.
+	defer t.flush()
+
+	defer func() { t.promptDrawn = false }()
+	t.armIdle()
+	defer t.disarmIdle()
+
+	if t.cursorX == 0 && t.cursorY == 0 {
+		if len(t.footer) > 0 {
+			// The prompt goes above the footer.
+			t.queue(hideCursor)
+			t.clearLines()
+			t.repaint()
+			t.queue(showCursor)
+		} else {
+			t.showPrompt()
+			t.updateRightPrompt()
+		}
+	}
+
+	for {
+		rest := t.remainder
+		lineOk := false
+		for !lineOk {
+			// Text that arrives in bulk, e.g. from a paste, is
+			// inserted in one go rather than key by key, unless
+			// the AutoCompleteCallback or a key hook has to see
+			// each key.
+			if n := printableRun(rest); n > 1 && t.AutoCompleteCallback == nil && len(t.keyHooks) == 0 {
+				t.insert(rest[:n])
+				rest = rest[n:]
+				continue
+			}
+
+			if n := t.handleReply(rest); n > 0 {
+				rest = rest[n:]
+				continue
+			}
+
+			var key int
+			seq := rest
+			key, rest = bytesToKey(rest)
+			if key < 0 {
+				break
+			}
+			seq = seq[:len(seq)-len(rest)]
+
+			if key == KeyRune {
+				// Characters beyond ASCII are inserted once
+				// all of their bytes have arrived.
+				if n := printableRune(seq); n > 0 {
+					t.insert(seq)
+				} else {
+					t.invalidKey()
+				}
+				continue
+			}
+			if t.escapeKey != 0 && key == int(t.escapeKey) {
+				t.saveRemainder(rest)
+				return "", ErrEscapeKey
+			}
+			line, lineOk = t.handleKey(key)
+			if key == KeyCtrlD && lineOk {
+				t.saveRemainder(rest)
+				return "", io.EOF
+			}
+			if t.interrupted {
+				t.interrupted = false
+				t.remainder = nil
+				return "^C", ErrInterrupted
+			}
+		}
+		t.saveRemainder(rest)
+		if !lineOk {
+			t.updateRightPrompt()
+		}
+		t.flush()
+		if lineOk {
+			if t.echo && t.mask == nil { //&& len(line) > 0 {
+				// don't put passwords into history...
+				b := []byte(line)
+				h := make([]byte, len(b))
+				copy(h, b)
+				t.history = append(t.history, h)
+			}
+			return
+		}
+
+		if err = t.fill(); err != nil {
+			return "", err
+		}
+		t.armIdle()
+	}
+}
+
+// fill reads more input into t.remainder. If an earlier read failed after
+// returning some data, its error is returned now instead. t.lock must be held
+// and is released while reading.
+func (t *Terminal) fill() error {
+	if t.readErr != nil {
+		err := t.readErr
+		t.readErr = nil
+		return err
+	}
+
+	if ch := t.inFlight; ch != nil {
+		// A query started reading before this call.
+		t.lock.Unlock()
+		r := <-ch
+		t.lock.Lock()
+
+		t.inFlight = nil
+		t.addInput(r.data)
+		if r.err != nil {
+			if len(r.data) == 0 {
+				return r.err
+			}
+			t.readErr = r.err
+		}
+		return nil
+	}
+
+	// t.remainder is a slice at the beginning of t.inBuf
+	// containing a partial key sequence
+	if len(t.remainder) == len(t.inBuf) {
+		t.growInBuf()
+	}
+	readBuf := t.inBuf[len(t.remainder):]
+
+	t.lock.Unlock()
+	n, err := t.c.Read(readBuf)
+	t.lock.Lock()
+	t.recordInput(readBuf[:n])
+
+	t.remainder = t.inBuf[:n+len(t.remainder)]
+	if n == len(readBuf) && len(t.inBuf) < bulkInBuf {
+		// There's probably more to come, e.g. a paste, which
+		// is handled faster in bigger batches.
+		t.growInBuf()
+	}
+	if err != nil {
+		if n == 0 {
+			return err
+		}
+		// Process the data first; the error is returned
+		// afterwards if it doesn't complete the line.
+		t.readErr = err
+	}
+	return nil
+}
+
+// readKey returns the next key press and the bytes it was read from, which
+// hold the character for KeyRune, reading more input as needed. t.lock must
+// be held.
+func (t *Terminal) readKey() (int, []byte, error) {
+	for {
+		rest := t.remainder
+		for {
+			n := t.handleReply(rest)
+			if n == 0 {
+				break
+			}
+			rest = rest[n:]
+		}
+		if len(rest) == 1 && rest[0] == KeyEscape {
+			// An escape sequence may have been split over two
+			// reads, so the rest of it is waited for a little
+			// before this is taken for the Escape key.
+			t.saveRemainder(rest)
+			if t.waitInput(escapeTimeout) {
+				continue
+			}
+			t.saveRemainder(nil)
+			return KeyEscape, []byte{KeyEscape}, nil
+		}
+		seq := rest
+		key, rest := bytesToKey(rest)
+		// Copy the key's bytes before saving the remainder overwrites
+		// them.
+		b := append([]byte(nil), seq[:len(seq)-len(rest)]...)
+		t.saveRemainder(rest)
+		if key >= 0 {
+			return key, b, nil
+		}
+		if err := t.fill(); err != nil {
+			return -1, nil, err
+		}
+	}
+}
+
+// escapeTimeout is how long readKey waits for the rest of an escape sequence
+// after an escape at the end of the input.
+const escapeTimeout = 50 * time.Millisecond
+
+// waitInput reads more input in the background and waits at most d for it to
+// be added to t.remainder. It reports whether any was. Otherwise the read goes
+// on, and fill picks up what it returns.
+func (t *Terminal) waitInput(d time.Duration) bool {
+	if t.readErr != nil {
+		return false
+	}
+	if t.inFlight == nil {
+		t.startRead()
+	}
+	ch := t.inFlight
+	t.lock.Unlock()
+	timer := time.NewTimer(d)
+	var r readResult
+	var ok bool
+	select {
+	case r, ok = <-ch:
+	case <-timer.C:
+	}
+	timer.Stop()
+	t.lock.Lock()
+
+	if !ok {
+		return false
+	}
+	t.inFlight = nil
+	t.addInput(r.data)
+	if r.err != nil {
+		t.readErr = r.err
+	}
+	return len(r.data) > 0
+}
+
+// startReading marks the terminal as reading input, which it does until
+// doneReading is called. It returns ErrConcurrentRead if another goroutine is
+// reading already, and ErrPassthrough in passthrough mode.
+func (t *Terminal) startReading() error {
+	switch {
+	case t.reading:
+		return ErrConcurrentRead
+	case t.passthrough:
+		return ErrPassthrough
+	}
+	t.reading = true
+	return nil
+}
+
+// doneReading marks the terminal as no longer reading input. If a query is
+// waiting for a reply, reading continues on its behalf.
+func (t *Terminal) doneReading() {
+	t.reading = false
+	if t.pendingQuery != nil && t.inFlight == nil {
+		t.startRead()
+	}
+}
+
+// saveRemainder moves rest, the unprocessed part of the input, to the start of
+// t.inBuf.
+func (t *Terminal) saveRemainder(rest []byte) {
+	if len(rest) == 0 {
+		t.remainder = nil
+		return
+	}
+	n := copy(t.inBuf, rest)
+	t.remainder = t.inBuf[:n]
+}
+
+// bulkInBuf is the size up to which t.inBuf grows when reads fill it.
+const bulkInBuf = 16 << 10
+
+// maxInBuf limits the size of a partial key sequence. A sequence that's still
+// incomplete at this size is discarded.
+const maxInBuf = 1 << 20
+
+// growInBuf makes room for more input after a partial key sequence that fills
+// t.inBuf.
+func (t *Terminal) growInBuf() {
+	if len(t.inBuf) >= maxInBuf {
+		t.remainder = nil
+		return
+	}
+	inBuf := make([]byte, 2*len(t.inBuf))
+	n := copy(inBuf, t.remainder)
+	if t.secret {
+		clear(t.inBuf)
+	}
+	t.inBuf = inBuf
+	t.remainder = inBuf[:n]
+}
+
+// SetPrompt sets the prompt to be used when reading subsequent lines. The
+// prompt of a line that is being read is changed by InvalidatePrompt.
+func (t *Terminal) SetPrompt(prompt string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.prompt = prompt
+	t.promptSpans = nil
+}
+
+// LineState is a snapshot of the line being edited.
+type LineState struct {
+	// Line is the text entered so far. It is empty while echo is
+	// disabled, so that passwords don't leak.
+	Line string
+	// Pos is the position of the cursor, as a byte offset into Line.
+	Pos int
+	// Prompt is the prompt the line is being entered after.
+	Prompt string
+}
+
+// LineState returns a snapshot of the line being edited, e.g. for rendering
+// previews or logging partial input from another goroutine.
+func (t *Terminal) LineState() LineState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.lineState()
+}
+
+// lineState returns a snapshot of the line being edited. t.lock must be held.
+func (t *Terminal) lineState() LineState {
+	state := LineState{Prompt: t.prompt}
+	if t.echo && t.mask == nil {
+		state.Line = string(t.line)
+		state.Pos = t.pos
+	}
+	return state
+}
+
+// clampPos returns pos limited to the valid cursor positions in the line.
+func (t *Terminal) clampPos(pos int) int {
+	return max(0, min(pos, len(t.line)))
+}
+
+// SetLine replaces the line being edited and moves the cursor to pos. It may
+// be called while another goroutine is blocked in ReadLine, e.g. from a
+// completion popup; the line is repainted as necessary.
+func (t *Terminal) SetLine(line string, pos int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.showPrompt()
+	t.setLine([]byte(line), max(0, min(pos, len(line))))
+	t.updateRightPrompt()
+	t.flush()
+}
+
+// InsertText inserts text into the line being edited at the cursor, leaving
+// the cursor after it, as if it had been typed.
+func (t *Terminal) InsertText(text string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.showPrompt()
+	t.insert([]byte(text))
+	t.updateRightPrompt()
+	t.flush()
+}
+
+// SetCursor moves the cursor to the given position in the line being edited.
+func (t *Terminal) SetCursor(pos int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.showPrompt()
+	t.pos = t.clampPos(pos)
+	t.moveCursorToPos(t.pos)
+	t.flush()
+}
+
+// SetContinuationPrompt sets the prompt used while the input is incomplete.
+// The default is "... ".
+func (t *Terminal) SetContinuationPrompt(prompt string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.continuationPrompt = prompt
+}
+
+// SetIncomplete tells the terminal whether the input entered so far is
+// incomplete, e.g. a statement spanning several lines in a REPL. While it is,
+// ReadLine uses the continuation prompt so that the user can tell the lines
+// apart from new input.
+func (t *Terminal) SetIncomplete(incomplete bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.incomplete = incomplete
+}
+
+// SetMultiLine sets whether Enter inserts a newline, letting the user edit a
+// buffer spanning several lines, e.g. a SQL statement or a Python function.
+// In multi-line mode, the buffer is submitted with Alt-Enter. Lines after the
+// first are displayed after the continuation prompt, and Up and Down move
+// between them, recalling history only from the first and last line.
+func (t *Terminal) SetMultiLine(multiLine bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.multiLine = multiLine
+}
+
+// SetEcho enables or disables local echo of the line being entered. A line
+// that is being edited is repainted accordingly.
+func (t *Terminal) SetEcho(echo bool) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if echo == t.echo {
+		return nil
+	}
+	t.echo = echo
+	if t.cursorX == 0 && t.cursorY == 0 {
+		// Nothing is on the screen yet.
+		return nil
+	}
+	t.queue(hideCursor)
+	t.clearLines()
+	t.repaint()
+	t.queue(showCursor)
+	return t.flush()
+}
+
+// Echo reports whether local echo is enabled.
+func (t *Terminal) Echo() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.echo
+}
+
+// SetRightPrompt sets a secondary prompt that is displayed flush right on the
+// input row, like zsh's RPROMPT. It disappears while the line being entered
+// would collide with it.
+func (t *Terminal) SetRightPrompt(prompt string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.rightPrompt = prompt
+	t.rightPromptWidth = stringWidth(prompt)
+}
+
+// SetPromptStyled sets a prompt made up of styled spans to be used when reading
+// subsequent lines. The spans are rendered according to the terminal's color
+// profile, and rendered again if it changes.
+func (t *Terminal) SetPromptStyled(spans ...StyledSpan) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.promptSpans = append([]StyledSpan(nil), spans...)
+	t.prompt = renderSpans(t.colorProfile, t.promptSpans)
+}
+
+// SetSize sets the size of the terminal, which should be called whenever it
+// changes. A line being edited is repainted to wrap at the new width. If
+// Events is in use, an EventResize is delivered, and the function set by
+// OnResize is called.
+func (t *Terminal) SetSize(width, height int) {
+	t.lock.Lock()
+	changed := width != t.termWidth || height != t.termHeight
+	oldWidth, oldHeight := t.termWidth, t.termHeight
+	t.termWidth, t.termHeight = width, height
+	if changed {
+		if t.recorder != nil {
+			t.recorder.Resize(width, height)
+		}
+		if width != oldWidth && width > 0 {
+			t.reflow()
+		}
+		t.statusLineResized(oldHeight)
+		t.flush()
+	}
+	onResize := t.onResize
+	t.lock.Unlock()
+
+	if changed {
+		t.post(Event{Type: EventResize, Width: width, Height: height})
+		if onResize != nil {
+			onResize(width, height)
+		}
+	}
+}
+
+// Size returns the size of the terminal, as last set by SetSize.
+func (t *Terminal) Size() (width, height int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.termWidth, t.termHeight
+}
+
+// reflow repaints the prompt and line after the width of the terminal has
+// changed, as they now wrap onto different rows. Most terminals rewrap the
+// text on the screen themselves when they are resized, which moves the
+// cursor to where it would be had the text been written at the new width,
+// and that's where it's assumed to be. Terminals that don't, like xterm, may
+// lose some rows above the prompt when they shrink.
+func (t *Terminal) reflow() {
+	editing := t.editing()
+	if t.events != nil || !editing && len(t.footer) == 0 {
+		// With Events, the application draws the screen itself.
+		return
+	}
+	y := 0
+	switch {
+	case !editing:
+		// Only the footer is on the screen, below the cursor.
+	case t.scrolling():
+		_, py := t.promptEnd()
+		y = py + t.cursorX/t.termWidth
+	default:
+		_, y = t.posToXY(t.pos)
+	}
+	t.queue(hideCursor)
+	t.move(y, 0, 0, 0)
+	t.queue([]byte{'\r'})
+	t.clearToEndOfScreen()
+	t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+	if editing {
+		t.repaint()
+	} else {
+		t.drawFooter(false)
+	}
+	t.queue(showCursor)
+}
+
+// SetColorProfile sets the color capabilities assumed for the terminal. With
+// NoColor, Escape points to empty escape codes. Applications that want to make
+// their own choice can use it to override the one taken from the environment.
+func (t *Terminal) SetColorProfile(p ColorProfile) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.colorProfile = p
+	if p == NoColor {
+		t.Escape = &EscapeCodes{}
+	} else {
+		t.Escape = &vt100EscapeCodes
+	}
+	if t.promptSpans != nil {
+		t.prompt = renderSpans(p, t.promptSpans)
+	}
+}
+
+// ColorProfile returns the color capabilities assumed for the terminal.
+func (t *Terminal) ColorProfile() ColorProfile {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.colorProfile
+}
+
+// Render returns text styled with s, degraded to what the terminal's color
+// profile is able to display.
+func (t *Terminal) Render(s Style, text string) string {
+	return s.Render(t.ColorProfile(), text)
+}
+
+// SetHistory replaces the lines that the Up and Down keys go through, oldest
+// first.
 func (t *Terminal) SetHistory(h []string) {
-	// t.history = make([][]byte, len(h))
-	// for i := range h {
-	// 	t.history[i] = []byte(h[i])
-	// }
-	// //	t.historyIdx = len(h)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.history = make([][]byte, len(h))
+	for i := range h {
+		t.history[i] = []byte(h[i])
+	}
+	t.historyIdx = len(h)
 }
 
+// GetHistory returns the lines entered so far, or set by SetHistory, oldest
+// first.
 func (t *Terminal) GetHistory() (h []string) {
-	// h = make([]string, len(t.history))
-	// for i := range t.history {
-	// 	h[i] = string(t.history[i])
-	// }
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	h = make([]string, len(t.history))
+	for i := range t.history {
+		h[i] = string(t.history[i])
+	}
 	return
 }
 
@@ -711,20 +2191,43 @@ func (sh *shell) Write(data []byte) (n int, err error) {
 	return sh.w.Write(data)
 }
 
-//var oldState *State
-
-func (t *Terminal) ReleaseFromStdInOut() { // doesn't really need a receiver, but maybe oldState can be part of term one day
-	//fd := int(os.Stdin.Fd())
-	//Restore(fd, oldState)
+// ReleaseFromStdInOut restores the settings standard input had before
+// NewWithStdInOut put it into raw mode, and stops following the size of the
+// window.
+func (t *Terminal) ReleaseFromStdInOut() {
+	if t.stopResize != nil {
+		t.stopResize()
+		t.stopResize = nil
+	}
+	if t.cooked != nil {
+		Restore(t.ttyFd, t.cooked)
+		unguard(t)
+	}
 }
 
+// NewWithStdInOut returns a Terminal on standard input and output. If standard
+// input is a terminal, it's put into raw mode until ReleaseFromStdInOut is
+// called, and the Terminal takes its size. If the program ends some other way,
+// see RestoreTerminals.
 func NewWithStdInOut(echo bool) (term *Terminal, err error) {
-	//fd := int(os.Stdin.Fd())
-	//oldState, err = MakeRaw(fd)
-	if err != nil {
-		panic(err)
-	}
 	sh := &shell{r: os.Stdin, w: os.Stdout}
 	term = NewTerminal(sh, "", echo)
-	return
+	term.SetColorProfile(DetectColorProfile())
+
+	fd := int(os.Stdin.Fd())
+	if !IsTerminal(fd) {
+		return term, nil
+	}
+	if term.cooked, err = MakeRaw(fd); err != nil {
+		return nil, err
+	}
+	term.ttyFd = fd
+	guard(term)
+	// Raw mode turns off the translation of "\n" to "\r\n".
+	term.SetTranslateNewlines(true)
+	if width, height, err := GetSize(fd); err == nil {
+		term.SetSize(width, height)
+	}
+	term.stopResize = term.watchResize(fd)
+	return term, nil
 }
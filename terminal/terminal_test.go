@@ -5,14 +5,18 @@
 package terminal
 
 import (
+	"bytes"
 	"io"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MockTerminal struct {
 	toSend       []byte
 	bytesPerRead int
 	received     []byte
+	writes       int
 }
 
 func (c *MockTerminal) Read(data []byte) (n int, err error) {
@@ -36,6 +40,7 @@ func (c *MockTerminal) Read(data []byte) (n int, err error) {
 
 func (c *MockTerminal) Write(data []byte) (n int, err error) {
 	c.received = append(c.received, data...)
+	c.writes++
 	return len(data), nil
 }
 
@@ -108,3 +113,615 @@ func TestKeyPresses(t *testing.T) {
 		}
 	}
 }
+
+func TestRightPrompt(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(20, 24)
+	ss.SetRightPrompt("[ok]")
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Fatalf("got error %v, expected EOF", err)
+	}
+	// The right prompt ends one column before the edge and the cursor
+	// returns to the end of the input.
+	if want := "> \x1b[13C[ok]"; !bytes.HasPrefix(c.received, []byte(want)) {
+		t.Errorf("got %q, expected prefix %q", c.received, want)
+	}
+	if !ss.rightPromptShown {
+		t.Errorf("right prompt not shown")
+	}
+
+	c.received = nil
+	c.toSend = []byte("cdefghijklm")
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Fatalf("got error %v, expected EOF", err)
+	}
+	if ss.rightPromptShown {
+		t.Errorf("right prompt still shown after collision")
+	}
+	if !bytes.Contains(c.received, []byte("\x1b[K")) {
+		t.Errorf("right prompt not cleared: %q", c.received)
+	}
+	if ss.cursorX != 15 {
+		t.Errorf("cursor at %d, expected 15", ss.cursorX)
+	}
+}
+
+func TestSetSizeReflow(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetSize(20, 5)
+	line := "abcdefghijklmnopqrstuvwxyz0123"
+	ss.SetLine(line, len(line))
+	if ss.cursorX != 12 || ss.cursorY != 1 {
+		t.Fatalf("cursor at %d,%d, expected 12,1", ss.cursorX, ss.cursorY)
+	}
+
+	// The terminal has rewrapped the line, which now covers four rows.
+	c.received = nil
+	ss.SetSize(10, 5)
+	if want := "\x1b[3A\r\x1b[J> " + line; !bytes.Contains(c.received, []byte(want)) {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if ss.cursorX != 2 || ss.cursorY != 3 || ss.maxLine != 3 {
+		t.Errorf("cursor at %d,%d with %d rows, expected 2,3 with 4", ss.cursorX, ss.cursorY, ss.maxLine+1)
+	}
+
+	c.received = nil
+	ss.SetSize(40, 5)
+	if want := "\r\x1b[J> " + line; !bytes.Contains(c.received, []byte(want)) {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if ss.cursorX != 32 || ss.cursorY != 0 {
+		t.Errorf("cursor at %d,%d, expected 32,0", ss.cursorX, ss.cursorY)
+	}
+
+	// Changing only the height leaves the line alone.
+	c.received = nil
+	ss.SetSize(40, 6)
+	if len(c.received) != 0 {
+		t.Errorf("got %q after changing the height", c.received)
+	}
+}
+
+func TestMultiLinePrompt(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\x1b[D")}
+	ss := NewTerminal(c, "~/src\n> ", true)
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Fatalf("got error %v, expected EOF", err)
+	}
+	if !bytes.HasPrefix(c.received, []byte("~/src\r\n> ab")) {
+		t.Errorf("got %q", c.received)
+	}
+	if ss.cursorX != 3 || ss.cursorY != 1 {
+		t.Errorf("cursor at %d,%d, expected 3,1", ss.cursorX, ss.cursorY)
+	}
+
+	c.received = nil
+	ss.Write([]byte("output\r\n"))
+	want := "\x1b[?25l\r\x1b[K\x1b[A\x1b[Koutput\r\n~/src\r\n> ab\x1b[D\x1b[?25h"
+	if string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if ss.cursorX != 3 || ss.cursorY != 1 {
+		t.Errorf("cursor at %d,%d after Write, expected 3,1", ss.cursorX, ss.cursorY)
+	}
+}
+
+func TestContinuationPrompt(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("if x {\rprint(x)\r}\r")}
+	ss := NewTerminal(c, "> ", true)
+
+	var lines []string
+	for _, incomplete := range []bool{true, true, false} {
+		line, err := ss.ReadLine()
+		if err != nil {
+			t.Fatalf("ReadLine: %v", err)
+		}
+		lines = append(lines, line)
+		ss.SetIncomplete(incomplete)
+	}
+	if want := "> if x {\r\n... print(x)\r\n... }\r\n"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if ss.prompt != "> " {
+		t.Errorf("prompt changed to %q", ss.prompt)
+	}
+}
+
+var multiLineTests = []struct {
+	in   string
+	line string
+}{
+	{"a\rb\x1b\r", "a\nb"},
+	{"abc\rd\x1b[Ax\x1b\r", "axbc\nd"},
+	{"ab\rcde\x1b[A\x1b[Bx\x1b\r", "ab\ncdxe"},
+	{"a\rb\x1b[D\177\x1b\r", "ab"},
+	{"a\rb\x1b[A\x1b[A\x1b[B\x1b[B\x1b[Bc\x1b\r", "a\nbc"},
+}
+
+func TestMultiLine(t *testing.T) {
+	for i, test := range multiLineTests {
+		c := &MockTerminal{toSend: []byte(test.in)}
+		ss := NewTerminal(c, "> ", true)
+		ss.SetMultiLine(true)
+		line, err := ss.ReadLine()
+		if line != test.line || err != nil {
+			t.Errorf("test %d: got %q, %v, expected %q", i, line, err, test.line)
+		}
+	}
+}
+
+func TestMultiLineEcho(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\rc\x1b[A")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetMultiLine(true)
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Fatalf("got error %v, expected EOF", err)
+	}
+	if want := "> ab\x1b[K\r\n... c\x1b[A\x1b[2D"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if ss.cursorX != 3 || ss.cursorY != 0 || ss.pos != 1 {
+		t.Errorf("cursor at %d,%d (pos %d), expected 3,0 (pos 1)", ss.cursorX, ss.cursorY, ss.pos)
+	}
+}
+
+func TestAcceptCallback(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("f() {\rx\r}\rg() {\x1b\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.AcceptCallback = func(line string) bool {
+		return strings.Count(line, "{") == strings.Count(line, "}")
+	}
+
+	line, err := ss.ReadLine()
+	if want := "f() {\nx\n}"; line != want || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, want)
+	}
+	if !bytes.Contains(c.received, []byte("\r\n... x")) {
+		t.Errorf("continuation prompt not shown: %q", c.received)
+	}
+
+	// Alt-Enter returns the input even if it's incomplete.
+	line, err = ss.ReadLine()
+	if want := "g() {"; line != want || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, want)
+	}
+}
+
+func TestReadLineWithDefault(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("\177X\r")}
+	ss := NewTerminal(c, "> ", true)
+	line, err := ss.ReadLineWithDefault("name: ", "value")
+	if line != "valuX" || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, "valuX")
+	}
+	if !bytes.HasPrefix(c.received, []byte("name: \x1b[?25lvalue")) {
+		t.Errorf("got %q", c.received)
+	}
+	if ss.prompt != "> " {
+		t.Errorf("prompt not restored: %q", ss.prompt)
+	}
+}
+
+func TestReadPasswordMasked(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\177c\r")}
+	ss := NewTerminal(c, "> ", true)
+	line, err := ss.ReadPasswordMasked("Password: ")
+	if line != "ac" || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, "ac")
+	}
+	if want := "Password: **\x1b[D \x1b[D*\r\n"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if len(ss.history) != 0 {
+		t.Errorf("password recorded in history: %q", ss.history)
+	}
+	if ss.mask != nil || !ss.echo {
+		t.Errorf("echo not restored")
+	}
+}
+
+func TestSetEcho(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("secret\r")}
+	ss := NewTerminal(c, "> ", false)
+	ss.ReadPassword("Password: ")
+	if ss.Echo() {
+		t.Errorf("ReadPassword enabled echo")
+	}
+
+	c.toSend = []byte("ab")
+	ss.ReadLine()
+	if want := "Password: \r\n> "; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	c.received = nil
+	ss.SetEcho(true)
+	if want := "\x1b[?25l\r\x1b[K> ab\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q after enabling echo, expected %q", c.received, want)
+	}
+}
+
+func TestBellOnError(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("\177a\t\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetBellOnError(true)
+	ss.ReadLine()
+	if want := "> \aa\a\r\n"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestBell(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ", true)
+	ss.Bell()
+	if want := "\a"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	c.received = nil
+	ss.SetVisualBell(true)
+	ss.Bell()
+	if want := "\x1b[?5h"; string(c.received) != want {
+		t.Errorf("got %q from visual bell, expected %q", c.received, want)
+	}
+}
+
+func TestLineMutation(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("X\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetLine("abc", 1)
+	ss.InsertText("12")
+	ss.SetCursor(100)
+	line, err := ss.ReadLine()
+	if want := "a12bcX"; line != want || err != nil {
+		t.Errorf("got %q, %v, expected %q", line, err, want)
+	}
+	if want := "> \x1b[?25labc\x1b[2D\x1b[?25h12bc\x1b[2D\x1b[2CX\r\n"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+func TestLineState(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("abc\x1b[D")}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadLine()
+	if state, want := ss.LineState(), (LineState{"abc", 2, "> "}); state != want {
+		t.Errorf("got %+v, expected %+v", state, want)
+	}
+
+	// The snapshot can be taken while ReadPassword is waiting for input.
+	var state LineState
+	ss.AutoCompleteCallback = func(line []byte, pos, key int) ([]byte, int) {
+		if key == 'x' {
+			state = ss.LineState()
+		}
+		return nil, 0
+	}
+	c.toSend = []byte("abx\r")
+	ss.ReadPassword("Password: ")
+	if want := (LineState{Prompt: "Password: "}); state != want {
+		t.Errorf("got %+v during ReadPassword, expected %+v", state, want)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\x1b[D")}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadLine()
+
+	c.received = nil
+	ss.Refresh()
+	if want := "\x1b[?25l\r\x1b[K> ab\x1b[D\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+
+	c.received = nil
+	ss.Redraw()
+	if want := "\x1b[?25l> ab\x1b[D\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if ss.cursorX != 3 || ss.cursorY != 0 {
+		t.Errorf("cursor at %d,%d, expected 3,0", ss.cursorX, ss.cursorY)
+	}
+}
+
+func TestHistoryRecallHidesCursor(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("abc\rx\x1b[A")}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadLine()
+	c.received = nil
+	ss.ReadLine()
+	if want := "> x\x1b[?25l\x1b[Dabc\x1b[?25h"; string(c.received) != want {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+}
+
+var setLineTests = []struct {
+	old, new string
+	out      string
+}{
+	// Only the part that changed is written.
+	{"abXdef", "abcdef", "\x1b[4Dc\x1b[3C"},
+	// A shorter line clears what's left of the old one.
+	{"abcxyz12", "abcdef", "\x1b[5Ddef\x1b[K"},
+	{"abc", "abcdef", "def"},
+	{"abc", "abc", ""},
+}
+
+func TestSetLineDiff(t *testing.T) {
+	for i, test := range setLineTests {
+		c := &MockTerminal{toSend: []byte(test.old)}
+		ss := NewTerminal(c, "> ", true)
+		ss.ReadLine()
+		c.received = nil
+		ss.SetLine(test.new, len(test.new))
+		if want := "\x1b[?25l" + test.out + "\x1b[?25h"; string(c.received) != want {
+			t.Errorf("test %d: got %q, expected %q", i, c.received, want)
+		}
+		if ss.cursorX != 2+len(test.new) {
+			t.Errorf("test %d: cursor at %d, expected %d", i, ss.cursorX, 2+len(test.new))
+		}
+	}
+}
+
+func TestMoveParameterized(t *testing.T) {
+	ss := NewTerminal(&MockTerminal{}, "> ", true)
+	ss.move(1, 2, 70, 0)
+	if want := "\x1b[A\x1b[2B\x1b[70D"; string(ss.outBuf) != want {
+		t.Errorf("got %q, expected %q", ss.outBuf, want)
+	}
+}
+
+func TestAbsoluteColumn(t *testing.T) {
+	long := strings.Repeat("x", 1000)
+	c := &MockTerminal{toSend: []byte(long)}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadLine()
+
+	ss.outBuf = ss.outBuf[:0]
+	ss.moveCursorToPos(0)
+	if want := "\x1b[12A\x1b[3G"; string(ss.outBuf) != want {
+		t.Errorf("got %q, expected %q", ss.outBuf, want)
+	}
+	ss.outBuf = ss.outBuf[:0]
+	ss.moveCursorToPos(78)
+	if want := "\x1b[B\r"; string(ss.outBuf) != want {
+		t.Errorf("got %q, expected %q", ss.outBuf, want)
+	}
+}
+
+func TestCoalescedWrites(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("abc\x1b[D\x1b[D\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetRightPrompt("<")
+	if line, _ := ss.ReadLine(); line != "abc" {
+		t.Fatalf("got %q", line)
+	}
+	// The prompt has to be written before blocking in Read, but all the
+	// keys that arrive in one read are echoed in one write.
+	if c.writes != 2 {
+		t.Errorf("got %d writes, expected 2", c.writes)
+	}
+
+	c.received, c.writes = nil, 0
+	c.toSend = []byte("x\x03")
+	ss.ReadLine()
+	if c.writes != 2 || !bytes.HasSuffix(c.received, []byte("x^C\r\n")) {
+		t.Errorf("got %d writes of %q, expected two ending in ^C", c.writes, c.received)
+	}
+}
+
+// discardTerminal is a ReadWriter that has no input and throws away output.
+type discardTerminal struct{}
+
+func (discardTerminal) Read(data []byte) (int, error)  { return 0, io.EOF }
+func (discardTerminal) Write(data []byte) (int, error) { return len(data), nil }
+
+// editingKeys are pressed repeatedly by the allocation tests and benchmarks.
+var editingKeys = []int{'a', 'b', KeyLeft, KeyBackspace, KeyRight, KeyAltLeft, KeyAltRight, KeyBackspace, KeyUp, KeyDown}
+
+func newBenchmarkTerminal() *Terminal {
+	ss := NewTerminal(discardTerminal{}, "\x1b[32m/home/user/src/github.com/project> \x1b[0m", true)
+	ss.SetRightPrompt("[main]")
+	ss.history = append(ss.history, []byte("some earlier command"))
+	for _, key := range "echo hello world" {
+		ss.handleKey(int(key))
+	}
+	ss.flush()
+	return ss
+}
+
+func pressKeys(ss *Terminal, keys []int) {
+	for _, key := range keys {
+		ss.handleKey(key)
+		ss.updateRightPrompt()
+		ss.flush()
+	}
+}
+
+func TestKeyPressAllocs(t *testing.T) {
+	ss := newBenchmarkTerminal()
+	pressKeys(ss, editingKeys)
+	if n := testing.AllocsPerRun(100, func() { pressKeys(ss, editingKeys) }); n != 0 {
+		t.Errorf("got %v allocations per run, expected none", n)
+	}
+
+	in := []byte("ab\x1b[D\x1b[1;3C\x1b[3~")
+	if n := testing.AllocsPerRun(100, func() {
+		for rest := in; len(rest) > 0; {
+			_, rest = bytesToKey(rest)
+		}
+	}); n != 0 {
+		t.Errorf("got %v allocations decoding keys, expected none", n)
+	}
+}
+
+func BenchmarkKeyPresses(b *testing.B) {
+	ss := newBenchmarkTerminal()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pressKeys(ss, editingKeys)
+	}
+}
+
+func BenchmarkBytesToKey(b *testing.B) {
+	in := []byte("hello\x1b[A\x1b[D\x1b[1;3C\x1b[3~world")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for rest := in; len(rest) > 0; {
+			_, rest = bytesToKey(rest)
+		}
+	}
+}
+
+func TestLargePaste(t *testing.T) {
+	long := strings.Repeat("0123456789", 100)
+	// An unknown escape sequence longer than the initial input buffer.
+	osc := "\x1b]1337;" + strings.Repeat("x", 600) + "\x07"
+	in := long + "\x1b[D\x1b[C\r" + osc + "second\r" + "third\r"
+	for _, bytesPerRead := range []int{0, 1, 7, 255, 256, 257} {
+		c := &MockTerminal{toSend: []byte(in), bytesPerRead: bytesPerRead}
+		ss := NewTerminal(c, "> ", true)
+		for _, want := range []string{long, "second", "third"} {
+			line, err := ss.ReadLine()
+			if line != want || err != nil {
+				t.Errorf("%d bytes per read: got %.20q (%d bytes), %v, expected %.20q (%d bytes)", bytesPerRead, line, len(line), err, want, len(want))
+			}
+		}
+	}
+}
+
+// eofReader returns all its data together with io.EOF.
+type eofReader struct {
+	MockTerminal
+}
+
+func (r *eofReader) Read(data []byte) (int, error) {
+	n := copy(data, r.toSend)
+	r.toSend = r.toSend[n:]
+	return n, io.EOF
+}
+
+func TestReadWithEOF(t *testing.T) {
+	c := &eofReader{MockTerminal{toSend: []byte("abc\rdef")}}
+	ss := NewTerminal(c, "> ", true)
+	if line, err := ss.ReadLine(); line != "abc" || err != nil {
+		t.Errorf("got %q, %v, expected abc", line, err)
+	}
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Errorf("got %v, expected EOF", err)
+	}
+	if string(ss.line) != "def" {
+		t.Errorf("input after the last line lost: %q", ss.line)
+	}
+}
+
+func TestBulkInsert(t *testing.T) {
+	tail := strings.Repeat("t", 1000)
+	paste := strings.Repeat("p", 3000)
+	c := &MockTerminal{toSend: []byte(tail)}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadLine()
+	ss.SetCursor(0)
+
+	c.received = nil
+	c.toSend = []byte(paste + "\r")
+	line, err := ss.ReadLine()
+	if line != paste+tail || err != nil {
+		t.Errorf("got %d bytes, %v, expected %d", len(line), err, len(paste+tail))
+	}
+	// The tail of the line is only repainted once.
+	if len(c.received) > 2*len(line) {
+		t.Errorf("pasting %d bytes wrote %d bytes", len(paste), len(c.received))
+	}
+}
+
+func TestSplitUTF8(t *testing.T) {
+	// Characters typed with compose or dead keys, arriving a byte at a
+	// time.
+	for _, withCallback := range []bool{false, true} {
+		c := &MockTerminal{
+			toSend:       []byte("na\xc3\xafve \xe2\x82\xac5 \xf0\x9f\x91\x8d\r"),
+			bytesPerRead: 1,
+		}
+		ss := NewTerminal(c, "> ", true)
+		if withCallback {
+			ss.AutoCompleteCallback = func(line []byte, pos, key int) ([]byte, int) {
+				return nil, 0
+			}
+		}
+		if line, err := ss.ReadLine(); err != nil || line != "na\u00efve \u20ac5 \U0001f44d" {
+			t.Errorf("got %q, %v, expected the characters to be reassembled", line, err)
+		}
+	}
+}
+
+func TestInvalidatePrompt(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\x1b[D\x14\x14\x15c\r")}
+	ss := NewTerminal(c, "12:00> ", true)
+	prompts := []string{"12:01> ", "12:01> ", "longer prompt> "}
+	// Each key press stands for a tick of a clock in another goroutine.
+	for _, key := range []int{'\x14', '\x15'} {
+		ss.RegisterKeyHook(key, func(LineState) Action {
+			c.received = append(c.received, '|')
+			ss.SetPrompt(prompts[0])
+			prompts = prompts[1:]
+			if err := ss.InvalidatePrompt(); err != nil {
+				t.Error(err)
+			}
+			return Consume
+		})
+	}
+	if line, err := ss.ReadLine(); err != nil || line != "acb" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	// Only the prompt is written the first time, and nothing if it
+	// hasn't changed.
+	if want := "|\x1b[?25l\r12:01> \x1b[C\x1b[?25h||"; !bytes.Contains(c.received, []byte(want)) {
+		t.Errorf("got %q, expected %q", c.received, want)
+	}
+	if want := "longer prompt> ab"; !bytes.Contains(c.received, []byte(want)) {
+		t.Errorf("got %q, expected the line repainted after %q", c.received, want)
+	}
+
+	// Nothing is written while no line is being read.
+	c.received = nil
+	ss.SetPrompt("> ")
+	if err := ss.InvalidatePrompt(); err != nil || len(c.received) > 0 {
+		t.Errorf("got %q, %v", c.received, err)
+	}
+}
+
+func TestConcurrentRead(t *testing.T) {
+	r, w := io.Pipe()
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, io.Discard}, "> ", true)
+	done := make(chan string)
+	go func() {
+		line, _ := ss.ReadLine()
+		done <- line
+	}()
+	// Wait for the first ReadLine to be reading.
+	w.Write([]byte("a"))
+	for ss.LineState().Line != "a" {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := ss.ReadLine(); err != ErrConcurrentRead {
+		t.Errorf("ReadLine: got %v, expected ErrConcurrentRead", err)
+	}
+	if _, err := ss.ReadPassword("Password: "); err != ErrConcurrentRead {
+		t.Errorf("ReadPassword: got %v, expected ErrConcurrentRead", err)
+	}
+	if _, err := ss.Confirm("Sure? ", true); err != ErrConcurrentRead {
+		t.Errorf("Confirm: got %v, expected ErrConcurrentRead", err)
+	}
+	w.Write([]byte("b\r"))
+	if line := <-done; line != "ab" {
+		t.Errorf("got %q from the first reader", line)
+	}
+}
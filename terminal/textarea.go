@@ -0,0 +1,262 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultTextAreaHeight is the number of rows of a TextArea, including its
+// border, unless specified otherwise.
+const defaultTextAreaHeight = 8
+
+// TextArea edits text spanning several lines, e.g. a commit message, in a box
+// of fixed size. Long lines are wrapped and the text scrolls to keep the cursor
+// in view.
+type TextArea struct {
+	t             *Terminal
+	width, height int
+	text          []rune
+	pos           int
+	// top is the first row of text shown.
+	top int
+}
+
+// NewTextArea returns a TextArea taking up width columns and height rows,
+// including its border. If either is 0, the width of the terminal or eight
+// rows are used.
+func (t *Terminal) NewTextArea(width, height int) *TextArea {
+	return &TextArea{t: t, width: width, height: height}
+}
+
+// SetText sets the text to be edited. The cursor is put at its end.
+func (a *TextArea) SetText(text string) {
+	a.text = []rune(text)
+	a.pos = len(a.text)
+}
+
+// Edit shows the box and lets the user edit the text in it. Enter starts a
+// new line and the arrow keys move the cursor. Ctrl-S or Escape finish
+// editing and return the text. ErrInterrupted is returned if the user
+// pressed Ctrl-C.
+func (a *TextArea) Edit() (string, error) {
+	t := a.t
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err := t.startReading(); err != nil {
+		return "", err
+	}
+	defer t.doneReading()
+
+	for {
+		a.draw()
+		key, b, err := t.readKey()
+		if err != nil {
+			a.finish()
+			return "", err
+		}
+
+		switch key {
+		case KeyCtrlS, KeyEscape:
+			a.finish()
+			return string(a.text), nil
+		case KeyCtrlC:
+			a.finish()
+			return "", ErrInterrupted
+		case KeyLeft:
+			if a.pos == 0 {
+				t.invalidKey()
+				continue
+			}
+			a.pos--
+		case KeyRight:
+			if a.pos == len(a.text) {
+				t.invalidKey()
+				continue
+			}
+			a.pos++
+		case KeyUp, KeyDown:
+			rows := a.rows()
+			row, col := a.cursor(rows)
+			if key == KeyUp {
+				row--
+			} else {
+				row++
+			}
+			if row < 0 || row >= len(rows) {
+				t.invalidKey()
+				continue
+			}
+			a.pos = a.column(rows[row], col)
+		case KeyBackspace:
+			if a.pos == 0 {
+				t.invalidKey()
+				continue
+			}
+			a.text = append(a.text[:a.pos-1], a.text[a.pos:]...)
+			a.pos--
+		case KeyEnter:
+			a.insert('\n')
+		case KeyRune:
+			r, _ := utf8.DecodeRune(b)
+			a.insert(r)
+		default:
+			if !isPrintable(key) {
+				t.invalidKey()
+				continue
+			}
+			a.insert(rune(key))
+		}
+	}
+}
+
+func (a *TextArea) insert(r rune) {
+	a.text = append(a.text, 0)
+	copy(a.text[a.pos+1:], a.text[a.pos:])
+	a.text[a.pos] = r
+	a.pos++
+}
+
+// size returns the size of the box including its border.
+func (a *TextArea) size() (width, height int) {
+	width, height = a.width, a.height
+	if width <= 0 {
+		// The last column is left empty so that the terminal doesn't
+		// wrap.
+		width = a.t.termWidth - 1
+	}
+	if height <= 0 {
+		height = defaultTextAreaHeight
+	}
+	return max(width, 3), max(height, 3)
+}
+
+// textRow is a row of text in a TextArea, given as the indexes of its first
+// rune and of the one after its last.
+type textRow struct {
+	start, end int
+}
+
+// rows splits the text into rows that fit into the box.
+func (a *TextArea) rows() []textRow {
+	width, _ := a.size()
+	inner := width - 2
+
+	var rows []textRow
+	start, w := 0, 0
+	for i := 0; i <= len(a.text); i++ {
+		if i == len(a.text) || a.text[i] == '\n' {
+			rows = append(rows, textRow{start, i})
+			start, w = i+1, 0
+			continue
+		}
+		rw := runeWidth(a.text[i])
+		if w+rw > inner {
+			rows = append(rows, textRow{start, i})
+			start, w = i, 0
+		}
+		w += rw
+	}
+	return rows
+}
+
+// cursor returns the row and column of the cursor.
+func (a *TextArea) cursor(rows []textRow) (row, col int) {
+	for i, r := range rows {
+		if r.start <= a.pos {
+			row = i
+		}
+	}
+	r := rows[row]
+	return row, runesWidth(a.text[r.start:a.pos])
+}
+
+// column returns the index of the rune in r at column col, or the end of r if
+// it's shorter.
+func (a *TextArea) column(r textRow, col int) int {
+	i, w := r.start, 0
+	for i < r.end {
+		rw := runeWidth(a.text[i])
+		if w+rw > col {
+			break
+		}
+		w += rw
+		i++
+	}
+	return i
+}
+
+func runesWidth(runes []rune) int {
+	w := 0
+	for _, r := range runes {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// draw paints the box and text and moves the cursor into it.
+func (a *TextArea) draw() {
+	t := a.t
+	width, height := a.size()
+	inner, innerHeight := width-2, height-2
+	rows := a.rows()
+	row, col := a.cursor(rows)
+	a.top = max(min(a.top, row), row-innerHeight+1)
+
+	t.queue(hideCursor)
+	t.clearLines()
+	t.maxLine = 0
+	t.writeText([]byte("┌" + strings.Repeat("─", inner) + "┐"))
+	var b []byte
+	for i := a.top; i < a.top+innerHeight; i++ {
+		b = append(b[:0], "│"...)
+		w := 0
+		if i < len(rows) {
+			for _, r := range a.text[rows[i].start:rows[i].end] {
+				b = utf8.AppendRune(b, r)
+				w += runeWidth(r)
+			}
+		}
+		for ; w < inner; w++ {
+			b = append(b, ' ')
+		}
+		b = append(b, "│"...)
+		t.writeText(newline)
+		t.writeText(b)
+	}
+	t.writeText(newline)
+	t.writeText([]byte("└" + strings.Repeat("─", inner) + "┘"))
+
+	// After the end of a full row the cursor stays on its last
+	// column rather than covering the border.
+	x, y := 1+min(col, inner-1), 1+row-a.top
+	t.move(t.cursorY-y, 0, 0, 0)
+	t.moveToColumn(t.cursorX, x)
+	t.cursorX, t.cursorY = x, y
+	t.queue(showCursor)
+	t.flush()
+}
+
+// finish leaves the box on the screen and moves the cursor below it.
+func (a *TextArea) finish() {
+	t := a.t
+	t.move(0, t.maxLine-t.cursorY, 0, 0)
+	t.queue([]byte("\r\n"))
+	t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+	if len(t.footer) > 0 {
+		t.drawFooter(false)
+	}
+	t.flush()
+}
+
+// ReadText is a shorthand for editing text, starting with initial, in a
+// TextArea of the given size.
+func (t *Terminal) ReadText(width, height int, initial string) (string, error) {
+	a := t.NewTextArea(width, height)
+	a.SetText(initial)
+	return a.Edit()
+}
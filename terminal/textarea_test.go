@@ -0,0 +1,89 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+var textAreaTests = []struct {
+	initial string
+	in      string
+	text    string
+	err     error
+}{
+	{"", "ab\rcd\x13", "ab\ncd", nil},
+	{"", "abc\x1b", "abc", nil},
+	{"fix", "\x1b[D\x1b[D\x7f\x7fF\x13", "Fix", nil},
+	// Up and down keep the column.
+	{"abcd\nef", "\x1b[A\x1b[DX\x1b[BY\x13", "aXbcd\nefY", nil},
+	// Lines are wrapped at the width of the box.
+	{"abcdefgh", "\x1b[AX\x13", "abcXdefgh", nil},
+	{"", "abcdef\x1b[AX\x13", "aXbcdef", nil},
+	{"", "aé日\x13", "aé日", nil},
+	{"abc", "\x03", "", ErrInterrupted},
+	{"abc", "", "", io.EOF},
+}
+
+func TestTextArea(t *testing.T) {
+	for i, test := range textAreaTests {
+		c := &MockTerminal{toSend: []byte(test.in)}
+		ss := NewTerminal(c, "> ", true)
+		a := ss.NewTextArea(7, 4)
+		a.SetText(test.initial)
+		text, err := a.Edit()
+		if text != test.text || err != test.err {
+			t.Errorf("test %d: got %q, %v, expected %q, %v", i, text, err, test.text, test.err)
+		}
+		if ss.cursorX != 0 || ss.cursorY != 0 || ss.maxLine != 0 {
+			t.Errorf("test %d: cursor state not reset", i)
+		}
+	}
+}
+
+func TestTextAreaOutput(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("\x13")}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadText(7, 4, "abcdefg")
+	out := string(c.received)
+	box := "┌─────┐\r\n│abcde│\r\n│fg   │\r\n└─────┘"
+	if !strings.Contains(out, box) {
+		t.Errorf("got %q, expected it to contain %q", out, box)
+	}
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Errorf("got %q, expected the cursor below the box", out)
+	}
+}
+
+func TestTextAreaScroll(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("\x13")}
+	ss := NewTerminal(c, "> ", true)
+	ss.ReadText(7, 4, "a\nb\nc")
+	out := string(c.received)
+	box := "┌─────┐\r\n│b    │\r\n│c    │\r\n└─────┘"
+	if !strings.Contains(out, box) {
+		t.Errorf("got %q, expected it to contain %q", out, box)
+	}
+}
+
+func TestTextAreaSplitEscape(t *testing.T) {
+	r, w := io.Pipe()
+	ss := NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{r, &MockTerminal{}}, "> ", true)
+	go func() {
+		// The left arrow arrives over two reads.
+		w.Write([]byte("ab\x1b"))
+		w.Write([]byte("[DX\x13"))
+	}()
+	a := ss.NewTextArea(7, 4)
+	if text, err := a.Edit(); text != "aXb" || err != nil {
+		t.Errorf("got %q, %v, expected %q", text, err, "aXb")
+	}
+	r.Close()
+}
@@ -0,0 +1,215 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "strings"
+
+// Quote is the kind of quoting in effect at a point of a line.
+type Quote int
+
+const (
+	NoQuote     Quote = iota
+	SingleQuote       // inside '...'
+	DoubleQuote       // inside "..."
+)
+
+// Token is a word of a line split as a POSIX shell does.
+type Token struct {
+	// Text is the word with its quotes and escapes removed.
+	Text string
+	// Start and End are the byte offsets of the word in the line, as
+	// typed.
+	Start, End int
+	// Quote is the quoting in effect at the end of the word, which isn't
+	// NoQuote if a quote is left open.
+	Quote Quote
+	// Operator is true for the operators separating commands and
+	// redirections: ; & | < > and their doubled forms.
+	Operator bool
+}
+
+// TokenContext describes the words of a line and where the cursor is among
+// them, for completion.
+type TokenContext struct {
+	// Tokens are the words of the whole line.
+	Tokens []Token
+	// Index is the index in Tokens of the word the cursor is in or at the
+	// end of. If the cursor is between words, a new word starts there, and
+	// Index is that of the word that follows, or len(Tokens).
+	Index int
+	// Arg is the index of the word within its command, counting from 0
+	// for the command's name.
+	Arg int
+	// Start is the byte offset at which the word under the cursor starts.
+	Start int
+	// Prefix is the part of the word before the cursor, with its quotes
+	// and escapes removed.
+	Prefix string
+	// Quote is the quoting in effect at the cursor.
+	Quote Quote
+}
+
+// Tokenize splits line into words as a POSIX shell does, honoring quotes and
+// backslash escapes, and reports which word the cursor at byte offset pos is
+// in, so that completers can work on words rather than bytes. Expansions such
+// as $var are left as they are.
+func Tokenize(line string, pos int) TokenContext {
+	pos = max(0, min(pos, len(line)))
+	ctx := TokenContext{Tokens: tokenize(line), Start: pos}
+
+	before := tokenize(line[:pos])
+	ctx.Index = len(before)
+	if n := len(before); n > 0 && before[n-1].End == pos && !before[n-1].Operator {
+		// The cursor is in the last word, or right after it.
+		cur := before[n-1]
+		ctx.Index = n - 1
+		ctx.Start = cur.Start
+		ctx.Prefix = cur.Text
+		ctx.Quote = cur.Quote
+	}
+	for i := ctx.Index - 1; i >= 0 && !before[i].Operator; i-- {
+		ctx.Arg++
+	}
+	return ctx
+}
+
+// tokenize splits line into tokens.
+func tokenize(line string) []Token {
+	var tokens []Token
+	var text strings.Builder
+	var cur *Token
+	quote := NoQuote
+
+	end := func(i int) {
+		if cur != nil {
+			cur.Text, cur.End, cur.Quote = text.String(), i, quote
+			tokens = append(tokens, *cur)
+			cur = nil
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if quote == NoQuote {
+			if c == ' ' || c == '\t' || c == '\n' {
+				end(i)
+				continue
+			}
+			if strings.IndexByte(";&|<>", c) >= 0 {
+				end(i)
+				n := 1
+				if i+1 < len(line) && line[i+1] == c && c != ';' {
+					n = 2
+				}
+				tokens = append(tokens, Token{Text: line[i : i+n], Start: i, End: i + n, Operator: true})
+				i += n - 1
+				continue
+			}
+		}
+		if cur == nil {
+			cur = &Token{Start: i}
+		}
+		switch {
+		case quote == SingleQuote:
+			if c == '\'' {
+				quote = NoQuote
+			} else {
+				text.WriteByte(c)
+			}
+		case c == '\\':
+			if i+1 == len(line) {
+				break
+			}
+			i++
+			if quote == DoubleQuote && strings.IndexByte("$`\"\\\n", line[i]) < 0 {
+				// Other backslashes are kept in double quotes.
+				text.WriteByte('\\')
+			}
+			if line[i] != '\n' {
+				text.WriteByte(line[i])
+			}
+		case c == '"':
+			if quote == DoubleQuote {
+				quote = NoQuote
+			} else {
+				quote = DoubleQuote
+			}
+		case c == '\'' && quote == NoQuote:
+			quote = SingleQuote
+		default:
+			text.WriteByte(c)
+		}
+	}
+	end(len(line))
+	return tokens
+}
+
+// shellSpecial holds the bytes that have to be quoted outside quotes.
+const shellSpecial = " \t\n'\"\\$`;&|<>()*?[]{}#~!"
+
+// QuoteWord returns word quoted for a POSIX shell, for insertion where quote
+// is in effect: outside quotes, special characters are escaped with
+// backslashes, and words with newlines are put into single quotes.
+func QuoteWord(word string, quote Quote) string {
+	var b strings.Builder
+	switch quote {
+	case SingleQuote:
+		// A single quote ends the quoted text, so one is written as
+		// '\'' to end it, escape it and start it again.
+		return strings.ReplaceAll(word, "'", `'\''`)
+	case DoubleQuote:
+		for i := 0; i < len(word); i++ {
+			if strings.IndexByte("$`\"\\", word[i]) >= 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(word[i])
+		}
+		return b.String()
+	}
+	if strings.IndexByte(word, '\n') >= 0 {
+		// A backslash followed by a newline is removed, not escaped.
+		return "'" + QuoteWord(word, SingleQuote) + "'"
+	}
+	for i := 0; i < len(word); i++ {
+		if strings.IndexByte(shellSpecial, word[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(word[i])
+	}
+	return b.String()
+}
+
+// Complete replaces the word under the cursor at pos in line, which ctx
+// describes, up to the cursor, with word, quoted as QuoteWord does for the
+// quoting in effect there, and returns the new line and cursor position. If
+// final is true, the word is complete: an open quote is closed and a space
+// follows it, unless one already does. Otherwise, e.g. for a directory that
+// may be completed further, the cursor is left right after word.
+func (ctx TokenContext) Complete(line string, pos int, word string, final bool) (newLine string, newPos int) {
+	pos = max(0, min(pos, len(line)))
+	var text string
+	switch ctx.Quote {
+	case SingleQuote:
+		text = "'" + QuoteWord(word, SingleQuote)
+	case DoubleQuote:
+		text = `"` + QuoteWord(word, DoubleQuote)
+	default:
+		text = QuoteWord(word, NoQuote)
+	}
+	rest := line[pos:]
+	if final {
+		switch ctx.Quote {
+		case SingleQuote:
+			text += "'"
+		case DoubleQuote:
+			text += `"`
+		}
+		if !strings.HasPrefix(rest, " ") {
+			text += " "
+		}
+	}
+	newLine = line[:ctx.Start] + text
+	return newLine + rest, len(newLine)
+}
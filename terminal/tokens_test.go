@@ -0,0 +1,119 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	line := `ls -l 'my dir'/"a b" x\ y|grep "un`
+	ctx := Tokenize(line, len(line))
+	want := []Token{
+		{Text: "ls", Start: 0, End: 2},
+		{Text: "-l", Start: 3, End: 5},
+		{Text: "my dir/a b", Start: 6, End: 20},
+		{Text: "x y", Start: 21, End: 25},
+		{Text: "|", Start: 25, End: 26, Operator: true},
+		{Text: "grep", Start: 26, End: 30},
+		{Text: "un", Start: 31, End: 34, Quote: DoubleQuote},
+	}
+	if !reflect.DeepEqual(ctx.Tokens, want) {
+		t.Errorf("got tokens %+v, expected %+v", ctx.Tokens, want)
+	}
+	if ctx.Index != 6 || ctx.Arg != 1 || ctx.Start != 31 || ctx.Prefix != "un" || ctx.Quote != DoubleQuote {
+		t.Errorf("got context %+v at the end", ctx)
+	}
+}
+
+var tokenContextTests = []struct {
+	line  string
+	pos   int
+	index int
+	arg   int
+	start int
+	pre   string
+	quote Quote
+}{
+	{"", 0, 0, 0, 0, "", NoQuote},
+	{"git ch", 6, 1, 1, 4, "ch", NoQuote},
+	{"git ch", 2, 0, 0, 0, "gi", NoQuote},
+	{"git  ch", 4, 1, 1, 4, "", NoQuote},
+	{"git ", 4, 1, 1, 4, "", NoQuote},
+	{"a; b", 3, 2, 0, 3, "", NoQuote},
+	{"cat 'it''s", 10, 1, 1, 4, "its", SingleQuote},
+	{`echo "a\"b\c`, 12, 1, 1, 5, `a"b\c`, DoubleQuote},
+	{`cd x\`, 5, 1, 1, 3, "x", NoQuote},
+}
+
+func TestTokenContext(t *testing.T) {
+	for _, test := range tokenContextTests {
+		ctx := Tokenize(test.line, test.pos)
+		if ctx.Index != test.index || ctx.Arg != test.arg || ctx.Start != test.start ||
+			ctx.Prefix != test.pre || ctx.Quote != test.quote {
+			t.Errorf("Tokenize(%q, %d) = %+v, expected index %d, arg %d, start %d, prefix %q, quote %d",
+				test.line, test.pos, ctx, test.index, test.arg, test.start, test.pre, test.quote)
+		}
+	}
+}
+
+var quoteWordTests = []struct {
+	word  string
+	quote Quote
+	want  string
+}{
+	{"plain", NoQuote, "plain"},
+	{"my file (1).txt", NoQuote, `my\ file\ \(1\).txt`},
+	{"$HOME;x", NoQuote, `\$HOME\;x`},
+	{"a\nb", NoQuote, "'a\nb'"},
+	{"it's", SingleQuote, `it'\''s`},
+	{`say "$hi"`, DoubleQuote, `say \"\$hi\"`},
+}
+
+func TestQuoteWord(t *testing.T) {
+	for _, test := range quoteWordTests {
+		if got := QuoteWord(test.word, test.quote); got != test.want {
+			t.Errorf("QuoteWord(%q, %d) = %q, expected %q", test.word, test.quote, got, test.want)
+		}
+		// The word comes back out of the tokenizer.
+		quoted := test.want
+		switch test.quote {
+		case SingleQuote:
+			quoted = "'" + quoted + "'"
+		case DoubleQuote:
+			quoted = `"` + quoted + `"`
+		}
+		if tokens := tokenize(quoted); len(tokens) != 1 || tokens[0].Text != test.word {
+			t.Errorf("%q is tokenized as %+v, expected %q", quoted, tokens, test.word)
+		}
+	}
+}
+
+var completeTests = []struct {
+	line  string
+	pos   int
+	word  string
+	final bool
+	want  string
+	pos2  int
+}{
+	{"cat my", 6, "my file", true, `cat my\ file `, 13},
+	{`cat "my`, 7, "my file", true, `cat "my file" `, 14},
+	{"cat 'it", 7, "it's", false, `cat 'it'\''s`, 12},
+	{"cat my x", 6, "my dir/", false, `cat my\ dir/ x`, 12},
+	{"cat m x", 5, "my", true, "cat my x", 6},
+}
+
+func TestComplete(t *testing.T) {
+	for _, test := range completeTests {
+		ctx := Tokenize(test.line, test.pos)
+		got, pos := ctx.Complete(test.line, test.pos, test.word, test.final)
+		if got != test.want || pos != test.pos2 {
+			t.Errorf("completing %q at %d with %q = %q, %d, expected %q, %d",
+				test.line, test.pos, test.word, got, pos, test.want, test.pos2)
+		}
+	}
+}
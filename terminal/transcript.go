@@ -0,0 +1,56 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import "io"
+
+// redactedInput takes the place of a password in the input transcript.
+var redactedInput = []byte("[redacted]")
+
+// SetTranscript makes the terminal copy everything it writes to out and
+// everything it reads to in, e.g. to capture a session for support. Either may
+// be nil, and both may be the same writer. The input typed while a password is
+// read is replaced by a single "[redacted]", and the output while a masked
+// password is read with SetPasswordReveal in effect, which may show part of
+// it, is left out. Errors writing the transcript are ignored.
+func (t *Terminal) SetTranscript(out, in io.Writer) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.transcriptOut, t.transcriptIn = out, in
+	t.recorder = nil
+}
+
+// recordOutput copies data written to the terminal to the transcript. t.lock
+// must be held.
+func (t *Terminal) recordOutput(data []byte) {
+	if t.transcriptOut == nil || t.revealing() {
+		return
+	}
+	t.transcriptOut.Write(data)
+}
+
+// revealing reports whether a masked password is being read whose characters
+// may be shown, by reveal, in the output. Whether they're shown at the time of
+// a flush doesn't tell, as one may be revealed and masked again before it.
+func (t *Terminal) revealing() bool {
+	return t.secret && t.mask != nil && t.revealDuration > 0
+}
+
+// recordInput copies data read from the terminal to the transcript. t.lock
+// must be held.
+func (t *Terminal) recordInput(data []byte) {
+	if t.transcriptIn == nil || len(data) == 0 {
+		return
+	}
+	if t.secret {
+		if !t.redacted {
+			t.transcriptIn.Write(redactedInput)
+			t.redacted = true
+		}
+		return
+	}
+	t.transcriptIn.Write(data)
+}
@@ -0,0 +1,67 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscript(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ls\rhunter2\r"), bytesPerRead: 1}
+	ss := NewTerminal(c, "> ", true)
+	var out, in bytes.Buffer
+	ss.SetTranscript(&out, &in)
+
+	if line, _ := ss.ReadLine(); line != "ls" {
+		t.Fatalf("got %q, expected ls", line)
+	}
+	ss.Write([]byte("file\r\n"))
+	if pw, _ := ss.ReadPassword("Password: "); pw != "hunter2" {
+		t.Fatalf("got %q, expected hunter2", pw)
+	}
+
+	if got, want := in.String(), "ls\r[redacted]"; got != want {
+		t.Errorf("got input %q, expected %q", got, want)
+	}
+	if got := out.String(); got != string(c.received) {
+		t.Errorf("got output %q, expected %q", got, c.received)
+	}
+	if !strings.Contains(out.String(), "file\r\n") {
+		t.Errorf("output %q is missing what was written", out.String())
+	}
+}
+
+func TestTranscriptReveal(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("secret\r"), bytesPerRead: 1}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetPasswordReveal(time.Hour)
+	var out bytes.Buffer
+	ss.SetTranscript(&out, nil)
+	ss.ReadPasswordMasked("Password: ")
+
+	if strings.ContainsAny(strings.TrimPrefix(out.String(), "Password: "), "secrt") {
+		t.Errorf("output %q shows the password", out.String())
+	}
+}
+
+func TestTranscriptRevealOneRead(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab\r")}
+	ss := NewTerminal(c, "> ", true)
+	ss.SetPasswordReveal(time.Hour)
+	var out bytes.Buffer
+	ss.SetTranscript(&out, nil)
+	if pw, _ := ss.ReadPasswordMasked("Password: "); pw != "ab" {
+		t.Fatalf("got %q, expected ab", pw)
+	}
+	if !strings.Contains(string(c.received), "b") {
+		t.Fatalf("got %q, expected b to be revealed", c.received)
+	}
+	if strings.Contains(out.String(), "b") {
+		t.Errorf("output %q shows the password", out.String())
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ellipsis replaces the part of a prompt that is cut off.
+const ellipsis = "…"
+
+// SetPromptTruncation makes prompts that would leave fewer than minInput
+// columns for the line lose text on the left, which is replaced by an
+// ellipsis, like shells do with long working directories. Rows of the prompt
+// above the input row are cut to the width of the terminal. This keeps the
+// line editable in narrow panes. A minInput of 0 turns truncation off, which
+// is the default.
+func (t *Terminal) SetPromptTruncation(minInput int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if minInput < 0 {
+		minInput = 0
+	}
+	t.promptMinInput = minInput
+}
+
+// shownPrompt returns the prompt as it is displayed at the current width.
+func (t *Terminal) shownPrompt() string {
+	if t.promptMinInput == 0 {
+		return t.prompt
+	}
+	rows := strings.Split(t.prompt, "\n")
+	for i, row := range rows {
+		width := t.termWidth
+		if i == len(rows)-1 {
+			width = max(width-t.promptMinInput, 1)
+		}
+		rows[i] = truncateLeft(row, width)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// truncateLeft shortens s to at most width columns by replacing text at its
+// start with an ellipsis. Escape sequences are kept, so that styles still
+// apply to what remains.
+func truncateLeft(s string, width int) string {
+	excess := stringWidth(s) - width
+	if excess <= 0 {
+		return s
+	}
+	// Make room for the ellipsis.
+	excess++
+	b := make([]byte, 0, len(s)+len(ellipsis))
+	for i := 0; i < len(s); {
+		if s[i] == KeyEscape {
+			n := escapeLength([]byte(s[i:]))
+			if n < 0 {
+				break
+			}
+			b = append(b, s[i:i+n]...)
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if excess > 0 {
+			excess -= runeWidth(r)
+			if excess <= 0 {
+				b = append(b, ellipsis...)
+			}
+		} else {
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return string(b)
+}
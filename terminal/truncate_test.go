@@ -0,0 +1,63 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+var truncateLeftTests = []struct {
+	in    string
+	width int
+	want  string
+}{
+	{"abc> ", 5, "abc> "},
+	{"abcdef> ", 5, "…ef> "},
+	{"日本語> ", 5, "…語> "},
+	{"日本語> ", 4, "…> "},
+	{"\x1b[1mabcdef\x1b[0m> ", 4, "\x1b[1m…f\x1b[0m> "},
+	{"abc", 0, ""},
+}
+
+func TestTruncateLeft(t *testing.T) {
+	for _, test := range truncateLeftTests {
+		if got := truncateLeft(test.in, test.width); got != test.want {
+			t.Errorf("truncateLeft(%q, %d) = %q, want %q", test.in, test.width, got, test.want)
+		}
+	}
+}
+
+func TestPromptTruncation(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ls\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "top\n/very/long/path> ", true)
+	ss.SetSize(12, 5)
+	ss.SetPromptTruncation(6)
+	if line, err := ss.ReadLine(); err != nil || line != "ls" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	if !bytes.Contains(c.received, []byte("top\r\n…ath> ls")) {
+		t.Errorf("prompt wasn't truncated: %q", c.received)
+	}
+	if bytes.Contains(c.received, []byte("/very")) {
+		t.Errorf("truncated text was written: %q", c.received)
+	}
+	if x, y := ss.posToXY(2); x != 8 || y != 1 {
+		t.Errorf("cursor at %d,%d after the line, want 8,1", x, y)
+	}
+
+	c.toSend = []byte("ls\r")
+	c.received = nil
+	ss.SetPromptTruncation(0)
+	if _, err := ss.ReadLine(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(c.received, []byte("/very/long/path> ")) {
+		t.Errorf("prompt was truncated after turning truncation off: %q", c.received)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges lists the code points that occupy two columns: East Asian Wide
+// and Fullwidth characters, and emoji presented as such by default.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f}, {0x231a, 0x231b}, {0x2329, 0x232a}, {0x23e9, 0x23ec},
+	{0x23f0, 0x23f0}, {0x23f3, 0x23f3}, {0x25fd, 0x25fe}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267f, 0x267f}, {0x2693, 0x2693}, {0x26a1, 0x26a1},
+	{0x26aa, 0x26ab}, {0x26bd, 0x26be}, {0x26c4, 0x26c5}, {0x26ce, 0x26ce},
+	{0x26d4, 0x26d4}, {0x26ea, 0x26ea}, {0x26f2, 0x26f3}, {0x26f5, 0x26f5},
+	{0x26fa, 0x26fa}, {0x26fd, 0x26fd}, {0x2705, 0x2705}, {0x270a, 0x270b},
+	{0x2728, 0x2728}, {0x274c, 0x274c}, {0x274e, 0x274e}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27b0, 0x27b0}, {0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c}, {0x2b50, 0x2b50}, {0x2b55, 0x2b55}, {0x2e80, 0x303e},
+	{0x3041, 0x33ff}, {0x3400, 0x4dbf}, {0x4e00, 0x9fff}, {0xa000, 0xa4cf},
+	{0xa960, 0xa97f}, {0xac00, 0xd7a3}, {0xf900, 0xfaff}, {0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f}, {0xff00, 0xff60}, {0xffe0, 0xffe6}, {0x16fe0, 0x16fe4},
+	{0x17000, 0x18aff}, {0x1b000, 0x1b2ff}, {0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e}, {0x1f191, 0x1f19a}, {0x1f200, 0x1f251}, {0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff}, {0x1f7e0, 0x1f7eb}, {0x1f900, 0x1f9ff}, {0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd}, {0x30000, 0x3fffd},
+}
+
+func isWide(r rune) bool {
+	i := sort.Search(len(wideRanges), func(i int) bool {
+		return wideRanges[i][1] >= r
+	})
+	return i < len(wideRanges) && wideRanges[i][0] <= r
+}
+
+// runeWidth returns the number of columns r occupies on the screen.
+// Combining marks, zero-width characters and control characters take up no
+// space.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7f:
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || r >= 0x1160 && r <= 0x11ff || isSkinTone(r):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// isSkinTone reports whether r is an emoji skin tone modifier, which is shown
+// as part of the emoji before it.
+func isSkinTone(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+// zeroWidthJoiner joins the characters around it into one, as in emoji
+// sequences like 👩‍💻.
+const zeroWidthJoiner = 0x200d
+
+// extendsCharacter reports whether r is shown as part of the character before
+// it: a combining mark, such as an accent or a variation selector, a
+// zero-width joiner or non-joiner, a Hangul vowel or final consonant, or a
+// skin tone modifier.
+func extendsCharacter(r rune) bool {
+	return r >= 0x300 && (unicode.In(r, unicode.Mn, unicode.Me) ||
+		r == 0x200c || r == zeroWidthJoiner || r >= 0x1160 && r <= 0x11ff || isSkinTone(r))
+}
+
+// prevCharacter returns the offset in b of the start of the character that
+// ends at pos, together with the runes that extend it or are joined to it.
+func prevCharacter(b []byte, pos int) int {
+	for pos > 0 {
+		r, size := utf8.DecodeLastRune(b[:pos])
+		pos -= size
+		if extendsCharacter(r) {
+			continue
+		}
+		if prev, _ := utf8.DecodeLastRune(b[:pos]); pos > 0 && prev == zeroWidthJoiner {
+			continue
+		}
+		break
+	}
+	return pos
+}
+
+// nextCharacter returns the offset in b of the end of the character that
+// starts at pos, together with the runes that extend it or are joined to it.
+func nextCharacter(b []byte, pos int) int {
+	_, size := utf8.DecodeRune(b[pos:])
+	pos += size
+	for pos < len(b) {
+		r, size := utf8.DecodeRune(b[pos:])
+		if !extendsCharacter(r) {
+			break
+		}
+		pos += size
+		if r == zeroWidthJoiner && pos < len(b) {
+			_, size = utf8.DecodeRune(b[pos:])
+			pos += size
+		}
+	}
+	return pos
+}
+
+// stringWidth returns the number of columns s occupies on the screen, not
+// counting any escape sequences.
+func stringWidth(s string) int {
+	return bytesWidth([]byte(s))
+}
+
+// bytesWidth is like stringWidth, but for UTF-8 encoded bytes.
+func bytesWidth(b []byte) int {
+	width := 0
+	for len(b) > 0 {
+		if b[0] == KeyEscape {
+			n := escapeLength(b)
+			if n < 0 {
+				break
+			}
+			b = b[n:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		width += runeWidth(r)
+		b = b[size:]
+	}
+	return width
+}
+
+// truncate returns the longest prefix of s that takes up no more than width
+// columns. Escape sequences are all kept, so that styles are still reset.
+func truncate(s string, width int) string {
+	if stringWidth(s) <= width {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	w, full := 0, false
+	for i := 0; i < len(s); {
+		if s[i] == KeyEscape {
+			n := escapeLength([]byte(s[i:]))
+			if n < 0 {
+				break
+			}
+			b = append(b, s[i:i+n]...)
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if rw := runeWidth(r); !full && w+rw <= width {
+			b = append(b, s[i:i+size]...)
+			w += rw
+		} else {
+			full = true
+		}
+		i += size
+	}
+	return string(b)
+}
+
+// splitWidth splits s into lines of at most width columns. Escape sequences
+// stay in the line they appear in.
+func splitWidth(s string, width int) []string {
+	var lines []string
+	start, w := 0, 0
+	for i := 0; i < len(s); {
+		if s[i] == KeyEscape {
+			n := escapeLength([]byte(s[i:]))
+			if n < 0 {
+				break
+			}
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := runeWidth(r)
+		if w+rw > width && w > 0 {
+			lines = append(lines, s[start:i])
+			start, w = i, 0
+		}
+		w += rw
+		i += size
+	}
+	return append(lines, s[start:])
+}
+
+// RuneWidth returns the number of columns r occupies on the screen, as the
+// terminal assumes when laying out the line.
+func RuneWidth(r rune) int {
+	return runeWidth(r)
+}
+
+// StringWidth returns the number of columns s occupies on the screen, not
+// counting any escape sequences.
+func StringWidth(s string) int {
+	return stringWidth(s)
+}
+
+// Wrap breaks text into lines of at most width columns, separated by "\n".
+// Lines are broken between words where possible, and words longer than a
+// line are split. Existing line breaks are kept. Escape sequences take up
+// no space and stay with the word they precede or follow.
+func Wrap(text string, width int) string {
+	width = max(width, 1)
+	var b strings.Builder
+	for i, para := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		wrapParagraph(&b, para, width)
+	}
+	return b.String()
+}
+
+func wrapParagraph(b *strings.Builder, s string, width int) {
+	lineWidth := 0
+	var spaces, word strings.Builder
+	wordWidth := 0
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		if lineWidth > 0 && lineWidth+spaces.Len()+wordWidth > width {
+			b.WriteByte('\n')
+			lineWidth = 0
+		} else {
+			b.WriteString(spaces.String())
+			lineWidth += spaces.Len()
+		}
+		if lineWidth == 0 && wordWidth > width {
+			parts := splitWidth(word.String(), width)
+			b.WriteString(strings.Join(parts, "\n"))
+			lineWidth = stringWidth(parts[len(parts)-1])
+		} else {
+			b.WriteString(word.String())
+			lineWidth += wordWidth
+		}
+		spaces.Reset()
+		word.Reset()
+		wordWidth = 0
+	}
+
+	for i := 0; i < len(s); {
+		if s[i] == KeyEscape {
+			n := escapeLength([]byte(s[i:]))
+			if n < 0 {
+				n = len(s) - i
+			}
+			word.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == ' ' {
+			flush()
+			spaces.WriteByte(' ')
+		} else {
+			word.WriteString(s[i : i+size])
+			wordWidth += runeWidth(r)
+		}
+		i += size
+	}
+	flush()
+}
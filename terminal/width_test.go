@@ -0,0 +1,129 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminal
+
+import (
+	"io"
+	"testing"
+)
+
+var stringWidthTests = []struct {
+	in    string
+	width int
+}{
+	{"", 0},
+	{"> ", 2},
+	{"\x1b[1;32muser\x1b[0m> ", 6},
+	{"\x1b]0;title\x07$ ", 2},
+	{"λ> ", 3},
+	{"日本語> ", 8},
+	{"é", 1},
+	{"👍", 2},
+}
+
+func TestStringWidth(t *testing.T) {
+	for i, test := range stringWidthTests {
+		if width := stringWidth(test.in); width != test.width {
+			t.Errorf("test %d (%q): got %d, expected %d", i, test.in, width, test.width)
+		}
+	}
+}
+
+var truncateTests = []struct {
+	in    string
+	width int
+	out   string
+}{
+	{"hello", 10, "hello"},
+	{"hello", 3, "hel"},
+	{"日本語", 3, "日"},
+	{"日本a", 3, "日"},
+	{"\x1b[1mbold\x1b[0m", 2, "\x1b[1mbo\x1b[0m"},
+}
+
+func TestTruncate(t *testing.T) {
+	for i, test := range truncateTests {
+		if out := truncate(test.in, test.width); out != test.out {
+			t.Errorf("test %d: got %q, expected %q", i, out, test.out)
+		}
+	}
+}
+
+var wrapTests = []struct {
+	in    string
+	width int
+	out   string
+}{
+	{"", 10, ""},
+	{"the quick brown fox", 10, "the quick\nbrown fox"},
+	{"the quick brown fox", 9, "the quick\nbrown fox"},
+	{"one\ntwo three", 5, "one\ntwo\nthree"},
+	{"supercalifragilistic is long", 8, "supercal\nifragili\nstic is\nlong"},
+	{"日本語 テキスト", 6, "日本語\nテキス\nト"},
+	{"\x1b[1mbold\x1b[0m text", 4, "\x1b[1mbold\x1b[0m\ntext"},
+	{"  indented", 20, "  indented"},
+}
+
+func TestWrap(t *testing.T) {
+	for i, test := range wrapTests {
+		if out := Wrap(test.in, test.width); out != test.out {
+			t.Errorf("test %d: got %q, expected %q", i, out, test.out)
+		}
+	}
+}
+
+func TestStyledPromptCursor(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("ab")}
+	ss := NewTerminal(c, "\x1b[32m日本> \x1b[0m", true)
+	if _, err := ss.ReadLine(); err != io.EOF {
+		t.Fatalf("got error %v, expected EOF", err)
+	}
+	if ss.cursorX != 8 || ss.cursorY != 0 {
+		t.Errorf("cursor at %d,%d, expected 8,0", ss.cursorX, ss.cursorY)
+	}
+
+	// The cursor is already where the prompt and line end, so no movement
+	// is needed.
+	ss.moveCursorToPos(ss.pos)
+	if len(ss.outBuf) != 0 {
+		t.Errorf("unexpected cursor movement %q", ss.outBuf)
+	}
+}
+
+var characterTests = []string{
+	"a",
+	"e\u0301",
+	"\U0001f44d\U0001f3fd",
+	"\U0001f469\u200d\U0001f4bb",
+	"\u1112\u1161\u11ab",
+}
+
+func TestCharacterBoundaries(t *testing.T) {
+	for _, test := range characterTests {
+		// The character is followed by another one.
+		b := []byte(test + "x")
+		if got := prevCharacter(b, len(test)); got != 0 {
+			t.Errorf("prevCharacter(%q) = %d, expected 0", b, got)
+		}
+		if got := nextCharacter(b, 0); got != len(test) {
+			t.Errorf("nextCharacter(%q) = %d, expected %d", b, got, len(test))
+		}
+	}
+}
+
+func TestCombiningBackspace(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("\x1b[D\x7f\x1b[C\x1b[D\x1b[Dx\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ", true)
+	line, err := ss.ReadLineWithDefault("> ", "café👍🏽!")
+	if err != nil || line != "cafxé!" {
+		t.Errorf("got %q, %v", line, err)
+	}
+	if width := stringWidth("é👍🏽‍"); width != 3 {
+		t.Errorf("got width %d, expected the marks and modifiers to take no space", width)
+	}
+}
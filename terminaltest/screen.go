@@ -0,0 +1,57 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminaltest
+
+import "github.com/LordEliasTM/pseudo-terminal-go/emulator"
+
+// Screen interprets the output sent to a terminal and keeps track of what it
+// would display. It is an emulator.Screen with a simpler API for tests; use
+// Emulator for the appearance of cells and other details.
+type Screen struct {
+	s *emulator.Screen
+}
+
+// NewScreen returns an empty screen of the given size.
+func NewScreen(width, height int) *Screen {
+	return &Screen{s: emulator.New(width, height)}
+}
+
+// Emulator returns the emulated screen the output is shown on.
+func (s *Screen) Emulator() *emulator.Screen {
+	return s.s
+}
+
+// Size returns the size of the screen.
+func (s *Screen) Size() (width, height int) {
+	return s.s.Size()
+}
+
+// Cursor returns the position of the cursor, starting from 0,0 at the top
+// left.
+func (s *Screen) Cursor() (x, y int) {
+	x, y, _ = s.s.Cursor()
+	return x, y
+}
+
+// Line returns the text shown in row y, without trailing spaces.
+func (s *Screen) Line(y int) string {
+	return s.s.Line(y)
+}
+
+// Lines returns the text shown in each row, up to the last one that isn't
+// empty.
+func (s *Screen) Lines() []string {
+	return s.s.Lines()
+}
+
+// String returns the rows returned by Lines separated by newlines.
+func (s *Screen) String() string {
+	return s.s.String()
+}
+
+// Write interprets b as output to the terminal.
+func (s *Screen) Write(b []byte) (int, error) {
+	return s.s.Write(b)
+}
@@ -0,0 +1,146 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package terminaltest provides utilities for testing code that uses the
+// terminal package: a fake connection that plays back scripted key presses
+// and a model of the screen it renders to.
+package terminaltest
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Key sequences sent by common keys.
+const (
+	Enter     = "\r"
+	Tab       = "\t"
+	Backspace = "\x7f"
+	Escape    = "\x1b"
+	Up        = "\x1b[A"
+	Down      = "\x1b[B"
+	Right     = "\x1b[C"
+	Left      = "\x1b[D"
+	Home      = "\x1b[H"
+	End       = "\x1b[F"
+	CtrlC     = "\x03"
+	CtrlD     = "\x04"
+)
+
+// Ctrl returns the key sequence sent by Ctrl and the letter c.
+func Ctrl(c byte) string {
+	return string([]byte{c & 0x1f})
+}
+
+// Split splits s into pieces of n bytes, e.g. to send an escape sequence
+// over several reads.
+func Split(s string, n int) []string {
+	var pieces []string
+	for len(s) > n {
+		pieces = append(pieces, s[:n])
+		s = s[n:]
+	}
+	return append(pieces, s)
+}
+
+// Conn is a fake terminal connection. Reads return the input given to Type,
+// and what's written is recorded and shown on a Screen. It is safe to use
+// from several goroutines.
+type Conn struct {
+	lock   sync.Mutex
+	input  []string
+	output bytes.Buffer
+	screen *Screen
+}
+
+// NewConn returns a Conn with a screen of the given size.
+func NewConn(width, height int) *Conn {
+	return &Conn{screen: NewScreen(width, height)}
+}
+
+// Type adds input for the terminal to read. Each piece is returned by a
+// separate read, so an escape sequence split over several pieces arrives in
+// parts.
+func (c *Conn) Type(pieces ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, p := range pieces {
+		if p != "" {
+			c.input = append(c.input, p)
+		}
+	}
+}
+
+// Read returns the next piece of input, or as much of it as fits into b. Once
+// all input has been read, it returns io.EOF.
+func (c *Conn) Read(b []byte) (n int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.input) == 0 {
+		return 0, io.EOF
+	}
+	n = copy(b, c.input[0])
+	if n < len(c.input[0]) {
+		c.input[0] = c.input[0][n:]
+	} else {
+		c.input = c.input[1:]
+	}
+	return n, nil
+}
+
+// Write records b and shows it on the screen.
+func (c *Conn) Write(b []byte) (n int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.output.Write(b)
+	return c.screen.Write(b)
+}
+
+// Output returns everything written so far.
+func (c *Conn) Output() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.output.String()
+}
+
+// Screen returns the screen the output is shown on. It must not be used while
+// the Conn is being written to.
+func (c *Conn) Screen() *Screen {
+	return c.screen
+}
+
+// New returns a Terminal with the given prompt that is connected to a new
+// Conn of the given size.
+func New(prompt string, width, height int) (*terminal.Terminal, *Conn) {
+	c := NewConn(width, height)
+	t := terminal.NewTerminal(c, prompt, true)
+	t.SetSize(width, height)
+	return t, c
+}
+
+// ExpectScreen reports an error unless the rows shown on s, up to the last
+// one that isn't empty, are want.
+func ExpectScreen(t testing.TB, s *Screen, want ...string) {
+	t.Helper()
+	if got := s.Lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("screen shows\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// ExpectCursor reports an error unless the cursor of s is at x, y.
+func ExpectCursor(t testing.TB, s *Screen, x, y int) {
+	t.Helper()
+	if gx, gy := s.Cursor(); gx != x || gy != y {
+		t.Errorf("cursor at %d,%d, expected %d,%d", gx, gy, x, y)
+	}
+}
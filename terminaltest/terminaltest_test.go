@@ -0,0 +1,64 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminaltest
+
+import (
+	"io"
+	"testing"
+)
+
+func TestReadLine(t *testing.T) {
+	term, c := New("> ", 20, 5)
+	c.Type("hello", Left, Left)
+	c.Type(Split(Left, 1)...)
+	c.Type("X", Enter)
+
+	line, err := term.ReadLine()
+	if line != "heXllo" || err != nil {
+		t.Fatalf("got %q, %v, expected heXllo", line, err)
+	}
+	ExpectScreen(t, c.Screen(), "> heXllo")
+	ExpectCursor(t, c.Screen(), 0, 1)
+}
+
+func TestWrappedLine(t *testing.T) {
+	term, c := New("> ", 10, 5)
+	c.Type("abcdefghijkl")
+	if _, err := term.ReadLine(); err != io.EOF {
+		t.Fatalf("got %v, expected EOF", err)
+	}
+	ExpectScreen(t, c.Screen(), "> abcdefgh", "ijkl")
+	ExpectCursor(t, c.Screen(), 4, 1)
+}
+
+var screenTests = []struct {
+	out   string
+	lines []string
+	x, y  int
+}{
+	{"abc\r\ndef", []string{"abc", "def"}, 3, 1},
+	{"abc\x1b[2D\x1b[K", []string{"a"}, 1, 0},
+	{"abc\x1b[1;2Hx", []string{"axc"}, 2, 0},
+	{"日本\bx", []string{"日 x"}, 4, 0},
+	{"abcde", []string{"abcde"}, 4, 0},
+	{"abcdef", []string{"abcde", "f"}, 1, 1},
+	{"1\n2\n3\n4", []string{"1", " 2", "  3", "   4"}, 4, 3},
+	{"1\r\n2\r\n3\r\n4\r\n5", []string{"2", "3", "4", "5"}, 1, 3},
+	{"\x1b]0;title\x07\x1b[1mok\x1b[0m", []string{"ok"}, 2, 0},
+}
+
+func TestScreen(t *testing.T) {
+	for i, test := range screenTests {
+		s := NewScreen(5, 4)
+		// Writing a byte at a time splits every sequence.
+		for _, piece := range Split(test.out, 1) {
+			s.Write([]byte(piece))
+		}
+		ExpectScreen(t, s, test.lines...)
+		if x, y := s.Cursor(); x != test.x || y != test.y {
+			t.Errorf("test %d: cursor at %d,%d, expected %d,%d", i, x, y, test.x, test.y)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vtparse parses the output sent to a terminal, following the state
+// machine of DEC's VT500-series terminals as documented by Paul Williams
+// (https://vt100.net/emu/dec_ansi_parser). The byte stream is turned into
+// actions, such as printing a character or dispatching a control sequence,
+// which an emulator then carries out.
+package vtparse
+
+import "unicode/utf8"
+
+// ActionType says what kind of action an Action is.
+type ActionType int
+
+const (
+	// Print displays Rune.
+	Print ActionType = iota
+	// Execute carries out the C0 control function Byte, e.g. a line feed.
+	Execute
+	// CSIDispatch carries out the control sequence given by Private,
+	// Params, Intermediates and Final.
+	CSIDispatch
+	// ESCDispatch carries out the escape sequence given by Intermediates
+	// and Final.
+	ESCDispatch
+	// OSCDispatch carries out the operating system command in Data.
+	OSCDispatch
+	// DCSHook starts a device control string given by Private, Params,
+	// Intermediates and Final. Its data follows in DCSPut actions.
+	DCSHook
+	// DCSPut passes on Byte, part of the data of a device control string.
+	DCSPut
+	// DCSUnhook ends a device control string.
+	DCSUnhook
+)
+
+var actionNames = [...]string{"Print", "Execute", "CSIDispatch", "ESCDispatch", "OSCDispatch", "DCSHook", "DCSPut", "DCSUnhook"}
+
+func (t ActionType) String() string {
+	if t < 0 || int(t) >= len(actionNames) {
+		return "ActionType(?)"
+	}
+	return actionNames[t]
+}
+
+// Action is an action parsed from the output. Which fields are set depends on
+// its Type. The slices point into the parser and are only valid until the
+// action handler returns.
+type Action struct {
+	Type ActionType
+	Rune rune
+	Byte byte
+	// Private is the private marker, one of "<=>?", at the start of
+	// the parameters, or 0.
+	Private byte
+	// Params holds the numeric parameters. Parameters that were left
+	// out are 0.
+	Params        []int
+	Intermediates []byte
+	Final         byte
+	Data          []byte
+}
+
+// Param returns parameter i, or def if it's missing or 0, as is the
+// convention for most control sequences.
+func (a *Action) Param(i, def int) int {
+	if i < len(a.Params) && a.Params[i] != 0 {
+		return a.Params[i]
+	}
+	return def
+}
+
+type state int
+
+const (
+	ground state = iota
+	escape
+	escapeIntermediate
+	csiEntry
+	csiParam
+	csiIntermediate
+	csiIgnore
+	dcsEntry
+	dcsParam
+	dcsIntermediate
+	dcsPassthrough
+	dcsIgnore
+	oscString
+	sosPMAPCString
+)
+
+const (
+	maxParams        = 16
+	maxIntermediates = 2
+	maxParamValue    = 65535
+	maxOSC           = 64 << 10
+)
+
+// Parser parses output for a terminal and passes the actions to a handler.
+type Parser struct {
+	handle func(*Action)
+	state  state
+	action Action
+
+	params        [maxParams]int
+	nparams       int
+	intermediates [maxIntermediates]byte
+	nintermediate int
+	// tooMany is set when more intermediates arrived than are kept; the
+	// sequence is then ignored.
+	tooMany bool
+	private byte
+	osc     []byte
+	// utf8 holds the start of a UTF-8 sequence split across writes.
+	utf8  [utf8.UTFMax]byte
+	nutf8 int
+}
+
+// NewParser returns a Parser in the ground state that passes each action to
+// handle.
+func NewParser(handle func(*Action)) *Parser {
+	return &Parser{handle: handle}
+}
+
+// Write parses b. Sequences may be split across writes. It never returns an
+// error.
+func (p *Parser) Write(b []byte) (n int, err error) {
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if p.state == ground && c >= 0x80 || p.nutf8 > 0 {
+			i += p.decodeUTF8(b[i:]) - 1
+			continue
+		}
+		p.advance(c)
+	}
+	return len(b), nil
+}
+
+// decodeUTF8 prints the rune at the start of b, which may continue one
+// begun in an earlier write, or keeps it if b is too short. It returns the
+// number of bytes of b used.
+func (p *Parser) decodeUTF8(b []byte) int {
+	n := copy(p.utf8[p.nutf8:], b)
+	buf := p.utf8[:p.nutf8+n]
+	if !utf8.FullRune(buf) {
+		p.nutf8 += n
+		return n
+	}
+	r, size := utf8.DecodeRune(buf)
+	// An invalid sequence is printed as a single utf8.RuneError.
+	used := max(size-p.nutf8, 0)
+	p.nutf8 = 0
+	p.print(r)
+	return used
+}
+
+func (p *Parser) print(r rune) {
+	p.action = Action{Type: Print, Rune: r}
+	p.handle(&p.action)
+}
+
+func (p *Parser) execute(c byte) {
+	p.action = Action{Type: Execute, Byte: c}
+	p.handle(&p.action)
+}
+
+func (p *Parser) clear() {
+	p.nparams = 0
+	p.params[0] = 0
+	p.nintermediate = 0
+	p.tooMany = false
+	p.private = 0
+}
+
+func (p *Parser) collect(c byte) {
+	if p.nintermediate == maxIntermediates {
+		p.tooMany = true
+		return
+	}
+	p.intermediates[p.nintermediate] = c
+	p.nintermediate++
+}
+
+func (p *Parser) param(c byte) {
+	if p.nparams == 0 {
+		p.nparams = 1
+	}
+	if c == ';' {
+		if p.nparams < maxParams {
+			p.params[p.nparams] = 0
+			p.nparams++
+		}
+		return
+	}
+	v := &p.params[p.nparams-1]
+	*v = min(*v*10+int(c-'0'), maxParamValue)
+}
+
+func (p *Parser) dispatch(t ActionType, final byte) {
+	if p.tooMany {
+		return
+	}
+	p.action = Action{
+		Type:          t,
+		Private:       p.private,
+		Params:        p.params[:p.nparams],
+		Intermediates: p.intermediates[:p.nintermediate],
+		Final:         final,
+	}
+	p.handle(&p.action)
+}
+
+// enter changes to state s, carrying out the actions on leaving the old state
+// and entering the new one.
+func (p *Parser) enter(s state) {
+	switch p.state {
+	case oscString:
+		p.action = Action{Type: OSCDispatch, Data: p.osc}
+		p.handle(&p.action)
+	case dcsPassthrough:
+		p.action = Action{Type: DCSUnhook}
+		p.handle(&p.action)
+	}
+	p.state = s
+	switch s {
+	case escape, csiEntry, dcsEntry:
+		p.clear()
+	case oscString:
+		p.osc = p.osc[:0]
+	}
+}
+
+func isExecuted(c byte) bool {
+	return c < 0x18 || c == 0x19 || c >= 0x1c && c < 0x20
+}
+
+func (p *Parser) advance(c byte) {
+	// Transitions from anywhere.
+	switch c {
+	case 0x18, 0x1a:
+		p.enter(ground)
+		p.execute(c)
+		return
+	case 0x1b:
+		p.enter(escape)
+		return
+	}
+
+	switch p.state {
+	case ground:
+		switch {
+		case isExecuted(c):
+			p.execute(c)
+		case c < 0x7f:
+			p.print(rune(c))
+		}
+
+	case escape:
+		switch {
+		case isExecuted(c):
+			p.execute(c)
+		case c < 0x30:
+			p.collect(c)
+			p.state = escapeIntermediate
+		case c == '[':
+			p.enter(csiEntry)
+		case c == ']':
+			p.enter(oscString)
+		case c == 'P':
+			p.enter(dcsEntry)
+		case c == 'X' || c == '^' || c == '_':
+			p.enter(sosPMAPCString)
+		case c < 0x7f:
+			p.dispatch(ESCDispatch, c)
+			p.enter(ground)
+		}
+
+	case escapeIntermediate:
+		switch {
+		case isExecuted(c):
+			p.execute(c)
+		case c < 0x30:
+			p.collect(c)
+		case c < 0x7f:
+			p.dispatch(ESCDispatch, c)
+			p.enter(ground)
+		}
+
+	case csiEntry, csiParam, csiIntermediate:
+		switch {
+		case isExecuted(c):
+			p.execute(c)
+		case c < 0x30:
+			p.collect(c)
+			p.state = csiIntermediate
+		case p.state == csiIntermediate && c < 0x40:
+			p.state = csiIgnore
+		case c <= '9' || c == ';':
+			p.param(c)
+			p.state = csiParam
+		case c == ':':
+			p.state = csiIgnore
+		case c < 0x40:
+			if p.state == csiEntry {
+				p.private = c
+				p.state = csiParam
+			} else {
+				p.state = csiIgnore
+			}
+		case c < 0x7f:
+			p.dispatch(CSIDispatch, c)
+			p.enter(ground)
+		}
+
+	case csiIgnore:
+		switch {
+		case isExecuted(c):
+			p.execute(c)
+		case c >= 0x40 && c < 0x7f:
+			p.enter(ground)
+		}
+
+	case dcsEntry, dcsParam, dcsIntermediate:
+		switch {
+		case isExecuted(c):
+			// Ignored.
+		case c < 0x30:
+			p.collect(c)
+			p.state = dcsIntermediate
+		case p.state == dcsIntermediate && c < 0x40:
+			p.state = dcsIgnore
+		case c <= '9' || c == ';':
+			p.param(c)
+			p.state = dcsParam
+		case c == ':':
+			p.state = dcsIgnore
+		case c < 0x40:
+			if p.state == dcsEntry {
+				p.private = c
+				p.state = dcsParam
+			} else {
+				p.state = dcsIgnore
+			}
+		case c < 0x7f:
+			if p.tooMany {
+				p.state = dcsIgnore
+				return
+			}
+			p.dispatch(DCSHook, c)
+			p.state = dcsPassthrough
+		}
+
+	case dcsPassthrough:
+		if c != 0x7f {
+			p.action = Action{Type: DCSPut, Byte: c}
+			p.handle(&p.action)
+		}
+
+	case dcsIgnore, sosPMAPCString:
+		// Ignored until the string terminator.
+
+	case oscString:
+		switch {
+		case c == 0x07:
+			// xterm also ends OSC strings with BEL.
+			p.enter(ground)
+		case c >= 0x20 && len(p.osc) < maxOSC:
+			p.osc = append(p.osc, c)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vtparse
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// describe returns a short description of a, for comparing with the
+// expectations below.
+func describe(a *Action) string {
+	switch a.Type {
+	case Print:
+		return fmt.Sprintf("print %q", a.Rune)
+	case Execute, DCSPut:
+		return fmt.Sprintf("%v %#x", a.Type, a.Byte)
+	case OSCDispatch:
+		return fmt.Sprintf("osc %q", a.Data)
+	case DCSUnhook:
+		return "unhook"
+	}
+	s := fmt.Sprintf("%v %v", a.Type, a.Params)
+	if a.Private != 0 {
+		s += " " + string(a.Private)
+	}
+	return s + " " + string(a.Intermediates) + string(a.Final)
+}
+
+var parseTests = []struct {
+	in   string
+	want []string
+}{
+	{"a\r\n", []string{"print 'a'", "Execute 0xd", "Execute 0xa"}},
+	{"日\xff", []string{"print '日'", "print '\ufffd'"}},
+	{"\x1b[1;32m", []string{"CSIDispatch [1 32] m"}},
+	{"\x1b[;5H", []string{"CSIDispatch [0 5] H"}},
+	{"\x1b[?25l", []string{"CSIDispatch [25] ? l"}},
+	{"\x1b[A", []string{"CSIDispatch [] A"}},
+	{"\x1b[2 q", []string{"CSIDispatch [2]  q"}},
+	{"\x1b[1\n2A", []string{"Execute 0xa", "CSIDispatch [12] A"}},
+	{"\x1b[1?2Ax", []string{"print 'x'"}},
+	{"\x1b[1\x18x", []string{"Execute 0x18", "print 'x'"}},
+	{"\x1b(B\x1b7", []string{"ESCDispatch [] (B", "ESCDispatch [] 7"}},
+	{"\x1b]0;tïtle\x07", []string{"osc \"0;tïtle\""}},
+	{"\x1b]2;x\x1b\\", []string{"osc \"2;x\"", "ESCDispatch [] \\"}},
+	{"\x1bP1$rq\x1b\\", []string{"DCSHook [1] $r", "DCSPut 0x71", "unhook", "ESCDispatch [] \\"}},
+	{"\x1b_apc\x1b\\z", []string{"ESCDispatch [] \\", "print 'z'"}},
+}
+
+func TestParse(t *testing.T) {
+	for _, split := range []bool{false, true} {
+		for i, test := range parseTests {
+			var got []string
+			p := NewParser(func(a *Action) {
+				got = append(got, describe(a))
+			})
+			if split {
+				for j := 0; j < len(test.in); j++ {
+					p.Write([]byte{test.in[j]})
+				}
+			} else {
+				p.Write([]byte(test.in))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("test %d (split %v): got %q, expected %q", i, split, got, test.want)
+			}
+		}
+	}
+}
+
+func TestParam(t *testing.T) {
+	a := Action{Params: []int{0, 7}}
+	if a.Param(0, 1) != 1 || a.Param(1, 1) != 7 || a.Param(2, 3) != 3 {
+		t.Errorf("Param returned wrong values for %v", a.Params)
+	}
+}
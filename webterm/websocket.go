@@ -0,0 +1,241 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webterm
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// The server side of the WebSocket protocol, RFC 6455, as far as a terminal
+// needs it.
+
+// acceptGUID is appended to the client's key to compute the accept key.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessage limits the size of messages from the client.
+const maxMessage = 1 << 20
+
+// Opcodes of frames.
+const (
+	opContinuation = 0
+	opText         = 1
+	opBinary       = 2
+	opClose        = 8
+	opPing         = 9
+	opPong         = 10
+)
+
+var (
+	errProtocol        = errors.New("webterm: websocket protocol error")
+	errMessageTooLarge = errors.New("webterm: websocket message too large")
+	errBadOrigin       = errors.New("webterm: cross-origin request")
+	errNotWebSocket    = errors.New("webterm: not a websocket handshake")
+)
+
+// websocket is a WebSocket connection.
+type websocket struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	wlock  sync.Mutex
+	closed bool
+}
+
+// hasToken reports whether the comma separated header value contains token.
+func hasToken(header, token string) bool {
+	for _, s := range strings.Split(header, ",") {
+		if strings.EqualFold(strings.TrimSpace(s), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrigin reports whether the request comes from a page served by the
+// same host, as browsers send the Origin header with WebSocket requests
+// made by any page. Requests without one don't come from a browser.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// upgrade completes the WebSocket handshake for r. On failure it responds
+// with an error itself.
+func upgrade(w http.ResponseWriter, r *http.Request, checkOrigin func(*http.Request) bool) (*websocket, error) {
+	if r.Method != http.MethodGet ||
+		!hasToken(r.Header.Get("Connection"), "upgrade") ||
+		!hasToken(r.Header.Get("Upgrade"), "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" ||
+		r.Header.Get("Sec-WebSocket-Key") == "" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "websocket handshake expected", http.StatusBadRequest)
+		return nil, errNotWebSocket
+	}
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, errBadOrigin
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection can't be upgraded", http.StatusInternalServerError)
+		return nil, errNotWebSocket
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + acceptGUID))
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &websocket{conn: conn, r: brw.Reader}, nil
+}
+
+// readMessage returns the next text or binary message. Pings are answered,
+// and a close frame is answered and returned as io.EOF.
+func (ws *websocket) readMessage() (op byte, msg []byte, err error) {
+	for {
+		fin, frameOp, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch frameOp {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ws.writeFrame(opClose, nil)
+			return 0, nil, io.EOF
+		case opText, opBinary:
+			if op != 0 {
+				return 0, nil, errProtocol
+			}
+			op = frameOp
+		case opContinuation:
+			if op == 0 {
+				return 0, nil, errProtocol
+			}
+		default:
+			return 0, nil, errProtocol
+		}
+		if len(msg)+len(payload) > maxMessage {
+			return 0, nil, errMessageTooLarge
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return op, msg, nil
+		}
+	}
+}
+
+// readFrame reads a frame from the client, which must be masked.
+func (ws *websocket) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [14]byte
+	if _, err := io.ReadFull(ws.r, head[:2]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0f
+	if head[0]&0x70 != 0 || head[1]&0x80 == 0 {
+		// Reserved bits are set, or the frame isn't masked.
+		return false, 0, nil, errProtocol
+	}
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		if _, err := io.ReadFull(ws.r, head[:2]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(head[:2]))
+	case 127:
+		if _, err := io.ReadFull(ws.r, head[:8]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(head[:8])
+	}
+	if op >= opClose && (n > 125 || !fin) {
+		return false, 0, nil, errProtocol
+	}
+	if n > maxMessage {
+		return false, 0, nil, errMessageTooLarge
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.r, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(ws.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame sends a single unfragmented frame.
+func (ws *websocket) writeFrame(op byte, payload []byte) error {
+	ws.wlock.Lock()
+	defer ws.wlock.Unlock()
+
+	if ws.closed {
+		return net.ErrClosed
+	}
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|op)
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+	_, err := ws.conn.Write(frame)
+	if op == opClose {
+		ws.closed = true
+	}
+	return err
+}
+
+// close sends a close frame, unless one was sent already, and closes the
+// connection.
+func (ws *websocket) close() error {
+	ws.writeFrame(opClose, nil)
+	return ws.conn.Close()
+}
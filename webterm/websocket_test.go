@@ -0,0 +1,180 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webterm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// client is the browser's side of a WebSocket connection.
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dial connects to the server, sending origin as the Origin header if it's
+// not empty, and returns the status of the response.
+func dial(t *testing.T, srv *httptest.Server, origin string) (*client, int) {
+	t.Helper()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		if got, want := resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+			t.Errorf("got accept key %q, expected %q", got, want)
+		}
+	}
+	return &client{conn, r}, resp.StatusCode
+}
+
+// send sends a masked frame.
+func (c *client) send(fin bool, op byte, payload string) {
+	head := op
+	if fin {
+		head |= 0x80
+	}
+	frame := []byte{head}
+	if len(payload) < 126 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i := 0; i < len(payload); i++ {
+		frame = append(frame, payload[i]^mask[i%4])
+	}
+	c.conn.Write(frame)
+}
+
+// receive reads a frame from the server.
+func (c *client) receive(t *testing.T) (op byte, payload string) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(head[1] & 0x7f)
+	if n == 126 {
+		var b [2]byte
+		io.ReadFull(c.r, b[:])
+		n = int(binary.BigEndian.Uint16(b[:]))
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(c.r, b); err != nil {
+		t.Fatal(err)
+	}
+	return head[0] & 0x0f, string(b)
+}
+
+// echoServer echoes messages until the connection fails.
+func echoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.close()
+		for {
+			_, msg, err := ws.readMessage()
+			if err != nil {
+				return
+			}
+			ws.writeFrame(opBinary, msg)
+		}
+	}))
+}
+
+func TestMessages(t *testing.T) {
+	srv := echoServer()
+	defer srv.Close()
+	c, status := dial(t, srv, "")
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("got status %d", status)
+	}
+
+	c.send(true, opText, "hello")
+	if op, msg := c.receive(t); op != opBinary || msg != "hello" {
+		t.Errorf("got %d %q, expected the message back", op, msg)
+	}
+
+	// A fragmented message, with a ping in between, and a long one.
+	c.send(false, opBinary, "a")
+	c.send(true, opPing, "p")
+	c.send(true, opContinuation, "b")
+	if op, msg := c.receive(t); op != opPong || msg != "p" {
+		t.Errorf("got %d %q, expected a pong", op, msg)
+	}
+	if _, msg := c.receive(t); msg != "ab" {
+		t.Errorf("got %q, expected ab", msg)
+	}
+	long := strings.Repeat("x", 1000)
+	c.send(true, opBinary, long)
+	if _, msg := c.receive(t); msg != long {
+		t.Errorf("got %d bytes, expected 1000", len(msg))
+	}
+
+	c.send(true, opClose, "")
+	if op, _ := c.receive(t); op != opClose {
+		t.Errorf("got opcode %d, expected the close to be answered", op)
+	}
+}
+
+func TestUnmaskedFrame(t *testing.T) {
+	srv := echoServer()
+	defer srv.Close()
+	c, _ := dial(t, srv, "")
+	c.conn.Write([]byte{0x82, 1, 'x'})
+	if op, _ := c.receive(t); op != opClose {
+		t.Errorf("got opcode %d, expected a close", op)
+	}
+	if _, err := c.r.ReadByte(); err != io.EOF {
+		t.Errorf("got %v, expected the connection to be closed", err)
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	srv := echoServer()
+	defer srv.Close()
+	if _, status := dial(t, srv, "http://evil.example"); status != http.StatusForbidden {
+		t.Errorf("got status %d for another origin, expected 403", status)
+	}
+	if _, status := dial(t, srv, srv.URL); status != http.StatusSwitchingProtocols {
+		t.Errorf("got status %d for the same origin, expected 101", status)
+	}
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d for a plain request, expected 400", resp.StatusCode)
+	}
+}
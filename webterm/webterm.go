@@ -0,0 +1,188 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package webterm connects browser-based consoles, such as xterm.js with its
+// attach addon, to a Terminal or a program on a pseudo-terminal over a
+// WebSocket.
+//
+// The browser sends what the user types as text or binary messages, and
+// reports the size of its terminal with text messages holding JSON like
+//
+//	{"type": "resize", "cols": 80, "rows": 24}
+//
+// The output is sent to the browser as binary messages.
+package webterm
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os/exec"
+	"sync"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/pty"
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+// Conn is a WebSocket connection to a browser-based terminal.
+type Conn struct {
+	ws *websocket
+	// pending holds input from a message that didn't fit the buffer passed
+	// to Read.
+	pending []byte
+
+	lock          sync.Mutex
+	width, height int
+	resized       func(width, height int)
+}
+
+// controlMessage is a message from the browser that isn't input.
+type controlMessage struct {
+	Type string `json:"type"`
+	Cols int    `json:"cols"`
+	Rows int    `json:"rows"`
+}
+
+// Upgrade turns the request into a WebSocket connection. checkOrigin decides
+// whether a request from a browser may connect; if it's nil, only pages
+// served by the same host may. If the handshake fails, Upgrade responds with
+// an error itself.
+func Upgrade(w http.ResponseWriter, r *http.Request, checkOrigin func(*http.Request) bool) (*Conn, error) {
+	ws, err := upgrade(w, r, checkOrigin)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{ws: ws}, nil
+}
+
+// OnResize sets a function to be called from Read when the browser reports
+// the size of its terminal. If it was reported before, f is called right
+// away.
+func (c *Conn) OnResize(f func(width, height int)) {
+	c.lock.Lock()
+	c.resized = f
+	width, height := c.width, c.height
+	c.lock.Unlock()
+
+	if f != nil && width > 0 && height > 0 {
+		f(width, height)
+	}
+}
+
+// Size returns the size of the browser's terminal, or zeros if it hasn't been
+// reported.
+func (c *Conn) Size() (width, height int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.width, c.height
+}
+
+// Read reads what the user typed. It returns io.EOF when the browser closes
+// the connection.
+func (c *Conn) Read(b []byte) (n int, err error) {
+	for len(c.pending) == 0 {
+		op, msg, err := c.ws.readMessage()
+		if err != nil {
+			return 0, err
+		}
+		if op == opText && c.control(msg) {
+			continue
+		}
+		c.pending = msg
+	}
+	n = copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// control handles msg if it's a control message and reports whether it was.
+// Input that happens to look like JSON isn't mistaken for one unless it's a
+// resize with a valid size.
+func (c *Conn) control(msg []byte) bool {
+	if len(msg) == 0 || msg[0] != '{' {
+		return false
+	}
+	var m controlMessage
+	if json.Unmarshal(msg, &m) != nil || m.Type != "resize" || m.Cols <= 0 || m.Rows <= 0 {
+		return false
+	}
+
+	c.lock.Lock()
+	c.width, c.height = m.Cols, m.Rows
+	f := c.resized
+	c.lock.Unlock()
+
+	if f != nil {
+		f(m.Cols, m.Rows)
+	}
+	return true
+}
+
+// Write sends b to the browser in a binary message.
+func (c *Conn) Write(b []byte) (n int, err error) {
+	if err := c.ws.writeFrame(opBinary, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.ws.close()
+}
+
+// TerminalHandler returns a handler connecting each browser to a new
+// Terminal using prompt, which is passed to serve. The terminal's size
+// follows the browser's, and the connection is closed when serve returns.
+func TerminalHandler(prompt string, serve func(t *terminal.Terminal, r *http.Request)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		t := terminal.NewTerminal(c, prompt, true)
+		t.SetTranslateNewlines(true)
+		c.OnResize(func(width, height int) {
+			t.SetSize(width, height)
+			t.Refresh()
+		})
+		serve(t, r)
+	})
+}
+
+// CommandHandler returns a handler running the program returned by command on
+// a pseudo-terminal for each browser. The program is killed when the browser
+// disconnects, and the connection is closed when the program ends.
+func CommandHandler(command func(r *http.Request) *exec.Cmd) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		cmd := command(r)
+		f, err := pty.Start(cmd)
+		if err != nil {
+			c.Write([]byte(err.Error() + "\r\n"))
+			return
+		}
+		defer f.Close()
+		pty.SetSize(f, 80, 24)
+		c.OnResize(func(width, height int) {
+			pty.SetSize(f, width, height)
+		})
+
+		go func() {
+			io.Copy(f, c)
+			// The browser is gone.
+			cmd.Process.Kill()
+		}()
+		io.Copy(c, f)
+		cmd.Wait()
+	})
+}
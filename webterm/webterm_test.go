@@ -0,0 +1,80 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webterm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/LordEliasTM/pseudo-terminal-go/terminal"
+)
+
+func TestTerminalHandler(t *testing.T) {
+	lines := make(chan string, 1)
+	srv := httptest.NewServer(TerminalHandler("> ", func(term *terminal.Terminal, r *http.Request) {
+		line, _ := term.ReadLine()
+		term.Write([]byte("got " + line + "\n"))
+		lines <- line
+	}))
+	defer srv.Close()
+
+	c, _ := dial(t, srv, "")
+	c.send(true, opText, `{"type": "resize", "cols": 100, "rows": 30}`)
+	// Input that isn't a resize is typed.
+	c.send(true, opText, `{"type": "other"}`)
+	c.send(true, opBinary, "\r")
+
+	if line := <-lines; line != `{"type": "other"}` {
+		t.Errorf("got line %q", line)
+	}
+	// The output ends with the line written by serve, with "\r\n", and
+	// the connection is closed.
+	var out strings.Builder
+	for {
+		op, msg := c.receive(t)
+		if op == opClose {
+			break
+		}
+		out.WriteString(msg)
+	}
+	if !strings.HasSuffix(out.String(), "got {\"type\": \"other\"}\r\n") {
+		t.Errorf("got output %q", out.String())
+	}
+}
+
+func TestCommandHandler(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+	srv := httptest.NewServer(CommandHandler(func(r *http.Request) *exec.Cmd {
+		return exec.Command("sh", "-c", `echo ready; read x; echo "got $x"`)
+	}))
+	defer srv.Close()
+
+	c, _ := dial(t, srv, "")
+	c.send(true, opText, `{"type": "resize", "cols": 100, "rows": 30}`)
+	var out strings.Builder
+	sent := false
+	for {
+		op, msg := c.receive(t)
+		if op == opClose {
+			break
+		}
+		out.WriteString(msg)
+		if !sent && strings.Contains(out.String(), "\n") {
+			c.send(true, opBinary, "yes\r")
+			sent = true
+		}
+	}
+	if strings.Contains(out.String(), "not supported") {
+		t.Skip(out.String())
+	}
+	if !strings.Contains(out.String(), "got yes") {
+		t.Errorf("got output %q, expected the program's answer", out.String())
+	}
+}